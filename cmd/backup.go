@@ -14,19 +14,25 @@ import (
 	"github.com/harshpatel5940/stash/internal/config"
 	"github.com/harshpatel5940/stash/internal/crypto"
 	"github.com/harshpatel5940/stash/internal/defaults"
+	"github.com/harshpatel5940/stash/internal/desktop"
 	"github.com/harshpatel5940/stash/internal/docker"
+	"github.com/harshpatel5940/stash/internal/editors"
 	stasherrors "github.com/harshpatel5940/stash/internal/errors"
 	"github.com/harshpatel5940/stash/internal/finder"
 	"github.com/harshpatel5940/stash/internal/fonts"
 	"github.com/harshpatel5940/stash/internal/gittracker"
+	"github.com/harshpatel5940/stash/internal/gpg"
 	"github.com/harshpatel5940/stash/internal/incremental"
 	"github.com/harshpatel5940/stash/internal/kubernetes"
 	"github.com/harshpatel5940/stash/internal/metadata"
 	"github.com/harshpatel5940/stash/internal/packager"
+	"github.com/harshpatel5940/stash/internal/platform"
 	"github.com/harshpatel5940/stash/internal/recovery"
+	"github.com/harshpatel5940/stash/internal/secrets"
 	"github.com/harshpatel5940/stash/internal/stats"
 	"github.com/harshpatel5940/stash/internal/ui"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 var (
@@ -39,6 +45,7 @@ var (
 	backupKeepCount    int
 	backupSkipBrowsers bool
 	backupIncremental  bool
+	backupGitBundles   bool
 )
 
 var backupCmd = &cobra.Command{
@@ -48,19 +55,29 @@ var backupCmd = &cobra.Command{
 
 The backup will include:
   - Dotfiles from home directory
-  - Secret directories (SSH, GPG, AWS)
+  - Secret directories (SSH, AWS) and GPG keys exported with gpg
   - .env and .pem files from your projects
   - Application configs (~/.config)
   - Package manager lists (Brewfile, MAS, VS Code, npm)
-  - Non-Homebrew apps detection (warns about manually installed apps)
+  - Non-Homebrew apps detection (warns about manually installed apps, macOS)
   - macOS system defaults/preferences (Dock, Finder, trackpad, etc.)
+  - Editor settings, keybindings, snippets and profiles (VS Code,
+    VS Code Insiders, Cursor, Zed, JetBrains IDEs)
   - Shell history (.zsh_history, .bash_history)
   - Browser data (Chrome, Firefox, Safari bookmarks & settings)
   - Git repositories tracking (list of all repos with clone scripts)
-  - Custom fonts from ~/Library/Fonts
+  - With --git-bundles: unpushed commits, stashes, local-only repos and
+    uncommitted changes (untracked files included) as git bundles and patches
+  - Custom fonts (~/Library/Fonts, or ~/.local/share/fonts on Linux)
+
+On Linux, browser data and fonts are read from XDG locations and
+macOS-only items (defaults, App Store apps) are skipped.
+
+The summary flags SSH keys without a passphrase, expired certificates and
+plaintext credentials (see 'stash secrets audit').
 
 The backup is compressed as tar.gz and encrypted with age.
-Perfect for quickly restoring your Mac anywhere.`,
+Perfect for quickly restoring your machine anywhere.`,
 	RunE: runBackup,
 }
 
@@ -75,6 +92,7 @@ func init() {
 	backupCmd.Flags().IntVar(&backupKeepCount, "keep", 5, "Number of backups to keep (older ones auto-deleted)")
 	backupCmd.Flags().BoolVar(&backupSkipBrowsers, "skip-browsers", false, "Skip browser data backup")
 	backupCmd.Flags().BoolVarP(&backupIncremental, "incremental", "i", false, "Perform incremental backup (only changed files)")
+	backupCmd.Flags().BoolVar(&backupGitBundles, "git-bundles", false, "Save unpushed commits and uncommitted changes of git repos (or set git.bundles)")
 }
 
 func runBackup(cmd *cobra.Command, args []string) error {
@@ -181,12 +199,15 @@ func runBackup(cmd *cobra.Command, args []string) error {
 		"dotfiles",
 		"ssh",
 		"gpg",
+		"gpg-keys",
 		"aws",
 		"config",
 		"env-files",
 		"pem-files",
 		"packages",
 		"macos-defaults",
+		"desktop-settings",
+		"editor-settings",
 		"shell-history",
 		"browser-data",
 		"git-repos",
@@ -210,20 +231,52 @@ func runBackup(cmd *cobra.Command, args []string) error {
 		Func func() error
 	}
 
+	// Repos with work no remote has, warned about in the summary
+	var localOnlyRepos []gittracker.GitRepo
+	// .pem files found, audited for the summary along with other secrets
+	var pemFiles []string
+
+	// gpg may ask for passphrases, so its export runs on its own before the
+	// parallel tasks
+	if err := backupGPG(tempDir, meta, arch, cfg); err != nil {
+		ui.PrintWarning("GPG: %v", err)
+	}
+
 	tasks := []backupTask{
 		{"Dotfiles", func() error { return backupDotfiles(tempDir, meta, arch, cfg, incrMgr, doIncrementalBackup) }},
 		{"Secrets", func() error { return backupSecrets(tempDir, meta, arch, incrMgr, doIncrementalBackup, cfg) }},
 		{"EnvFiles", func() error { return backupEnvFiles(tempDir, meta, arch, cfg, incrMgr, doIncrementalBackup) }},
-		{"PemFiles", func() error { return backupPemFiles(tempDir, meta, arch, cfg, incrMgr, doIncrementalBackup) }},
+		{"PemFiles", func() error {
+			var err error
+			pemFiles, err = backupPemFiles(tempDir, meta, arch, cfg, incrMgr, doIncrementalBackup)
+			return err
+		}},
 		{"Packages", func() error { return backupPackages(tempDir, meta) }},
-		{"MacOSDefaults", func() error { return backupMacOSDefaults(tempDir, meta, cfg) }},
 		{"ShellHistory", func() error { return backupShellHistory(tempDir, meta, arch, incrMgr, doIncrementalBackup, cfg) }},
-		{"GitRepos", func() error { return backupGitRepos(tempDir, meta, cfg) }},
+		{"GitRepos", func() error {
+			var err error
+			localOnlyRepos, err = backupGitRepos(tempDir, meta, cfg)
+			return err
+		}},
 		{"Fonts", func() error { return backupFonts(tempDir, meta) }},
 		{"Docker", func() error { return backupDocker(tempDir, meta, cfg) }},
 		{"Kubernetes", func() error { return backupKubernetes(tempDir, meta) }},
 	}
 
+	if platform.Current().IsMacOS() {
+		tasks = append(tasks, backupTask{"MacOSDefaults", func() error { return backupMacOSDefaults(tempDir, meta, cfg) }})
+	} else {
+		ui.PrintVerbose("Skipping macOS defaults (not running on macOS)")
+	}
+
+	if platform.Current().IsLinux() && cfg.IsDesktopSettingsEnabled() {
+		tasks = append(tasks, backupTask{"DesktopSettings", func() error { return backupDesktopSettings(tempDir, cfg) }})
+	}
+
+	if cfg.IsEditorsEnabled() {
+		tasks = append(tasks, backupTask{"EditorSettings", func() error { return backupEditorSettings(tempDir) }})
+	}
+
 	if cfg.IsBrowsersEnabled() && !backupSkipBrowsers {
 		tasks = append(tasks, backupTask{"BrowserData", func() error { return backupBrowserData(tempDir, meta, incrMgr, doIncrementalBackup) }})
 	} else {
@@ -310,9 +363,12 @@ func runBackup(cmd *cobra.Command, args []string) error {
 		ui.PrintVerbose("Warning: failed to create README: %v", err)
 	}
 
+	secretsReport := secrets.NewAuditor(meta.HomeDir, pemFiles).Run()
+
 	if backupDryRun {
 		ui.PrintInfo("DRY RUN - Would create: %s/%s.tar.gz.age (%d files)",
 			cfg.BackupDir, backupName, meta.GetFileCount())
+		printSecretsAudit(secretsReport)
 		if backupVerbose {
 			fmt.Println(meta.Summary())
 		}
@@ -399,6 +455,8 @@ func runBackup(cmd *cobra.Command, args []string) error {
 	if note := strings.TrimSpace(meta.Note); note != "" {
 		ui.PrintDim("  Note: %s", note)
 	}
+	printLocalOnlyGitWork(localOnlyRepos, backupGitBundles || cfg.IsGitBundlesEnabled())
+	printSecretsAudit(secretsReport)
 
 	// Verbose: detailed statistics
 	if backupVerbose {
@@ -416,7 +474,7 @@ func runBackup(cmd *cobra.Command, args []string) error {
 	if incrMgr != nil && !backupDryRun {
 		var backedUpFiles []string
 		for _, fileInfo := range meta.Files {
-			backedUpFiles = append(backedUpFiles, fileInfo.OriginalPath)
+			backedUpFiles = append(backedUpFiles, metadata.ExpandHome(fileInfo.OriginalPath, meta.HomeDir))
 		}
 
 		isFull := !doIncrementalBackup
@@ -572,6 +630,10 @@ func backupSecrets(tempDir string, meta *metadata.Metadata, arch *archiver.Archi
 	count := 0
 
 	for name, path := range secretDirs {
+		// backupGPG exports GPG keys with gpg
+		if name == "gpg" {
+			continue
+		}
 		destPath := filepath.Join(tempDir, name)
 
 		if backupVerbose {
@@ -601,12 +663,21 @@ func backupSecrets(tempDir string, meta *metadata.Metadata, arch *archiver.Archi
 	return nil
 }
 
+// flattenedBackupName turns a file path into a single archive file name,
+// relative to the home directory so names don't depend on search path order
+func flattenedBackupName(file, homeDir string) string {
+	relPath := strings.TrimPrefix(metadata.ContractHome(file, homeDir), "~/")
+	relPath = strings.TrimPrefix(relPath, "/")
+	return strings.ReplaceAll(relPath, "/", "-")
+}
+
 func backupEnvFiles(tempDir string, meta *metadata.Metadata, arch *archiver.Archiver, cfg *config.Config, incrMgr *incremental.Manager, doIncremental bool) error {
 	envFinder := finder.NewEnvFilesFinder(cfg.SearchPaths, cfg.Exclude)
 	envFiles, err := envFinder.FindEnvFiles()
 	if err != nil {
 		return err
 	}
+	repoLocator := gittracker.NewRepoLocator()
 
 	count := 0
 	skipped := 0
@@ -620,10 +691,7 @@ func backupEnvFiles(tempDir string, meta *metadata.Metadata, arch *archiver.Arch
 			continue
 		}
 
-		relPath := strings.TrimPrefix(file, filepath.Dir(cfg.SearchPaths[0]))
-		relPath = strings.TrimPrefix(relPath, "/")
-		safeName := strings.ReplaceAll(relPath, "/", "-")
-
+		safeName := flattenedBackupName(file, meta.HomeDir)
 		destPath := filepath.Join(tempDir, "env-files", safeName)
 
 		if backupVerbose {
@@ -639,7 +707,12 @@ func backupEnvFiles(tempDir string, meta *metadata.Metadata, arch *archiver.Arch
 			}
 		}
 
-		if err := meta.AddFile(file, filepath.Join("env-files", safeName)); err != nil {
+		var remoteURL, repoRelPath string
+		if loc, ok := repoLocator.Locate(file); ok {
+			remoteURL, repoRelPath = loc.RemoteURL, loc.RelPath
+		}
+
+		if err := meta.AddRepoFile(file, filepath.Join("env-files", safeName), remoteURL, repoRelPath); err != nil {
 			if backupVerbose {
 				fmt.Printf("  ⚠️  Failed to add metadata for %s: %v\n", file, err)
 			}
@@ -657,12 +730,13 @@ func backupEnvFiles(tempDir string, meta *metadata.Metadata, arch *archiver.Arch
 	return nil
 }
 
-func backupPemFiles(tempDir string, meta *metadata.Metadata, arch *archiver.Archiver, cfg *config.Config, incrMgr *incremental.Manager, doIncremental bool) error {
+func backupPemFiles(tempDir string, meta *metadata.Metadata, arch *archiver.Archiver, cfg *config.Config, incrMgr *incremental.Manager, doIncremental bool) ([]string, error) {
 	envFinder := finder.NewEnvFilesFinder(cfg.SearchPaths, cfg.Exclude)
 	pemFiles, err := envFinder.FindPemFiles()
 	if err != nil {
-		return err
+		return nil, err
 	}
+	repoLocator := gittracker.NewRepoLocator()
 
 	count := 0
 	skipped := 0
@@ -676,10 +750,7 @@ func backupPemFiles(tempDir string, meta *metadata.Metadata, arch *archiver.Arch
 			continue
 		}
 
-		relPath := strings.TrimPrefix(file, filepath.Dir(cfg.SearchPaths[0]))
-		relPath = strings.TrimPrefix(relPath, "/")
-		safeName := strings.ReplaceAll(relPath, "/", "-")
-
+		safeName := flattenedBackupName(file, meta.HomeDir)
 		destPath := filepath.Join(tempDir, "pem-files", safeName)
 
 		if backupVerbose {
@@ -695,7 +766,12 @@ func backupPemFiles(tempDir string, meta *metadata.Metadata, arch *archiver.Arch
 			}
 		}
 
-		if err := meta.AddFile(file, filepath.Join("pem-files", safeName)); err != nil {
+		var remoteURL, repoRelPath string
+		if loc, ok := repoLocator.Locate(file); ok {
+			remoteURL, repoRelPath = loc.RemoteURL, loc.RelPath
+		}
+
+		if err := meta.AddRepoFile(file, filepath.Join("pem-files", safeName), remoteURL, repoRelPath); err != nil {
 			if backupVerbose {
 				fmt.Printf("  ⚠️  Failed to add metadata for %s: %v\n", file, err)
 			}
@@ -710,7 +786,7 @@ func backupPemFiles(tempDir string, meta *metadata.Metadata, arch *archiver.Arch
 		}
 		fmt.Println()
 	}
-	return nil
+	return pemFiles, nil
 }
 
 func backupPackages(tempDir string, meta *metadata.Metadata) error {
@@ -787,6 +863,104 @@ func backupMacOSDefaults(tempDir string, meta *metadata.Metadata, cfg *config.Co
 	return nil
 }
 
+func backupDesktopSettings(tempDir string, cfg *config.Config) error {
+	dm := desktop.NewDesktopManagerWithConfig(filepath.Join(tempDir, "desktop-settings"), cfg)
+
+	if backupDryRun {
+		if backupVerbose {
+			fmt.Printf("  Would backup %d desktop settings paths and files\n", dm.ConfiguredSections())
+		}
+		return nil
+	}
+
+	if err := dm.BackupAll(); err != nil {
+		return fmt.Errorf("failed to backup desktop settings: %w", err)
+	}
+
+	count, _ := dm.GetStats(filepath.Join(tempDir, "desktop-settings", desktop.SettingsFile))
+	if backupVerbose {
+		fmt.Printf("  ✓ Backed up %d desktop settings sections\n", count)
+	}
+
+	return nil
+}
+
+// backupGPG exports GPG keys and owner trust with gpg, falling back to a
+// copy of the GPG home without agent sockets and lock files when gpg isn't
+// installed or the export fails. Without a terminal, such as under cron, gpg
+// can't ask for passphrases, so protected secret keys get the copy instead.
+func backupGPG(tempDir string, meta *metadata.Metadata, arch *archiver.Archiver, cfg *config.Config) error {
+	dotfilesFinder, err := finder.NewDotfilesFinderWithConfig(cfg)
+	if err != nil {
+		return err
+	}
+	path, ok := dotfilesFinder.FindSecretDirs()["gpg"]
+	if !ok {
+		return nil
+	}
+
+	if backupDryRun {
+		if backupVerbose {
+			fmt.Printf("  Would export GPG keys from %s\n", path)
+		}
+		return nil
+	}
+
+	if gpg.Available() {
+		interactive := term.IsTerminal(int(os.Stdin.Fd()))
+		count, err := gpg.NewGPGManager(path).Export(filepath.Join(tempDir, "gpg-keys"), interactive)
+		if err == nil {
+			if backupVerbose {
+				fmt.Printf("  ✓ Exported %d GPG keys\n", count)
+			}
+			return nil
+		}
+		if backupVerbose {
+			fmt.Printf("  ⚠️  GPG export failed, copying %s instead: %v\n", path, err)
+		}
+	}
+
+	err = arch.CopyDirExcept(path, filepath.Join(tempDir, "gpg"), func(destPath string) bool {
+		return gpg.IsRuntimeFile(filepath.Base(destPath))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to copy GPG directory: %w", err)
+	}
+	if err := meta.AddFile(path, "gpg"); err != nil && backupVerbose {
+		fmt.Printf("  ⚠️  Failed to add metadata for gpg: %v\n", err)
+	}
+	if backupVerbose {
+		fmt.Printf("  🔐 %s → gpg\n", path)
+	}
+	return nil
+}
+
+func backupEditorSettings(tempDir string) error {
+	em := editors.NewEditorsManager(filepath.Join(tempDir, "editor-settings"))
+
+	if backupDryRun {
+		if backupVerbose {
+			fmt.Println("  Would backup editor settings (VS Code, Cursor, Zed, JetBrains)")
+		}
+		return nil
+	}
+
+	records, err := em.BackupAll()
+	if err != nil {
+		// Not having any of the editors isn't an error
+		ui.PrintVerbose("Editor settings: %v", err)
+		return nil
+	}
+
+	if backupVerbose {
+		for _, record := range records {
+			fmt.Printf("  ✓ Backed up %s settings (%d items)\n", record.Label, len(record.Paths))
+		}
+	}
+
+	return nil
+}
+
 func backupShellHistory(tempDir string, meta *metadata.Metadata, arch *archiver.Archiver, incrMgr *incremental.Manager, doIncremental bool, cfg *config.Config) error {
 	homeDir, _ := os.UserHomeDir()
 	historyDir := filepath.Join(tempDir, "shell-history")
@@ -852,7 +1026,7 @@ func backupBrowserData(tempDir string, meta *metadata.Metadata, incrMgr *increme
 
 	if backupDryRun {
 		if backupVerbose {
-			fmt.Println("  Would backup browser data (Chrome, Firefox, Safari)")
+			fmt.Println("  Would backup browser data (Chrome, Firefox, etc.)")
 		}
 		return nil
 	}
@@ -862,14 +1036,30 @@ func backupBrowserData(tempDir string, meta *metadata.Metadata, incrMgr *increme
 		return err
 	}
 
-	for browserName, count := range counts {
+	for _, info := range browserFileInfos(bm, counts, tempDir) {
+		meta.AddFileInfo(info)
+	}
+
+	return nil
+}
+
+// browserFileInfos records each backed up browser directory against the
+// live browser directory it mirrors
+func browserFileInfos(bm *browser.BrowserManager, counts map[string]int, tempDir string) []metadata.FileInfo {
+	var infos []metadata.FileInfo
+	for _, b := range bm.GetBrowsers() {
+		browserName := b.Name
+		count, ok := counts[browserName]
+		if !ok {
+			continue
+		}
 		relPath := "browser-data/" + strings.ToLower(browserName)
 		fullPath := filepath.Join(tempDir, relPath)
 
 		size, _ := getDirSize(fullPath)
 
-		meta.AddFileInfo(metadata.FileInfo{
-			OriginalPath: "~/Library/Application Support/" + browserName,
+		infos = append(infos, metadata.FileInfo{
+			OriginalPath: b.Path,
 			BackupPath:   relPath,
 			Size:         size,
 			Mode:         0755,
@@ -879,17 +1069,14 @@ func backupBrowserData(tempDir string, meta *metadata.Metadata, incrMgr *increme
 			fmt.Printf("  ✓ Backed up %s (%d items)\n", browserName, count)
 		}
 	}
-
-	return nil
+	return infos
 }
 
-func backupGitRepos(tempDir string, meta *metadata.Metadata, cfg *config.Config) error {
+// backupGitRepos tracks git repositories and returns those with work that
+// exists only on this machine
+func backupGitRepos(tempDir string, meta *metadata.Metadata, cfg *config.Config) ([]gittracker.GitRepo, error) {
 	gitDir := filepath.Join(tempDir, "git-repos")
-	gt := gittracker.NewGitTrackerWithConfig(
-		gitDir,
-		cfg.GetGitMaxDepth(),
-		cfg.GetGitSkipDirs(),
-	)
+	gt := newGitTracker(gitDir, cfg)
 
 	searchDirs := cfg.GetGitSearchDirs()
 
@@ -897,27 +1084,27 @@ func backupGitRepos(tempDir string, meta *metadata.Metadata, cfg *config.Config)
 		if backupVerbose {
 			fmt.Println("  Would scan for git repositories in common directories")
 		}
-		return nil
+		return nil, nil
 	}
 
+	scanStart := time.Now()
 	if err := gt.ScanDirectories(searchDirs); err != nil {
-		return err
+		return nil, err
 	}
+	ui.PrintVerbose("Scanned %d git repositories in %.1fs", gt.GetCount(), time.Since(scanStart).Seconds())
 
-	// Warn about repos needing attention
-	reposNeedingAttention := gt.GetReposNeedingAttention()
-	for _, repo := range reposNeedingAttention {
-		if repo.Dirty && repo.UnpushedCount > 0 {
-			fmt.Printf("  ⚠️  %s: uncommitted changes + %d unpushed commit(s)\n", repo.Path, repo.UnpushedCount)
-		} else if repo.Dirty {
-			fmt.Printf("  ⚠️  %s: uncommitted changes\n", repo.Path)
-		} else if repo.UnpushedCount > 0 {
-			fmt.Printf("  ⚠️  %s: %d unpushed commit(s)\n", repo.Path, repo.UnpushedCount)
+	if backupGitBundles || cfg.IsGitBundlesEnabled() {
+		bundled, err := gt.CreateBundles()
+		if err != nil && backupVerbose {
+			fmt.Printf("  ⚠️  Some git work could not be saved: %v\n", err)
+		}
+		if backupVerbose && bundled > 0 {
+			fmt.Printf("  ✓ Saved unpushed or uncommitted work from %d repo(s)\n", bundled)
 		}
 	}
 
 	if err := gt.Save(); err != nil {
-		return err
+		return nil, err
 	}
 
 	count := gt.GetCount()
@@ -938,7 +1125,50 @@ func backupGitRepos(tempDir string, meta *metadata.Metadata, cfg *config.Config)
 		}
 	}
 
-	return nil
+	var localOnly []gittracker.GitRepo
+	for _, repo := range gt.GetRepos() {
+		if len(repo.LocalOnlyWork()) > 0 {
+			localOnly = append(localOnly, repo)
+		}
+	}
+	return localOnly, nil
+}
+
+// newGitTracker creates a tracker with the scan settings from config
+func newGitTracker(outputDir string, cfg *config.Config) *gittracker.GitTracker {
+	gt := gittracker.NewGitTrackerWithConfig(outputDir, cfg.GetGitMaxDepth(), cfg.GetGitSkipDirs())
+	gt.SetWorkers(cfg.GetGitScanWorkers())
+	gt.SetCommandTimeout(cfg.GetGitCommandTimeout())
+	if cfg.IsGitScanCacheEnabled() {
+		gt.SetCache(gittracker.LoadScanCache(gittracker.DefaultScanCachePath()))
+	}
+	return gt
+}
+
+// maxLocalOnlyRepos caps how many repos the backup summary lists
+const maxLocalOnlyRepos = 10
+
+// printLocalOnlyGitWork warns about repo data that a fresh clone won't bring back
+func printLocalOnlyGitWork(repos []gittracker.GitRepo, bundled bool) {
+	if len(repos) == 0 {
+		return
+	}
+
+	homeDir, _ := os.UserHomeDir()
+	ui.PrintWarning("%d git repo(s) have work that exists only on this machine:", len(repos))
+	for i, repo := range repos {
+		if i == maxLocalOnlyRepos && !backupVerbose {
+			ui.PrintDim("  ... and %d more (run `stash remind`)", len(repos)-maxLocalOnlyRepos)
+			break
+		}
+		ui.PrintDim("  %s: %s", shortenRemindPath(repo.Path, homeDir), strings.Join(repo.LocalOnlyWork(), ", "))
+	}
+
+	if bundled {
+		ui.PrintDim("  Commits, stashes and uncommitted changes were saved as git bundles; worktrees were not")
+	} else {
+		ui.PrintDim("  Push it, or save it with --git-bundles")
+	}
 }
 
 func backupFonts(tempDir string, meta *metadata.Metadata) error {
@@ -947,7 +1177,7 @@ func backupFonts(tempDir string, meta *metadata.Metadata) error {
 
 	if backupDryRun {
 		if backupVerbose {
-			fmt.Println("  Would backup custom fonts from ~/Library/Fonts")
+			fmt.Printf("  Would backup custom fonts from %s\n", fm.FontsDir())
 		}
 		return nil
 	}
@@ -960,7 +1190,7 @@ func backupFonts(tempDir string, meta *metadata.Metadata) error {
 	size, _ := getDirSize(fontsDir)
 
 	meta.AddFileInfo(metadata.FileInfo{
-		OriginalPath: "~/Library/Fonts",
+		OriginalPath: fm.FontsDir(),
 		BackupPath:   "fonts/",
 		Size:         size,
 		Mode:         0755,
@@ -7,6 +7,9 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/harshpatel5940/stash/internal/browser"
+	"github.com/harshpatel5940/stash/internal/platform"
 )
 
 func TestInitCmd(t *testing.T) {
@@ -135,3 +138,85 @@ func TestBackupCmd(t *testing.T) {
 		t.Error("No .tar.gz backup file found")
 	}
 }
+
+func TestBrowserFileInfosRestoreOverBrowser(t *testing.T) {
+	home := t.TempDir()
+	p := platform.New(platform.MacOS, home)
+	chrome := filepath.Join(p.AppSupportDir(), "Google", "Chrome")
+	os.MkdirAll(filepath.Join(chrome, "Default"), 0755)
+	os.WriteFile(filepath.Join(chrome, "Default", "Bookmarks"), []byte("{}"), 0644)
+
+	tempDir := t.TempDir()
+	bm := browser.NewBrowserManagerFor(filepath.Join(tempDir, "browser-data"), p)
+	counts, err := bm.BackupAll()
+	if err != nil {
+		t.Fatalf("BackupAll failed: %v", err)
+	}
+
+	infos := browserFileInfos(bm, counts, tempDir)
+	if len(infos) != 1 {
+		t.Fatalf("Expected 1 browser entry, got %d", len(infos))
+	}
+	if infos[0].OriginalPath != chrome {
+		t.Errorf("Expected original path %s, got %s", chrome, infos[0].OriginalPath)
+	}
+	// Restore copies the backup directory over the original, so the
+	// profile files must sit where they do in the browser directory
+	backed := filepath.Join(tempDir, filepath.FromSlash(infos[0].BackupPath), "Default", "Bookmarks")
+	if _, err := os.Stat(backed); err != nil {
+		t.Errorf("Expected Default/Bookmarks in the backup: %v", err)
+	}
+}
+
+func TestRestoreUnencryptedWithoutKey(t *testing.T) {
+	tmpHome := t.TempDir()
+	oldHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpHome)
+	defer os.Setenv("HOME", oldHome)
+	t.Cleanup(func() {
+		restoreOnly, restoreTarget = nil, ""
+	})
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	defer func() { os.Stdout = oldStdout }()
+
+	rootCmd.SetArgs([]string{"init"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	os.WriteFile(filepath.Join(tmpHome, ".zshrc"), []byte("alias ls='ls -G'"), 0644)
+
+	backupDir := filepath.Join(tmpHome, "stash-backups")
+	rootCmd.SetArgs([]string{"backup", "--no-encrypt", "--output", backupDir})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	backups, _ := filepath.Glob(filepath.Join(backupDir, "*.tar.gz"))
+	if len(backups) != 1 {
+		t.Fatalf("Expected 1 unencrypted backup, got %v", backups)
+	}
+
+	// A fresh machine has the backup but no key
+	os.Remove(filepath.Join(tmpHome, ".stash.key"))
+
+	target := filepath.Join(tmpHome, "sandbox")
+	rootCmd.SetArgs([]string{"restore", backups[0], "--only", "~/.zshrc", "--target", target})
+	err := rootCmd.Execute()
+
+	w.Close()
+	os.Stdout = oldStdout
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+
+	if err != nil {
+		t.Fatalf("Restore without a key failed: %v\n%s", err, buf.String())
+	}
+	if !strings.Contains(buf.String(), "without a rollback snapshot") {
+		t.Errorf("Expected a warning about the skipped snapshot, got:\n%s", buf.String())
+	}
+	if _, err := os.Stat(filepath.Join(target, tmpHome, ".zshrc")); err != nil {
+		t.Errorf("Expected .zshrc to be restored: %v", err)
+	}
+}
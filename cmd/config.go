@@ -245,6 +245,8 @@ func runConfigEditTUI(configPath string) error {
 	diffLimit := fmt.Sprintf("%d", cfg.GetDiffDisplayLimit())
 	browserEnabled := cfg.IsBrowsersEnabled()
 	macosEnabled := cfg.IsMacOSDefaultsEnabled()
+	desktopEnabled := cfg.IsDesktopSettingsEnabled()
+	editorsEnabled := cfg.IsEditorsEnabled()
 	cloudEnabled := cfg.Cloud.Enabled
 	cloudProvider := cfg.Cloud.Provider
 	cloudBucket := cfg.Cloud.Bucket
@@ -271,6 +273,8 @@ func runConfigEditTUI(configPath string) error {
 			huh.NewInput().Title("Restore file picker threshold").Description("Show file picker only below this file count").Value(&restoreThreshold),
 			huh.NewConfirm().Title("Enable browser data backup").Value(&browserEnabled),
 			huh.NewConfirm().Title("Enable macOS defaults backup").Value(&macosEnabled),
+			huh.NewConfirm().Title("Enable Linux desktop settings backup").Value(&desktopEnabled),
+			huh.NewConfirm().Title("Enable editor settings backup").Value(&editorsEnabled),
 			huh.NewConfirm().Title("Enable cloud sync settings").Value(&cloudEnabled),
 		),
 		huh.NewGroup(
@@ -320,6 +324,8 @@ func runConfigEditTUI(configPath string) error {
 	cfg.Diff.DisplayLimit = diffLimitInt
 	cfg.Browsers.Enabled = browserEnabled
 	cfg.MacOSDefaults.Enabled = macosEnabled
+	cfg.Desktop.Enabled = desktopEnabled
+	cfg.Editors.Enabled = editorsEnabled
 	cfg.Cloud.Enabled = cloudEnabled
 	cfg.Cloud.Provider = strings.TrimSpace(cloudProvider)
 	cfg.Cloud.Bucket = strings.TrimSpace(cloudBucket)
@@ -367,6 +373,14 @@ func runConfigEditTUI(configPath string) error {
 	if err != nil {
 		return err
 	}
+	desktopDconf, err := editStringListWithTUI("Desktop dconf paths", "dconf paths or gsettings schemas to backup and restore", cfg.Desktop.Dconf, defaults.Desktop.Dconf)
+	if err != nil {
+		return err
+	}
+	desktopKDE, err := editStringListWithTUI("Desktop KDE files", "KDE rc files in ~/.config to backup and restore", cfg.Desktop.KDE, defaults.Desktop.KDE)
+	if err != nil {
+		return err
+	}
 	browserInclude, err := editStringListWithTUI("Browser include filter", "Leave empty for all supported browsers", cfg.Browsers.Include, defaults.Browsers.Include)
 	if err != nil {
 		return err
@@ -382,6 +396,8 @@ func runConfigEditTUI(configPath string) error {
 	cfg.Git.SearchDirs = gitSearchDirs
 	cfg.Git.SkipDirs = gitSkipDirs
 	cfg.MacOSDefaults.Domains = macosDomains
+	cfg.Desktop.Dconf = desktopDconf
+	cfg.Desktop.KDE = desktopKDE
 	cfg.Browsers.Include = browserInclude
 
 	if err := cfg.Save(configPath); err != nil {
@@ -423,9 +439,15 @@ func ensureConfigSections(cfg, defaults *config.Config) {
 	if cfg.MacOSDefaults == nil {
 		cfg.MacOSDefaults = defaults.MacOSDefaults
 	}
+	if cfg.Desktop == nil {
+		cfg.Desktop = defaults.Desktop
+	}
 	if cfg.Browsers == nil {
 		cfg.Browsers = defaults.Browsers
 	}
+	if cfg.Editors == nil {
+		cfg.Editors = defaults.Editors
+	}
 	if cfg.Diff == nil {
 		cfg.Diff = defaults.Diff
 	}
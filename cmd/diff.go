@@ -25,6 +25,8 @@ Shows:
   - Files that were added, removed, or modified
   - Size changes for each category
   - Package manager changes (Homebrew, npm, etc.)
+  - Package version upgrades and downgrades (from packages.lock.json)
+  - Linux desktop settings changes (GNOME/KDE)
 
 Examples:
   stash diff backup-old.tar.gz.age backup-new.tar.gz.age
@@ -92,6 +94,19 @@ func runDiff(cmd *cobra.Command, args []string) error {
 		result.ModifiedSize,
 	)
 
+	if len(result.SettingsChanges) > 0 {
+		ui.PrintInfo("%d desktop setting(s) changed", len(result.SettingsChanges))
+	}
+
+	if diffShowPackages && len(result.VersionChanges) > 0 {
+		kinds := make(map[string]int)
+		for _, change := range result.VersionChanges {
+			kinds[change.Kind()]++
+		}
+		ui.PrintInfo("%d package version change(s): %d upgraded, %d downgraded, %d added, %d removed",
+			len(result.VersionChanges), kinds["upgraded"], kinds["downgraded"], kinds["added"], kinds["removed"])
+	}
+
 	// Verbose: detailed file lists
 	if diffVerbose {
 		// Added files
@@ -147,6 +162,50 @@ func runDiff(cmd *cobra.Command, args []string) error {
 			}
 		}
 
+		// Desktop settings changes
+		if len(result.SettingsChanges) > 0 {
+			fmt.Printf("\n%s:\n", ui.Bold("Desktop settings"))
+			limit := cfg.GetDiffDisplayLimit()
+			for i, change := range result.SettingsChanges {
+				if i >= limit {
+					ui.PrintDim("  ... and %d more", len(result.SettingsChanges)-limit)
+					break
+				}
+				mark := ui.Warning("~")
+				switch change.Kind {
+				case '+':
+					mark = ui.Success("+")
+				case '-':
+					mark = ui.Error("-")
+				}
+				fmt.Printf("  %s %s\n", mark, change)
+			}
+		}
+
+		// Package version changes
+		if diffShowPackages && len(result.VersionChanges) > 0 {
+			fmt.Printf("\n%s:\n", ui.Bold("Package versions"))
+			limit := cfg.GetDiffDisplayLimit()
+			for i, change := range result.VersionChanges {
+				if i >= limit {
+					ui.PrintDim("  ... and %d more", len(result.VersionChanges)-limit)
+					break
+				}
+				var mark string
+				switch change.Kind() {
+				case "added":
+					mark = ui.Success("+")
+				case "removed":
+					mark = ui.Error("-")
+				case "upgraded":
+					mark = ui.Success("↑")
+				default:
+					mark = ui.Warning("↓")
+				}
+				fmt.Printf("  %s %s\n", mark, change)
+			}
+		}
+
 		// Package changes
 		if diffShowPackages && len(result.PackageChanges) > 0 {
 			fmt.Printf("\n%s:\n", ui.Bold("Packages"))
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/harshpatel5940/stash/internal/config"
+	"github.com/harshpatel5940/stash/internal/security"
+	"github.com/harshpatel5940/stash/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var doctorFix bool
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check secrets for unsafe permissions",
+	Long: `Checks ~/.ssh, ~/.gnupg, ~/.aws and ~/.kube for files and directories
+that other users can read. ssh and gpg refuse keys with loose permissions.
+
+Directories may be at most 0700 and files 0600 (0644 for ~/.ssh/*.pub).
+Add or override rules under secrets.permissions in ~/.stash.yaml:
+
+  secrets:
+    permissions:
+      - path: .config/gh
+        dir_mode: "0700"
+        file_mode: "0600"
+
+Restore applies the same rules to the files it writes.
+
+Examples:
+  stash doctor
+  stash doctor --fix`,
+	RunE: runDoctor,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "Remove the permissions the rules don't allow")
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	policy, err := permissionPolicy(cfg, homeDir)
+	if err != nil {
+		return err
+	}
+
+	issues := policy.Audit()
+	if len(issues) == 0 {
+		ui.PrintSuccess("Secret permissions look right")
+		return nil
+	}
+
+	for _, issue := range issues {
+		ui.PrintWarning("%s", issue)
+	}
+
+	if !doctorFix {
+		return fmt.Errorf("%d path(s) with unsafe permissions; run 'stash doctor --fix' to fix them", len(issues))
+	}
+
+	fixed := 0
+	for _, issue := range issues {
+		n, err := policy.Apply(issue.Path)
+		fixed += n
+		if err != nil {
+			return fmt.Errorf("failed to fix %s: %w", issue.Path, err)
+		}
+	}
+	ui.PrintSuccess("Fixed permissions on %d path(s)", fixed)
+	return nil
+}
+
+// permissionPolicy returns the built-in permission rules followed by the
+// configured ones, for the home directory homeDir
+func permissionPolicy(cfg *config.Config, homeDir string) (*security.PermissionPolicy, error) {
+	rules := append([]security.PermissionRule{}, security.DefaultPermissionRules...)
+	for _, rule := range cfg.GetPermissionRules() {
+		dirMode, err := security.ParseMode(rule.DirMode)
+		if err != nil {
+			return nil, fmt.Errorf("secrets.permissions %s: %w", rule.Path, err)
+		}
+		fileMode, err := security.ParseMode(rule.FileMode)
+		if err != nil {
+			return nil, fmt.Errorf("secrets.permissions %s: %w", rule.Path, err)
+		}
+		rules = append(rules, security.PermissionRule{Path: rule.Path, DirMode: dirMode, FileMode: fileMode})
+	}
+	return security.NewPermissionPolicy(homeDir, rules), nil
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/harshpatel5940/stash/internal/backuputil"
+	"github.com/harshpatel5940/stash/internal/config"
+	"github.com/harshpatel5940/stash/internal/packager"
+	"github.com/harshpatel5940/stash/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	packagesDecryptKey     string
+	packagesInstallMissing bool
+	packagesExact          bool
+	packagesVerbose        bool
+)
+
+var packagesCmd = &cobra.Command{
+	Use:   "packages",
+	Short: "Work with the packages recorded in backups",
+}
+
+var packagesStatusCmd = &cobra.Command{
+	Use:   "status [backup-id|name]",
+	Short: "Compare installed packages with a backup",
+	Long: `Lists the packages again with every package manager recorded in a
+backup (Homebrew, App Store, VS Code, npm, language and Go packages, and
+Linux system packages) and reports, per manager, which packages are
+missing, which are installed but not in the backup, and which are at a
+different version than packages.lock.json recorded.
+
+Without a backup argument the most recent backup is used.
+
+Examples:
+  stash packages status
+  stash packages status 2 -v
+  stash packages status --install-missing
+  stash packages status --install-missing --exact-versions`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runPackagesStatus,
+}
+
+func init() {
+	rootCmd.AddCommand(packagesCmd)
+	packagesCmd.AddCommand(packagesStatusCmd)
+
+	packagesStatusCmd.Flags().StringVarP(&packagesDecryptKey, "decrypt-key", "k", "", "Path to decryption key (default: ~/.stash.key)")
+	packagesStatusCmd.Flags().BoolVar(&packagesInstallMissing, "install-missing", false, "Install the packages that are missing")
+	packagesStatusCmd.Flags().BoolVar(&packagesExact, "exact-versions", false, "Install missing packages at the versions in packages.lock.json")
+	packagesStatusCmd.Flags().BoolVarP(&packagesVerbose, "verbose", "v", false, "Also list extra packages and installer output")
+}
+
+func runPackagesStatus(cmd *cobra.Command, args []string) error {
+	ui.Verbose = packagesVerbose
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	cfg.ExpandPaths()
+
+	input := "1"
+	if len(args) > 0 {
+		input = args[0]
+	}
+	backup, err := resolveBackupInput(input, cfg.BackupDir)
+	if err != nil {
+		return err
+	}
+
+	keyPath := packagesDecryptKey
+	if keyPath == "" {
+		keyPath = cfg.EncryptionKey
+	}
+
+	tempDir, err := os.MkdirTemp("", "stash-packages-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	packagesDir := filepath.Join(tempDir, "packages")
+	if err := backuputil.ExtractDir(backup.Path, keyPath, "packages", packagesDir); err != nil {
+		return fmt.Errorf("failed to read packages from %s: %w", backup.Name, err)
+	}
+
+	ui.PrintInfo("Comparing installed packages with %s...", backup.Name)
+	statuses, err := packager.CheckPackages(packagesDir)
+	if err != nil {
+		return err
+	}
+	if len(statuses) == 0 {
+		ui.PrintInfo("No package lists found in %s", backup.Name)
+		return nil
+	}
+
+	missing := printPackageStatuses(statuses)
+	if missing == 0 {
+		return nil
+	}
+	if !packagesInstallMissing {
+		ui.PrintDim("Run with --install-missing to install the %d missing package(s)", missing)
+		return nil
+	}
+
+	fmt.Println()
+	installer := packager.NewInstaller(packagesVerbose)
+	if packagesExact {
+		installer.UseLock(loadPackageLock(packagesDir))
+	}
+
+	var warnings []string
+	for _, status := range statuses {
+		if len(status.Missing) == 0 {
+			continue
+		}
+		ui.PrintInfo("Installing missing %s...", status.Label)
+		count, err := installer.InstallMissing(packagesDir, status)
+		if count > 0 {
+			ui.PrintSuccess("Installed %d %s", count, status.Label)
+		}
+
+		var installErr *packager.PackageInstallError
+		switch {
+		case errors.As(err, &installErr):
+			warnings = append(warnings, fmt.Sprintf("%s failed packages: %s", status.Label, strings.Join(installErr.FailedPackages, ", ")))
+		case err != nil:
+			warnings = append(warnings, fmt.Sprintf("%s: %v", status.Label, err))
+		}
+	}
+
+	if len(warnings) > 0 {
+		fmt.Println()
+		for _, warning := range warnings {
+			ui.PrintWarning("%s", warning)
+		}
+	}
+	return nil
+}
+
+// printPackageStatuses prints one line per manager and the packages that
+// differ, returning how many packages are missing
+func printPackageStatuses(statuses []packager.PackageStatus) int {
+	missing := 0
+	for _, status := range statuses {
+		missing += len(status.Missing)
+		if status.InSync() {
+			ui.PrintSuccess("%s: %s", status.Label, status.Summary())
+			continue
+		}
+		ui.PrintWarning("%s: %s", status.Label, status.Summary())
+
+		for _, name := range status.Missing {
+			fmt.Printf("    - %s\n", name)
+		}
+		for _, mismatch := range status.Mismatched {
+			fmt.Printf("    ~ %s: %s in backup, %s installed\n", mismatch.Name, mismatch.Backup, mismatch.Installed)
+		}
+		if packagesVerbose {
+			for _, name := range status.Extra {
+				fmt.Printf("    + %s\n", name)
+			}
+		}
+	}
+	return missing
+}
@@ -1,25 +1,46 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
 
 	"github.com/harshpatel5940/stash/internal/config"
 	"github.com/harshpatel5940/stash/internal/gittracker"
 	"github.com/harshpatel5940/stash/internal/ui"
+	"github.com/schollz/progressbar/v3"
 	"github.com/spf13/cobra"
 )
 
 var (
 	remindVerbose bool
+	remindJSON    bool
 )
 
+// remindReport is the --json output of stash remind
+type remindReport struct {
+	Total          int          `json:"total"`
+	NeedsAttention int          `json:"needs_attention"`
+	Repos          []remindRepo `json:"repos"`
+}
+
+type remindRepo struct {
+	gittracker.GitRepo
+	Issues []string `json:"issues"`
+}
+
 var remindCmd = &cobra.Command{
 	Use:   "remind",
 	Short: "Show git repos needing attention",
 	Long: `Scans your project directories for git repositories and shows
-which ones have uncommitted changes or unpushed commits.
+which ones have work that exists only on this machine: uncommitted
+changes, unpushed commits, stashes, branches without an upstream,
+unpushed tags and linked worktrees.
+
+Use --json for machine-readable output (repos needing attention, or
+all repos with --verbose).
 
 Run before backup or at end of day to ensure all work is committed.`,
 	RunE: runRemind,
@@ -28,10 +49,11 @@ Run before backup or at end of day to ensure all work is committed.`,
 func init() {
 	rootCmd.AddCommand(remindCmd)
 	remindCmd.Flags().BoolVarP(&remindVerbose, "verbose", "v", false, "Show all repos, not just those needing attention")
+	remindCmd.Flags().BoolVar(&remindJSON, "json", false, "Output as JSON")
 }
 
 func runRemind(cmd *cobra.Command, args []string) error {
-	ui.Verbose = remindVerbose
+	ui.Verbose = remindVerbose && !remindJSON
 
 	cfg, err := config.Load()
 	if err != nil {
@@ -39,28 +61,50 @@ func runRemind(cmd *cobra.Command, args []string) error {
 	}
 	cfg.ExpandPaths()
 
-	gt := gittracker.NewGitTrackerWithConfig(
-		"",
-		cfg.GetGitMaxDepth(),
-		cfg.GetGitSkipDirs(),
-	)
+	gt := newGitTracker("", cfg)
+
+	// Show progress once repos have been found; JSON output stays clean
+	var bar *progressbar.ProgressBar
+	if !remindJSON {
+		gt.SetProgress(func(done, total int) {
+			if bar == nil {
+				bar = ui.NewProgressBar(total, "Scanning repos")
+			}
+			_ = bar.Set(done)
+		})
+	}
+
+	// Ctrl-C stops the scan and any running git commands
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer stop()
 
 	searchDirs := cfg.GetGitSearchDirs()
 
-	if err := gt.ScanDirectories(searchDirs); err != nil {
+	if err := gt.ScanDirectoriesContext(ctx, searchDirs); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("scan interrupted")
+		}
 		return fmt.Errorf("failed to scan: %w", err)
 	}
 
 	homeDir, _ := os.UserHomeDir()
 
 	allRepos := gt.GetRepos()
+	needsAttention := gt.GetReposNeedingAttention()
+
+	if remindJSON {
+		listed := needsAttention
+		if remindVerbose {
+			listed = allRepos
+		}
+		return printRemindJSON(len(allRepos), len(needsAttention), listed)
+	}
+
 	if len(allRepos) == 0 {
 		ui.PrintInfo("No git repositories found")
 		return nil
 	}
 
-	needsAttention := gt.GetReposNeedingAttention()
-
 	// Minimal: all clean
 	if len(needsAttention) == 0 {
 		ui.PrintSuccess("All %d repos clean", len(allRepos))
@@ -72,30 +116,31 @@ func runRemind(cmd *cobra.Command, args []string) error {
 	uncommittedCount := 0
 	unpushedCount := 0
 	noUpstreamCount := 0
+	stashCount := 0
+	localBranchCount := 0
+	unpushedTagCount := 0
+	worktreeCount := 0
 
 	for _, repo := range needsAttention {
 		shortPath := truncateRemindPath(shortenRemindPath(repo.Path, homeDir), 80)
-		issues := []string{}
+		fmt.Printf("  %s %s (%s)\n", ui.IconWarning, shortPath, strings.Join(remindIssues(repo), ", "))
 
 		if repo.Dirty {
-			issues = append(issues, "uncommitted")
 			uncommittedCount++
 		}
 		if repo.UnpushedCount > 0 {
-			issues = append(issues, fmt.Sprintf("%d unpushed", repo.UnpushedCount))
 			unpushedCount++
 		}
 		if !repo.HasUpstream && len(repo.Remotes) > 0 {
-			issues = append(issues, "no upstream")
 			noUpstreamCount++
 		}
-		if repo.Behind > 0 {
-			issues = append(issues, fmt.Sprintf("%d behind", repo.Behind))
-		}
-
-		fmt.Printf("  %s %s (%s)\n", ui.IconWarning, shortPath, strings.Join(issues, ", "))
+		stashCount += repo.StashCount
+		localBranchCount += len(repo.LocalBranches)
+		unpushedTagCount += len(repo.UnpushedTags)
+		worktreeCount += len(repo.Worktrees)
 	}
 	fmt.Printf("\n  Uncommitted: %d  Unpushed: %d  No upstream: %d\n", uncommittedCount, unpushedCount, noUpstreamCount)
+	fmt.Printf("  Stashes: %d  Local branches: %d  Unpushed tags: %d  Worktrees: %d\n", stashCount, localBranchCount, unpushedTagCount, worktreeCount)
 
 	// Verbose: show all repos
 	if remindVerbose {
@@ -115,6 +160,57 @@ func runRemind(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// remindIssues lists the short status labels shown for a repo
+func remindIssues(repo gittracker.GitRepo) []string {
+	issues := []string{}
+	if repo.Dirty {
+		issues = append(issues, "uncommitted")
+	}
+	if repo.Untracked > 0 {
+		issues = append(issues, fmt.Sprintf("%d untracked", repo.Untracked))
+	}
+	if repo.UnpushedCount > 0 {
+		issues = append(issues, fmt.Sprintf("%d unpushed", repo.UnpushedCount))
+	}
+	if !repo.HasUpstream && len(repo.Remotes) > 0 {
+		issues = append(issues, "no upstream")
+	}
+	if repo.Behind > 0 {
+		issues = append(issues, fmt.Sprintf("%d behind", repo.Behind))
+	}
+	if repo.StashCount > 0 {
+		issues = append(issues, fmt.Sprintf("%d stashed", repo.StashCount))
+	}
+	if len(repo.LocalBranches) > 0 {
+		issues = append(issues, fmt.Sprintf("local branches: %s", strings.Join(repo.LocalBranches, ", ")))
+	}
+	if len(repo.UnpushedTags) > 0 {
+		issues = append(issues, fmt.Sprintf("unpushed tags: %s", strings.Join(repo.UnpushedTags, ", ")))
+	}
+	if len(repo.Worktrees) > 0 {
+		issues = append(issues, fmt.Sprintf("%d worktree(s)", len(repo.Worktrees)))
+	}
+	return issues
+}
+
+func printRemindJSON(total, needsAttention int, repos []gittracker.GitRepo) error {
+	report := remindReport{
+		Total:          total,
+		NeedsAttention: needsAttention,
+		Repos:          []remindRepo{},
+	}
+	for _, repo := range repos {
+		report.Repos = append(report.Repos, remindRepo{GitRepo: repo, Issues: remindIssues(repo)})
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
 func shortenRemindPath(path, homeDir string) string {
 	if strings.HasPrefix(path, homeDir) {
 		return "~" + path[len(homeDir):]
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -12,9 +13,12 @@ import (
 	"github.com/harshpatel5940/stash/internal/config"
 	"github.com/harshpatel5940/stash/internal/crypto"
 	"github.com/harshpatel5940/stash/internal/defaults"
+	"github.com/harshpatel5940/stash/internal/desktop"
 	"github.com/harshpatel5940/stash/internal/incremental"
 	"github.com/harshpatel5940/stash/internal/metadata"
 	"github.com/harshpatel5940/stash/internal/packager"
+	"github.com/harshpatel5940/stash/internal/platform"
+	"github.com/harshpatel5940/stash/internal/rollback"
 	"github.com/harshpatel5940/stash/internal/tui"
 	"github.com/harshpatel5940/stash/internal/ui"
 	"github.com/spf13/cobra"
@@ -26,17 +30,38 @@ var (
 	restoreEditor     bool
 	restoreNoDecrypt  bool
 	restoreNoTUI      bool
+	restoreNoSnapshot bool
+	restoreOnConflict string
+	restoreOnly       []string
+	restoreExclude    []string
+	restoreTarget     string
+	restoreMap        []string
+	restoreWithPkgs   bool
+	restoreExact      bool
+	restoreResume     bool
 	restoreVerbose    bool
 )
 
 type RestoreOptions struct {
 	RestoreFiles         bool
 	RestoreMacOSDefaults bool
+	RestoreDesktop       bool
+	DesktopSections      []string // IDs picked in the editor; nil means ask or restore all
+	ImportGPG            bool
+	EditorSettings       []string // names of editors whose settings to restore
 	InstallHomebrew      bool
+	StartServices        bool
+	InstallSuggested     bool // casks matched to apps installed outside Homebrew
+	InstallSystem        bool
+	InstallToolchains    bool
+	InstallGo            bool
+	LanguagePackages     []string // names of packager.LanguageManagers to install
+	ExactVersions        bool     // pin packages to packages.lock.json instead of installing the latest
 	InstallMAS           bool
 	InstallVSCode        bool
 	InstallNPM           bool
 	RestoreShellHistory  bool
+	RestoreGitRepos      bool
 }
 
 var restoreCmd = &cobra.Command{
@@ -52,15 +77,61 @@ The restore process:
   5. Executes selected actions automatically:
      - Restore files (dotfiles, SSH, GPG, configs)
      - Restore macOS system preferences
+     - Restore Linux desktop settings (GNOME/KDE)
      - Install Homebrew packages
+     - Install Linux system packages (apt, dnf, pacman, flatpak, snap)
+     - Reinstall language runtimes (mise, asdf, nvm, pyenv, rbenv, rustup)
+     - Reinstall Go binaries with go install
+     - Install pipx, uv, pip, Cargo, gem, pnpm and Composer packages
      - Install Mac App Store apps
      - Install VS Code extensions
      - Install NPM global packages
 
+Before any file is overwritten, the current version is saved to an encrypted
+rollback snapshot. Use 'stash undo' to put everything back as it was.
+Git repos cloned by the restore are not part of the snapshot and stay in
+place after an undo. The snapshot is encrypted with your stash key, so restoring an unencrypted
+backup on a machine without a key goes ahead without one.
+
+Local files that differ from the backup are treated as conflicts. For each
+one you can overwrite it, keep the local copy, save the backup copy next to
+it as <file>.stash-restored, or merge the two with conflict markers. Use
+--on-conflict to pick one answer for every conflict without prompting.
+
+Use --only and --exclude to restore part of a backup without prompts. Each
+takes a path glob (~ is allowed, a matching directory selects everything
+beneath it) or category:<name> for a top-level backup folder such as
+category:ssh or category:env-files. Both flags can be repeated.
+
+Paths under the home directory of the machine the backup was taken on are
+moved to the current home automatically. Use --map old=new to relocate other
+prefixes, such as a different project layout.
+
+Use --target <dir> to restore into a sandbox directory: every path is
+remapped under <dir> and package installs and system defaults are skipped
+unless --with-packages is given.
+
+Packages install at their latest versions unless you choose exact versions,
+which pins everything the package manager allows to the versions recorded in
+the backup's packages.lock.json. --exact-versions picks this without asking.
+
+Homebrew, App Store, VS Code, Go, language and NPM package installs are
+saved to an install plan as they go, retrying failures
+(restore.install_retries) and installing VS Code extensions in parallel
+(restore.install_workers). If a restore is interrupted, --resume-install
+continues with what's left. Linux system packages and language runtimes
+are installed in batches and aren't part of the plan; restore again to
+retry them.
+
 Use --dry-run to preview what would be restored without making changes.
 Use --editor to pick/drop individual files in your editor (git-rebase style).
 Use --no-tui for simple Y/n prompts instead of interactive multi-select.`,
-	Args: cobra.ExactArgs(1),
+	Args: func(cmd *cobra.Command, args []string) error {
+		if restoreResume {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
 	RunE: runRestore,
 }
 
@@ -71,12 +142,20 @@ func init() {
 	restoreCmd.Flags().BoolVar(&restoreEditor, "editor", false, "Pick files in editor (git-rebase style)")
 	restoreCmd.Flags().BoolVar(&restoreNoDecrypt, "no-decrypt", false, "Skip decryption")
 	restoreCmd.Flags().BoolVar(&restoreNoTUI, "no-tui", false, "Use Y/n prompts instead of TUI")
+	restoreCmd.Flags().BoolVar(&restoreNoSnapshot, "no-snapshot", false, "Skip the pre-restore rollback snapshot")
+	restoreCmd.Flags().StringVar(&restoreOnConflict, "on-conflict", "", "Conflict policy: ask, overwrite, keep, save-as, merge (default from config: ask)")
+	restoreCmd.Flags().StringArrayVar(&restoreOnly, "only", nil, "Restore only files matching a glob or category:<name> (repeatable)")
+	restoreCmd.Flags().StringArrayVar(&restoreExclude, "exclude", nil, "Skip files matching a glob or category:<name> (repeatable)")
+	restoreCmd.Flags().StringArrayVar(&restoreMap, "map", nil, "Rewrite paths with prefix old=new, e.g. /Users/alice/work=~/code (repeatable)")
+	restoreCmd.Flags().StringVar(&restoreTarget, "target", "", "Restore into an alternate root directory instead of $HOME")
+	restoreCmd.Flags().BoolVar(&restoreWithPkgs, "with-packages", false, "With --target, still install packages and restore system defaults")
+	restoreCmd.Flags().BoolVar(&restoreExact, "exact-versions", false, "Install the package versions recorded in the backup where possible")
+	restoreCmd.Flags().BoolVar(&restoreResume, "resume-install", false, "Continue package installs an interrupted restore left unfinished")
 	restoreCmd.Flags().BoolVarP(&restoreVerbose, "verbose", "v", false, "Show detailed output")
 }
 
 func runRestore(cmd *cobra.Command, args []string) error {
 	ui.Verbose = restoreVerbose
-	backupRef := args[0]
 
 	cfg, err := config.Load()
 	if err != nil {
@@ -84,6 +163,19 @@ func runRestore(cmd *cobra.Command, args []string) error {
 	}
 	cfg.ExpandPaths()
 
+	if restoreResume {
+		return resumeInstall(cfg)
+	}
+	backupRef := args[0]
+
+	conflictPolicy := strings.TrimSpace(restoreOnConflict)
+	if conflictPolicy == "" {
+		conflictPolicy = cfg.GetRestoreConflictPolicy()
+	}
+	if err := validateConflictPolicy(conflictPolicy); err != nil {
+		return err
+	}
+
 	resolvedBackup, err := resolveBackupInput(backupRef, cfg.BackupDir)
 	if err != nil {
 		return err
@@ -95,6 +187,21 @@ func runRestore(cmd *cobra.Command, args []string) error {
 		ui.PrintInfo("DRY RUN - No changes will be made")
 	}
 
+	resolver, err := newDestResolver(restoreTarget, restoreMap)
+	if err != nil {
+		return err
+	}
+	if resolver.targetRoot != "" {
+		ui.PrintVerbose("Restoring into %s", resolver.targetRoot)
+	}
+
+	filter, err := newRestoreFilter(restoreOnly, restoreExclude, resolver.homeDir)
+	if err != nil {
+		return err
+	}
+	// --only restores just the matching files, without prompts or package installs
+	selective := len(filter.only) > 0
+
 	keyPath := strings.TrimSpace(restoreDecryptKey)
 	if keyPath == "" {
 		keyPath = strings.TrimSpace(cfg.EncryptionKey)
@@ -190,33 +297,104 @@ func runRestore(cmd *cobra.Command, args []string) error {
 
 	ui.PrintVerbose("Files: %d", len(meta.Files))
 
+	resolver.SetSourceHome(meta.SourceHome())
+	if resolver.sourceHome != "" && resolver.sourceHome != resolver.homeDir {
+		ui.PrintVerbose("Remapping %s -> %s", resolver.sourceHome, resolver.homeDir)
+	}
+
+	currentPlatform := platform.Current()
+	sourcePlatform := platform.New(meta.SourcePlatform(), resolver.sourceHome)
+	if sourcePlatform.HomeDir == "" {
+		sourcePlatform.HomeDir = resolver.homeDir
+	}
+	if sourcePlatform.OS != currentPlatform.OS {
+		ui.PrintInfo("Backup is from %s, restoring on %s", sourcePlatform.Name(), currentPlatform.Name())
+		resolver.SetPlatforms(sourcePlatform, currentPlatform)
+
+		var skipped []metadata.FileInfo
+		meta.Files, skipped = foreignPlatformFiles(meta.Files, sourcePlatform, currentPlatform)
+		if len(skipped) > 0 {
+			ui.PrintInfo("Skipping %d %s-only item(s)", len(skipped), sourcePlatform.Name())
+			for _, f := range skipped {
+				ui.PrintVerbose("  %s", f.OriginalPath)
+			}
+		}
+	}
+
+	if hasRepoFiles(meta.Files) {
+		ui.PrintVerbose("Looking for local clones of repositories...")
+		gt := newGitTracker("", cfg)
+		searchDirs := append([]string{}, cfg.GetGitSearchDirs()...)
+		_ = gt.ScanDirectories(append(searchDirs, cfg.SearchPaths...))
+		resolver.SetRepos(gt.GetRepos())
+	}
+
+	if filter.Active() {
+		meta.Files = filter.Apply(meta.Files, extractDir, resolver)
+		if len(meta.Files) == 0 {
+			return fmt.Errorf("no files in backup match the --only/--exclude filters")
+		}
+		ui.PrintVerbose("Matched %d entries", len(meta.Files))
+	}
+
 	packagesDir := filepath.Join(extractDir, "packages")
 	macosDefaultsFile := filepath.Join(extractDir, "macos-defaults", "macos-defaults.json")
 
-	hasBrewfile := fileExists(filepath.Join(packagesDir, "Brewfile"))
-	hasMAS := fileExists(filepath.Join(packagesDir, "mas-apps.txt"))
-	hasVSCode := fileExists(filepath.Join(packagesDir, "vscode-extensions.txt"))
-	hasNPM := fileExists(filepath.Join(packagesDir, "npm-global.txt"))
-	hasMacOSDefaults := fileExists(macosDefaultsFile)
-	hasShellHistory := fileExists(filepath.Join(extractDir, "shell-history"))
+	gitReposFile := filepath.Join(extractDir, "git-repos", "git-repos.json")
+	editorsDir := filepath.Join(extractDir, "editor-settings")
+	gpgKeysDir := filepath.Join(extractDir, "gpg-keys")
 
-	useNoTUI := restoreNoTUI || !cfg.IsRestoreTUIEnabled()
+	var systemPlans []packager.SystemPlan
+	var desktopSettings *desktop.Settings
+	desktopSettingsFile := filepath.Join(extractDir, "desktop-settings", desktop.SettingsFile)
+	if currentPlatform.IsLinux() {
+		systemPlans = packager.PlanSystemPackages(packagesDir)
+		desktopSettings, _ = desktop.Load(desktopSettingsFile)
+	}
 
-	var options RestoreOptions
-	if !restoreDryRun {
-		available := tui.AvailableOptions{
-			HasBrewfile:      hasBrewfile,
-			HasMAS:           hasMAS,
-			HasVSCode:        hasVSCode,
-			HasNPM:           hasNPM,
-			HasMacOSDefaults: hasMacOSDefaults,
-			HasShellHistory:  hasShellHistory,
+	available := tui.AvailableOptions{
+		HasBrewfile:      fileExists(filepath.Join(packagesDir, "Brewfile")),
+		HasServices:      fileExists(filepath.Join(packagesDir, packager.BrewServicesFile)),
+		HasSuggested:     fileExists(filepath.Join(packagesDir, packager.SuggestedCasksFile)) && currentPlatform.IsMacOS(),
+		HasSystem:        len(systemPlans) > 0,
+		HasToolchains:    fileExists(filepath.Join(packagesDir, packager.ToolchainsFile)),
+		HasGo:            fileExists(filepath.Join(packagesDir, packager.GoBinariesFile)),
+		LanguageManagers: availableLanguageManagers(packagesDir),
+		HasLock:          fileExists(filepath.Join(packagesDir, packager.LockFile)),
+		HasMAS:           fileExists(filepath.Join(packagesDir, "mas-apps.txt")) && currentPlatform.IsMacOS(),
+		HasVSCode:        fileExists(filepath.Join(packagesDir, "vscode-extensions.txt")),
+		HasNPM:           fileExists(filepath.Join(packagesDir, "npm-global.txt")),
+		HasMacOSDefaults: fileExists(macosDefaultsFile) && currentPlatform.IsMacOS(),
+		HasDesktop:       desktopSettings != nil && len(desktopSettings.Sections) > 0,
+		HasGPG:           hasGPGKeys(gpgKeysDir),
+		Editors:          availableEditors(editorsDir),
+		HasShellHistory:  fileExists(filepath.Join(extractDir, "shell-history")),
+		HasGitRepos:      hasGitRepos(gitReposFile),
+	}
+
+	// A sandbox restore only lays down files unless packages were asked for
+	if resolver.targetRoot != "" && !restoreWithPkgs {
+		available = tui.AvailableOptions{
+			HasGPG:          available.HasGPG,
+			Editors:         available.Editors,
+			HasShellHistory: available.HasShellHistory,
+			HasGitRepos:     available.HasGitRepos,
 		}
+	}
+	if selective {
+		available = tui.AvailableOptions{}
+	}
+
+	useNoTUI := restoreNoTUI || !cfg.IsRestoreTUIEnabled()
 
+	var options RestoreOptions
+	if selective {
+		options = RestoreOptions{RestoreFiles: true}
+	} else if !restoreDryRun {
 		if useNoTUI {
 			// Use simple Y/n prompts
 			var err error
-			options, err = promptRestoreOptions(hasBrewfile, hasMAS, hasVSCode, hasNPM, hasMacOSDefaults, hasShellHistory)
+			options, err = promptRestoreOptions(available)
 			if err != nil {
 				return fmt.Errorf("failed to get restore options: %w", err)
 			}
@@ -229,26 +407,51 @@ func runRestore(cmd *cobra.Command, args []string) error {
 			options = RestoreOptions{
 				RestoreFiles:         tuiOpts.RestoreFiles,
 				RestoreMacOSDefaults: tuiOpts.RestoreMacOSDefaults,
+				RestoreDesktop:       tuiOpts.RestoreDesktop,
+				ImportGPG:            tuiOpts.ImportGPG,
+				EditorSettings:       tuiOpts.EditorSettings,
 				InstallHomebrew:      tuiOpts.InstallHomebrew,
+				StartServices:        tuiOpts.StartServices,
+				InstallSuggested:     tuiOpts.InstallSuggested,
+				InstallSystem:        tuiOpts.InstallSystem,
+				InstallToolchains:    tuiOpts.InstallToolchains,
+				InstallGo:            tuiOpts.InstallGo,
+				LanguagePackages:     tuiOpts.LanguagePackages,
+				ExactVersions:        tuiOpts.ExactVersions,
 				InstallMAS:           tuiOpts.InstallMAS,
 				InstallVSCode:        tuiOpts.InstallVSCode,
 				InstallNPM:           tuiOpts.InstallNPM,
 				RestoreShellHistory:  tuiOpts.RestoreShellHistory,
+				RestoreGitRepos:      tuiOpts.RestoreGitRepos,
 			}
 		}
 	} else {
 		// Dry run - use default options
 		options = RestoreOptions{
 			RestoreFiles:         true,
-			RestoreMacOSDefaults: hasMacOSDefaults,
-			InstallHomebrew:      hasBrewfile,
-			InstallMAS:           hasMAS,
-			InstallVSCode:        hasVSCode,
-			InstallNPM:           hasNPM,
-			RestoreShellHistory:  hasShellHistory,
+			RestoreMacOSDefaults: available.HasMacOSDefaults,
+			RestoreDesktop:       available.HasDesktop,
+			ImportGPG:            available.HasGPG,
+			EditorSettings:       editorOptionNames(available.Editors),
+			InstallHomebrew:      available.HasBrewfile,
+			StartServices:        available.HasServices,
+			InstallSuggested:     available.HasSuggested,
+			InstallSystem:        available.HasSystem,
+			InstallToolchains:    available.HasToolchains,
+			InstallGo:            available.HasGo,
+			LanguagePackages:     languageOptionNames(available.LanguageManagers),
+			InstallMAS:           available.HasMAS,
+			InstallVSCode:        available.HasVSCode,
+			InstallNPM:           available.HasNPM,
+			RestoreShellHistory:  available.HasShellHistory,
+			RestoreGitRepos:      available.HasGitRepos,
 		}
 	}
 
+	if restoreExact && available.HasLock {
+		options.ExactVersions = true
+	}
+
 	// Dry run: show summary and exit
 	if restoreDryRun {
 		fileCount := 0
@@ -260,31 +463,112 @@ func runRestore(cmd *cobra.Command, args []string) error {
 		ui.PrintInfo("DRY RUN: Would restore %d files", fileCount)
 		if restoreVerbose {
 			for _, f := range meta.Files {
-				fmt.Printf("  %s\n", f.OriginalPath)
+				fmt.Printf("  %s\n", resolver.ResolveFile(f))
+			}
+		}
+
+		for _, line := range unmatchedRepoFiles(meta.Files, resolver) {
+			ui.PrintWarning("%s", line)
+		}
+
+		if options.RestoreGitRepos {
+			printGitReposPlan(gitReposFile, resolver)
+		}
+
+		if options.RestoreDesktop {
+			printDesktopSettingsPlan(desktopSettings)
+		}
+
+		if options.ImportGPG {
+			printGPGKeysPlan(gpgKeysDir, resolver)
+		}
+
+		printEditorSettingsPlan(editorsDir, options.EditorSettings, resolver)
+
+		if options.InstallSuggested {
+			printSuggestedCasksPlan(filepath.Join(packagesDir, packager.SuggestedCasksFile))
+		}
+
+		if options.StartServices {
+			printBrewServicesPlan(filepath.Join(packagesDir, packager.BrewServicesFile))
+		}
+
+		if options.InstallSystem {
+			printSystemPackagesPlan(systemPlans)
+		}
+
+		if options.InstallToolchains {
+			printToolchainsPlan(filepath.Join(packagesDir, packager.ToolchainsFile))
+		}
+
+		if options.InstallGo {
+			printGoBinariesPlan(filepath.Join(packagesDir, packager.GoBinariesFile), options.ExactVersions)
+		}
+
+		var lock *packager.Lock
+		if options.ExactVersions {
+			lock = loadPackageLock(packagesDir)
+			ui.PrintInfo("DRY RUN: Would pin %d package(s) to the versions in %s", len(lock.Packages), packager.LockFile)
+		}
+		printLanguagePackagesPlan(packagesDir, options.LanguagePackages, lock)
+
+		items, _ := planFileRestore(meta.Files, extractDir, resolver)
+		if conflicts := detectConflicts(items); len(conflicts) > 0 {
+			ui.PrintWarning("%d local file(s) differ from the backup (policy: %s)", len(conflicts), conflictPolicy)
+			for _, c := range conflicts {
+				ui.PrintDim("  %s (%s)", c.Dest, c.Describe())
+				if restoreVerbose {
+					printConflictDiff(c)
+				}
 			}
 		}
 		return nil
 	}
 
+	// Find out whether a rollback snapshot can be written before anything is
+	// asked or changed
+	takeSnapshot := !restoreNoSnapshot
+	if takeSnapshot {
+		if err := rollback.NewManager(cfg.BackupDir).Check(keyPath); err != nil {
+			if !errors.Is(err, rollback.ErrNoKey) || strings.HasSuffix(backupFile, ".age") {
+				return fmt.Errorf("cannot create rollback snapshot: %w\nPass your key with -k to encrypt the snapshot, or use --no-snapshot to restore without one", err)
+			}
+			// An unencrypted backup needs no key, so a fresh machine may not have one
+			ui.PrintWarning("No encryption key at %s, restoring without a rollback snapshot", keyPath)
+			ui.PrintDim("  This restore can't be reverted with 'stash undo'. Run 'stash init' to create a key.")
+			takeSnapshot = false
+		}
+	}
+
 	filesToRestore := meta.Files
+	var conflictChoices map[string]string
 	if restoreEditor {
+		// Conflicts are listed in the plan so they can be resolved there too
+		planned, _ := planFileRestore(meta.Files, extractDir, resolver)
+		editorConflicts := detectConflicts(planned)
+
 		// Interactive editor mode - pick files AND packages/actions
-		selected, editorOptions, err := interactivePickAll(meta.Files, tempDir, hasBrewfile, hasMAS, hasVSCode, hasNPM, hasMacOSDefaults, hasShellHistory)
+		selected, editorOptions, choices, err := interactivePickAll(meta.Files, tempDir, editorConflicts, conflictPolicy, available, desktopSettings)
 		if err != nil {
 			return fmt.Errorf("interactive selection failed: %w", err)
 		}
 		// Only exit if user selected no files AND editor doesn't install/restore packages/defaults/etc.
 		if len(selected) == 0 && editorOptions.RestoreFiles &&
-			!editorOptions.InstallHomebrew && !editorOptions.InstallMAS &&
+			!editorOptions.InstallHomebrew && !editorOptions.StartServices && !editorOptions.InstallSuggested && !editorOptions.InstallSystem && !editorOptions.InstallToolchains && !editorOptions.InstallGo && len(editorOptions.LanguagePackages) == 0 && !editorOptions.InstallMAS &&
 			!editorOptions.InstallVSCode && !editorOptions.InstallNPM &&
-			!editorOptions.RestoreMacOSDefaults && !editorOptions.RestoreShellHistory {
+			!editorOptions.RestoreMacOSDefaults && !editorOptions.RestoreDesktop && !editorOptions.ImportGPG && len(editorOptions.EditorSettings) == 0 && !editorOptions.RestoreShellHistory &&
+			!editorOptions.RestoreGitRepos {
 			ui.PrintInfo("No restore options selected")
 			return nil
 		}
 		filesToRestore = selected
+		conflictChoices = choices
 		// Override options from editor
 		options = editorOptions
-	} else if !useNoTUI && options.RestoreFiles {
+		if restoreExact && available.HasLock {
+			options.ExactVersions = true
+		}
+	} else if !useNoTUI && options.RestoreFiles && !filter.Active() {
 		// Use TUI multi-select for file selection (only for smaller backups and if user chose to restore files)
 		if len(meta.Files) <= cfg.GetRestoreFilePickerThreshold() {
 			selected, err := tui.FilePickerForm(meta.Files)
@@ -305,61 +589,122 @@ func runRestore(cmd *cobra.Command, args []string) error {
 	successCount := 0
 	skippedCount := 0
 	var restoreWarnings []string
+	var journal *rollback.Journal
+	var conflictStats conflictSummary
+
+	// Repos are cloned first so files backed up from them land in the clones.
+	// Clones aren't part of the rollback snapshot, which is why it is checked
+	// above before anything is cloned.
+	if options.RestoreGitRepos {
+		warnings, repos := restoreGitRepos(gitReposFile, resolver, cfg.GetGitCloneWorkers())
+		restoreWarnings = append(restoreWarnings, warnings...)
+		resolver.AddRepos(repos)
+	}
 
+	var items []restoreItem
 	if options.RestoreFiles {
-		for _, fileInfo := range filesToRestore {
-			// Validate that the backup path doesn't escape the extraction directory
-			cleanedBackupPath := filepath.Clean(fileInfo.BackupPath)
-			backupFilePath := filepath.Join(extractDir, cleanedBackupPath)
-
-			// Ensure the resolved path stays within extractDir
-			rel, err := filepath.Rel(extractDir, backupFilePath)
-			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
-				ui.PrintVerbose("Skipping file with unsafe backup path: %s", fileInfo.BackupPath)
-				skippedCount++
-				continue
-			}
+		var unsafe int
+		items, unsafe = planFileRestore(filesToRestore, extractDir, resolver)
+		skippedCount += unsafe
+		restoreWarnings = append(restoreWarnings, unmatchedRepoFiles(filesToRestore, resolver)...)
+	}
 
-			destPath := fileInfo.OriginalPath
+	// Editor settings are copied later but share the conflict checks and
+	// rollback snapshot with files
+	var editorItems []restoreItem
+	var editorStorage []string
+	if len(options.EditorSettings) > 0 {
+		editorItems, editorStorage = planEditorSettings(editorsDir, options.EditorSettings, resolver)
+	}
 
-			if strings.HasPrefix(destPath, "~") {
-				homeDir, _ := os.UserHomeDir()
-				destPath = filepath.Join(homeDir, destPath[1:])
-			}
+	// Desktop sections are picked now so the KDE rc files they write go
+	// through the same conflict checks and snapshot
+	var desktopSections []desktop.Section
+	var desktopItems []restoreItem
+	if options.RestoreDesktop && desktopSettings != nil {
+		desktopSections, err = pickDesktopSections(desktopSettings, options.DesktopSections, !useNoTUI && !restoreEditor)
+		if err != nil {
+			restoreWarnings = append(restoreWarnings, fmt.Sprintf("Desktop settings selection: %v", err))
+		}
+		desktopItems = planDesktopSettings(desktopSections, filepath.Join(tempDir, "desktop-staging"), resolver)
+	}
+	targets := append(append(append([]restoreItem{}, items...), editorItems...), desktopItems...)
+
+	conflicts := detectConflicts(targets)
+	for _, c := range conflicts {
+		if choice, ok := conflictChoices[c.Dest]; ok {
+			c.Resolution = choice
+		}
+	}
+	if err := resolveConflicts(conflicts, conflictPolicy, useNoTUI); err != nil {
+		return fmt.Errorf("failed to resolve conflicts: %w", err)
+	}
+	conflictMap := conflictsByDest(conflicts)
 
-			if fileInfo.IsDir {
-				if err := arch.CopyDir(backupFilePath, destPath); err != nil {
-					ui.PrintVerbose("Failed: %s - %v", fileInfo.OriginalPath, err)
+	// Conflicting files are written separately according to their resolution
+	skipConflict := func(dest string) bool {
+		_, ok := conflictMap[dest]
+		return ok
+	}
+
+	if takeSnapshot && len(targets) > 0 {
+		ui.PrintVerbose("Creating rollback snapshot...")
+		journal, err = snapshotRestoreTargets(targets, skipConflict, append(conflictWritePaths(conflicts), editorStorage...), cfg.BackupDir, filepath.Base(backupFile), keyPath)
+		if err != nil {
+			return fmt.Errorf("failed to create rollback snapshot: %w\nPass your key with -k to encrypt the snapshot, or use --no-snapshot to restore without one", err)
+		}
+	}
+
+	if options.RestoreFiles {
+		for _, item := range items {
+			if item.Info.IsDir {
+				if err := arch.CopyDirExcept(item.Source, item.Dest, skipConflict); err != nil {
+					ui.PrintVerbose("Failed: %s - %v", item.Info.OriginalPath, err)
 					skippedCount++
 					continue
 				}
+				successCount += copiedFileCount(item.Source, item.Dest, skipConflict)
+			} else if skipConflict(item.Dest) {
+				continue
 			} else {
-				if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
-					ui.PrintVerbose("Failed to create dir for %s", fileInfo.OriginalPath)
+				if err := os.MkdirAll(filepath.Dir(item.Dest), 0755); err != nil {
+					ui.PrintVerbose("Failed to create dir for %s", item.Info.OriginalPath)
 					skippedCount++
 					continue
 				}
 
-				if err := arch.CopyFile(backupFilePath, destPath); err != nil {
-					ui.PrintVerbose("Failed: %s - %v", fileInfo.OriginalPath, err)
+				if err := arch.CopyFile(item.Source, item.Dest); err != nil {
+					ui.PrintVerbose("Failed: %s - %v", item.Info.OriginalPath, err)
 					skippedCount++
 					continue
 				}
 
-				_ = os.Chmod(destPath, fileInfo.Mode)
+				_ = os.Chmod(item.Dest, item.Info.Mode)
+				successCount++
 			}
 
-			ui.PrintVerbose("Restored: %s", fileInfo.OriginalPath)
+			ui.PrintVerbose("Restored: %s", item.Info.OriginalPath)
 		}
+	}
 
-		successCount = len(filesToRestore) - skippedCount
+	for _, c := range conflicts {
+		if err := applyConflictResolution(arch, c, &conflictStats); err != nil {
+			ui.PrintVerbose("Failed: %s - %v", c.Dest, err)
+			restoreWarnings = append(restoreWarnings, fmt.Sprintf("Conflict %s: %v", c.Dest, err))
+		}
+	}
+	// Kept, saved and merged files are reported with the conflicts instead
+	successCount += conflictStats.Overwritten
+
+	if options.RestoreFiles {
+		restoreWarnings = append(restoreWarnings, applyPermissionPolicy(cfg, items, conflicts, resolver)...)
 	}
 
 	homeDir, _ := os.UserHomeDir()
-	stashBackupsDir := filepath.Join(homeDir, "stash-backups")
+	stashBackupsDir := resolver.Rooted(filepath.Join(homeDir, "stash-backups"))
 	persistentPackagesDir := filepath.Join(stashBackupsDir, "packages")
 
-	if _, err := os.Stat(packagesDir); err == nil {
+	if _, err := os.Stat(packagesDir); err == nil && !selective {
 		_ = os.MkdirAll(stashBackupsDir, 0755)
 		os.RemoveAll(persistentPackagesDir)
 		arch := archiver.NewArchiver()
@@ -375,104 +720,111 @@ func runRestore(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	installer := packager.NewInstaller(false)
+	if len(desktopSections) > 0 {
+		restoreWarnings = append(restoreWarnings, restoreDesktopSettings(desktopSettingsFile, desktopSections, desktopItems, resolver, skipConflict)...)
+	}
 
-	if options.InstallHomebrew && fileExists(filepath.Join(persistentPackagesDir, "Brewfile")) {
-		brewfilePath := filepath.Join(persistentPackagesDir, "Brewfile")
+	if options.ImportGPG {
+		restoreWarnings = append(restoreWarnings, importGPGKeys(gpgKeysDir, resolver)...)
+	}
 
-		// Parse Brewfile into individual items
-		items, err := packager.ParseBrewfile(brewfilePath)
-		if err != nil {
-			ui.PrintWarning("Failed to parse Brewfile: %v", err)
-		} else if !useNoTUI && len(items) > 0 {
-			// Show package picker if not in no-TUI mode
-			var tuiItems []tui.BrewPackageItem
-			for _, item := range items {
-				tuiItems = append(tuiItems, tui.BrewPackageItem{
-					Type:    item.Type,
-					Name:    item.Name,
-					Label:   packager.FormatBrewfileItem(item),
-					RawLine: item.RawLine,
-				})
-			}
+	if len(options.EditorSettings) > 0 {
+		restoreWarnings = append(restoreWarnings, restoreEditorSettings(editorsDir, options.EditorSettings, resolver, skipConflict)...)
+	}
 
-			selectedItems, err := tui.BrewPackagePickerForm(tuiItems)
-			if err != nil {
-				ui.PrintWarning("Package selection failed: %v", err)
-			} else if len(selectedItems) == 0 {
-				ui.PrintInfo("No packages selected, skipping Homebrew installation")
-			} else {
-				// Create filtered Brewfile with selected packages
-				var filteredItems []packager.BrewfileItem
-				for _, tuiItem := range selectedItems {
-					filteredItems = append(filteredItems, packager.BrewfileItem{
-						Type:    tuiItem.Type,
-						Name:    tuiItem.Name,
-						RawLine: tuiItem.RawLine,
-					})
-				}
+	installer := newPackageInstaller(cfg)
+	var lock *packager.Lock
+	if options.ExactVersions {
+		lock = loadPackageLock(persistentPackagesDir)
+		installer.UseLock(lock)
+	}
 
-				tempBrewfile := filepath.Join(tempDir, "Brewfile.filtered")
-				if err := packager.CreateFilteredBrewfile(filteredItems, tempBrewfile); err != nil {
-					ui.PrintWarning("Failed to create filtered Brewfile: %v", err)
-				} else {
-					ui.PrintVerbose("Installing %d selected packages...", len(selectedItems))
-					if err := installer.InstallBrewPackages(tempBrewfile); err != nil {
-						ui.PrintWarning("Homebrew failed: %v", err)
-						if brewErr, ok := err.(*packager.BrewInstallError); ok && len(brewErr.FailedPackages) > 0 {
-							restoreWarnings = append(restoreWarnings, fmt.Sprintf("Homebrew failed packages: %s", strings.Join(brewErr.FailedPackages, ", ")))
-						} else {
-							restoreWarnings = append(restoreWarnings, fmt.Sprintf("Homebrew: %v", err))
-						}
-					}
-				}
-			}
-		} else {
-			// Install all packages (no-TUI mode or parse error)
-			ui.PrintVerbose("Installing Homebrew packages...")
-			if err := installer.InstallBrewPackages(brewfilePath); err != nil {
-				ui.PrintWarning("Homebrew failed: %v", err)
-				if brewErr, ok := err.(*packager.BrewInstallError); ok && len(brewErr.FailedPackages) > 0 {
-					restoreWarnings = append(restoreWarnings, fmt.Sprintf("Homebrew failed packages: %s", strings.Join(brewErr.FailedPackages, ", ")))
-				} else {
-					restoreWarnings = append(restoreWarnings, fmt.Sprintf("Homebrew: %v", err))
-				}
-			}
+	// Package installs other than system packages and runtimes are tracked
+	// item by item, so an interrupted restore can continue with --resume-install
+	plan := packager.NewInstallPlan(filepath.Join(persistentPackagesDir, packager.InstallPlanFile), resolvedBackup.Name)
+	plan.ExactVersions = options.ExactVersions
+	if options.InstallHomebrew && fileExists(filepath.Join(persistentPackagesDir, "Brewfile")) {
+		if brewfile := pickBrewfile(filepath.Join(persistentPackagesDir, "Brewfile"), !useNoTUI); brewfile != nil {
+			plan.AddBrewfile(brewfile)
+		}
+	}
+	if options.InstallSuggested && fileExists(filepath.Join(persistentPackagesDir, packager.SuggestedCasksFile)) {
+		if brewfile := pickBrewfile(filepath.Join(persistentPackagesDir, packager.SuggestedCasksFile), !useNoTUI); brewfile != nil {
+			plan.AddBrewfile(brewfile)
 		}
 	}
-
 	if options.InstallMAS && fileExists(filepath.Join(persistentPackagesDir, "mas-apps.txt")) {
-		ui.PrintVerbose("Installing Mac App Store apps...")
-		count, err := installer.InstallMASApps(filepath.Join(persistentPackagesDir, "mas-apps.txt"))
-		if err != nil {
+		if err := plan.AddListFile(packager.ManagerMAS, filepath.Join(persistentPackagesDir, "mas-apps.txt")); err != nil {
 			restoreWarnings = append(restoreWarnings, fmt.Sprintf("MAS: %v", err))
-		} else {
-			ui.PrintSuccess("Installed %d Mac App Store app(s)", count)
 		}
 	}
-
 	if options.InstallVSCode && fileExists(filepath.Join(persistentPackagesDir, "vscode-extensions.txt")) {
-		ui.PrintVerbose("Installing VS Code extensions...")
-		count, err := installer.InstallVSCodeExtensions(filepath.Join(persistentPackagesDir, "vscode-extensions.txt"))
-		if err != nil {
+		if err := plan.AddListFile(packager.ManagerVSCode, filepath.Join(persistentPackagesDir, "vscode-extensions.txt")); err != nil {
 			restoreWarnings = append(restoreWarnings, fmt.Sprintf("VS Code extensions: %v", err))
-		} else {
-			ui.PrintSuccess("Installed %d VS Code extension(s)", count)
 		}
 	}
-
+	if options.InstallGo && fileExists(filepath.Join(persistentPackagesDir, packager.GoBinariesFile)) {
+		if err := plan.AddGoBinaries(filepath.Join(persistentPackagesDir, packager.GoBinariesFile), lock); err != nil {
+			restoreWarnings = append(restoreWarnings, fmt.Sprintf("Go binaries: %v", err))
+		}
+	}
+	restoreWarnings = append(restoreWarnings, planLanguagePackages(plan, persistentPackagesDir, options.LanguagePackages, lock)...)
 	if options.InstallNPM && fileExists(filepath.Join(persistentPackagesDir, "npm-global.txt")) {
-		ui.PrintVerbose("Installing NPM packages...")
-		if err := installer.InstallNPMPackages(filepath.Join(persistentPackagesDir, "npm-global.txt")); err != nil {
+		if err := plan.AddNPMPackages(filepath.Join(persistentPackagesDir, "npm-global.txt"), lock); err != nil {
 			restoreWarnings = append(restoreWarnings, fmt.Sprintf("NPM globals: %v", err))
 		}
 	}
+	if len(plan.Items) > 0 {
+		if err := plan.Save(); err != nil {
+			ui.PrintWarning("Failed to save install plan: %v", err)
+		}
+	}
+
+	restoreWarnings = append(restoreWarnings, runInstallPlan(installer, plan, packager.ManagerBrew)...)
+
+	if options.StartServices && fileExists(filepath.Join(persistentPackagesDir, packager.BrewServicesFile)) {
+		restoreWarnings = append(restoreWarnings, startBrewServices(installer, filepath.Join(persistentPackagesDir, packager.BrewServicesFile))...)
+	}
+
+	if options.InstallSystem {
+		restoreWarnings = append(restoreWarnings, installSystemPackages(installer, systemPlans)...)
+	}
+
+	// Runtimes are built after Homebrew and system packages, which provide their build dependencies
+	if options.InstallToolchains && fileExists(filepath.Join(persistentPackagesDir, packager.ToolchainsFile)) {
+		restoreWarnings = append(restoreWarnings, installToolchains(installer, filepath.Join(persistentPackagesDir, packager.ToolchainsFile))...)
+	}
+
+	// After runtimes, since Go itself may come from mise or asdf
+	restoreWarnings = append(restoreWarnings, runInstallPlan(installer, plan, packager.ManagerGo)...)
+
+	for _, manager := range options.LanguagePackages {
+		restoreWarnings = append(restoreWarnings, runInstallPlan(installer, plan, manager)...)
+	}
+
+	restoreWarnings = append(restoreWarnings, runInstallPlan(installer, plan, packager.ManagerMAS)...)
+	restoreWarnings = append(restoreWarnings, runInstallPlan(installer, plan, packager.ManagerVSCode)...)
+	restoreWarnings = append(restoreWarnings, runInstallPlan(installer, plan, packager.ManagerNPM)...)
 
 	// Final output
 	ui.PrintSuccess("Restored %d files", successCount)
 	if skippedCount > 0 {
 		ui.PrintDim("  Skipped: %d", skippedCount)
 	}
+	if conflictStats.Kept+conflictStats.Saved+conflictStats.Merged > 0 {
+		ui.PrintDim("  Conflicts: %d kept, %d saved as %s, %d merged",
+			conflictStats.Kept, conflictStats.Saved, restoredSuffix, conflictStats.Merged)
+	}
+	if conflictStats.Merged > 0 {
+		ui.PrintDim("  Merged files contain <<<<<<< markers to resolve by hand")
+	}
+	if journal != nil {
+		ui.PrintDim("  Undo: stash undo %s", journal.ID)
+		if options.RestoreGitRepos {
+			ui.PrintDim("  Git repos cloned by this restore are not removed by undo")
+		}
+	}
+	finishInstallPlan(plan)
 	if len(restoreWarnings) > 0 {
 		fmt.Println()
 		ui.PrintWarning("Restore completed with warnings:")
@@ -484,7 +836,137 @@ func runRestore(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func interactivePickAll(files []metadata.FileInfo, tempDir string, hasBrewfile, hasMAS, hasVSCode, hasNPM, hasMacOSDefaults, hasShellHistory bool) ([]metadata.FileInfo, RestoreOptions, error) {
+// restoreItem is a backed-up file or directory resolved to its source in the
+// extracted archive and its destination on disk
+type restoreItem struct {
+	Info   metadata.FileInfo
+	Source string
+	Dest   string
+	// Exclude, if set, reports files inside a directory item that aren't
+	// restored; otherwise the archiver's CopyDir exclusions apply
+	Exclude func(destPath string) bool
+}
+
+// copiedFileCount counts the files a directory copy from src to dest
+// writes, leaving out excluded names, symlinks and files skip reports
+func copiedFileCount(src, dest string, skip func(string) bool) int {
+	count := 0
+	_ = filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if path != src && archiver.Excluded(info.Name()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil || skip(filepath.Join(dest, rel)) {
+			return nil
+		}
+		count++
+		return nil
+	})
+	return count
+}
+
+// planFileRestore resolves source and destination paths for each file,
+// dropping entries whose backup path escapes the extraction directory
+func planFileRestore(files []metadata.FileInfo, extractDir string, resolver destResolver) ([]restoreItem, int) {
+	var items []restoreItem
+	skipped := 0
+
+	for _, fileInfo := range files {
+		// Validate that the backup path doesn't escape the extraction directory
+		cleanedBackupPath := filepath.Clean(fileInfo.BackupPath)
+		backupFilePath := filepath.Join(extractDir, cleanedBackupPath)
+
+		// Ensure the resolved path stays within extractDir
+		rel, err := filepath.Rel(extractDir, backupFilePath)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+			ui.PrintVerbose("Skipping file with unsafe backup path: %s", fileInfo.BackupPath)
+			skipped++
+			continue
+		}
+
+		items = append(items, restoreItem{
+			Info:   fileInfo,
+			Source: backupFilePath,
+			Dest:   resolver.ResolveFile(fileInfo),
+		})
+	}
+
+	return items, skipped
+}
+
+// hasRepoFiles reports whether any file was backed up from a git repository
+func hasRepoFiles(files []metadata.FileInfo) bool {
+	for _, f := range files {
+		if f.GitRemote != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// unmatchedRepoFiles describes files whose repository has no clone on this
+// machine; they are restored to their original location instead
+func unmatchedRepoFiles(files []metadata.FileInfo, resolver destResolver) []string {
+	var unmatched []string
+	for _, f := range files {
+		if f.GitRemote == "" {
+			continue
+		}
+		if _, ok := resolver.RepoClone(f); ok {
+			continue
+		}
+		unmatched = append(unmatched, fmt.Sprintf("%s from %s: repo not cloned here, using original path %s", f.GitRelPath, f.GitRemote, resolver.ResolveFile(f)))
+	}
+	return unmatched
+}
+
+// snapshotRestoreTargets captures everything the restore is about to overwrite
+// into an encrypted rollback archive so it can be reverted with `stash undo`.
+// Files skip reports are left to conflict resolution, so only those listed
+// in extraPaths are captured.
+func snapshotRestoreTargets(items []restoreItem, skip func(string) bool, extraPaths []string, backupDir, backupName, keyPath string) (*rollback.Journal, error) {
+	rb := rollback.NewManager(backupDir)
+	snap, err := rb.Begin(backupName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		var captureErr error
+		if item.Info.IsDir {
+			exclude := item.Exclude
+			captureErr = snap.CaptureTree(item.Source, item.Dest, func(dest string) bool {
+				return (exclude != nil && exclude(dest)) || skip(dest)
+			})
+		} else if !skip(item.Dest) {
+			captureErr = snap.CaptureFile(item.Dest)
+		}
+		if captureErr != nil {
+			snap.Abort()
+			return nil, captureErr
+		}
+	}
+
+	for _, path := range extraPaths {
+		if err := snap.CaptureFile(path); err != nil {
+			snap.Abort()
+			return nil, err
+		}
+	}
+
+	return snap.Commit(keyPath)
+}
+
+func interactivePickAll(files []metadata.FileInfo, tempDir string, conflicts []*restoreConflict, conflictPolicy string, available tui.AvailableOptions, desktopSettings *desktop.Settings) ([]metadata.FileInfo, RestoreOptions, map[string]string, error) {
 	planPath := filepath.Join(tempDir, "RESTORE_PLAN")
 
 	var content strings.Builder
@@ -500,24 +982,63 @@ func interactivePickAll(files []metadata.FileInfo, tempDir string, hasBrewfile,
 	// Add package installation options
 	content.WriteString("# === PACKAGES & SETTINGS ===\n\n")
 
-	if hasBrewfile {
+	if available.HasBrewfile {
 		content.WriteString("pick [BREW] Install Homebrew packages (may take a while)\n")
 	}
-	if hasMAS {
+	if available.HasServices {
+		content.WriteString("pick [SRV ] Start Homebrew services that were running\n")
+	}
+	if available.HasSuggested {
+		content.WriteString(fmt.Sprintf("drop [CASK] Install Homebrew casks for manually installed apps (see %s)\n", packager.SuggestedCasksFile))
+	}
+	if available.HasSystem {
+		content.WriteString("pick [SYS ] Install system packages (apt, dnf, pacman, flatpak, snap)\n")
+	}
+	if available.HasToolchains {
+		content.WriteString("pick [LANG] Install language runtimes (mise, asdf, nvm, pyenv, rbenv, rustup)\n")
+	}
+	if available.HasGo {
+		content.WriteString("pick [GO  ] Install Go binaries (go install)\n")
+	}
+	for _, lang := range available.LanguageManagers {
+		action := "drop"
+		if lang.Default {
+			action = "pick"
+		}
+		content.WriteString(fmt.Sprintf("%s [PKG ] %s (%d %s)\n", action, lang.Name, lang.Count, lang.Label))
+	}
+	if available.HasMAS {
 		content.WriteString("drop [MAS ] Install Mac App Store apps\n")
 	}
-	if hasVSCode {
+	if available.HasVSCode {
 		content.WriteString("pick [CODE] Install VS Code extensions\n")
 	}
-	if hasNPM {
+	if available.HasNPM {
 		content.WriteString("drop [NPM ] Install NPM global packages\n")
 	}
-	if hasMacOSDefaults {
+	if available.HasLock {
+		content.WriteString(fmt.Sprintf("drop [LOCK] Install exact versions from %s (where possible)\n", packager.LockFile))
+	}
+	if available.HasMacOSDefaults {
 		content.WriteString("pick [PREF] Restore macOS defaults (Dock, Finder, etc.)\n")
 	}
-	if hasShellHistory {
+	if available.HasDesktop {
+		for _, section := range desktopSettings.Sections {
+			content.WriteString(fmt.Sprintf("pick [DESK] %s (%d keys)\n", section.ID(), section.KeyCount()))
+		}
+	}
+	if available.HasGPG {
+		content.WriteString("pick [GPG ] Import GPG keys and trust\n")
+	}
+	for _, editor := range available.Editors {
+		content.WriteString(fmt.Sprintf("pick [EDIT] %s (%s settings, %d items)\n", editor.Name, editor.Label, editor.Count))
+	}
+	if available.HasShellHistory {
 		content.WriteString("pick [HIST] Restore shell history\n")
 	}
+	if available.HasGitRepos {
+		content.WriteString("drop [GIT ] Clone git repositories and reapply saved work\n")
+	}
 
 	content.WriteString("\n# === FILES & DIRECTORIES ===\n\n")
 
@@ -530,8 +1051,26 @@ func interactivePickAll(files []metadata.FileInfo, tempDir string, hasBrewfile,
 		content.WriteString(fmt.Sprintf("pick [%s] %s (%s)\n", fileType, fileInfo.OriginalPath, size))
 	}
 
+	if len(conflicts) > 0 {
+		defaultResolution := conflictPolicy
+		if defaultResolution == tui.ConflictAsk {
+			defaultResolution = tui.ConflictOverwrite
+		}
+
+		content.WriteString("\n# === CONFLICTS ===\n")
+		content.WriteString("# These local files differ from the backup (+added -removed by overwriting).\n")
+		content.WriteString("#   overwrite = replace the local file with the backup\n")
+		content.WriteString("#   keep      = leave the local file untouched\n")
+		content.WriteString("#   save-as   = write the backup next to it as <file>" + restoredSuffix + "\n")
+		content.WriteString("#   merge     = combine both with conflict markers (text files only)\n\n")
+
+		for _, c := range conflicts {
+			content.WriteString(fmt.Sprintf("%s [CONF] %s (%s)\n", defaultResolution, c.Dest, c.Describe()))
+		}
+	}
+
 	if err := os.WriteFile(planPath, []byte(content.String()), 0644); err != nil {
-		return nil, RestoreOptions{}, fmt.Errorf("failed to create restore plan: %w", err)
+		return nil, RestoreOptions{}, nil, fmt.Errorf("failed to create restore plan: %w", err)
 	}
 
 	editor := os.Getenv("EDITOR")
@@ -553,12 +1092,12 @@ func interactivePickAll(files []metadata.FileInfo, tempDir string, hasBrewfile,
 	cmd.Stderr = os.Stderr
 
 	if err := cmd.Run(); err != nil {
-		return nil, RestoreOptions{}, fmt.Errorf("editor failed: %w", err)
+		return nil, RestoreOptions{}, nil, fmt.Errorf("editor failed: %w", err)
 	}
 
 	planContent, err := os.ReadFile(planPath)
 	if err != nil {
-		return nil, RestoreOptions{}, fmt.Errorf("failed to read restore plan: %w", err)
+		return nil, RestoreOptions{}, nil, fmt.Errorf("failed to read restore plan: %w", err)
 	}
 
 	fileMap := make(map[string]metadata.FileInfo)
@@ -568,6 +1107,7 @@ func interactivePickAll(files []metadata.FileInfo, tempDir string, hasBrewfile,
 
 	var selected []metadata.FileInfo
 	options := RestoreOptions{RestoreFiles: true}
+	conflictChoices := make(map[string]string)
 
 	scanner := bufio.NewScanner(strings.NewReader(string(planContent)))
 	lineNum := 0
@@ -587,6 +1127,18 @@ func interactivePickAll(files []metadata.FileInfo, tempDir string, hasBrewfile,
 		}
 
 		action := parts[0]
+
+		// Handle conflict resolutions
+		if strings.Trim(parts[1], "[]") == "CONF" {
+			path := parsePlanPath(strings.Join(parts[1:], " "))
+			if path == "" || validateConflictPolicy(action) != nil || action == tui.ConflictAsk {
+				fmt.Printf("⚠️  Warning: couldn't parse conflict on line %d\n", lineNum)
+				continue
+			}
+			conflictChoices[path] = action
+			continue
+		}
+
 		if action != "pick" && action != "drop" {
 			fmt.Printf("⚠️  Warning: unknown action '%s' on line %d, treating as 'drop'\n", action, lineNum)
 			continue
@@ -599,6 +1151,29 @@ func interactivePickAll(files []metadata.FileInfo, tempDir string, hasBrewfile,
 		case "BREW":
 			options.InstallHomebrew = (action == "pick")
 			continue
+		case "SRV":
+			options.StartServices = (action == "pick")
+			continue
+		case "CASK":
+			options.InstallSuggested = (action == "pick")
+			continue
+		case "SYS":
+			options.InstallSystem = (action == "pick")
+			continue
+		case "LANG":
+			options.InstallToolchains = (action == "pick")
+			continue
+		case "GO":
+			options.InstallGo = (action == "pick")
+			continue
+		case "LOCK":
+			options.ExactVersions = (action == "pick")
+			continue
+		case "PKG":
+			if name := parsePlanPath(strings.Join(parts[1:], " ")); action == "pick" && name != "" {
+				options.LanguagePackages = append(options.LanguagePackages, name)
+			}
+			continue
 		case "MAS":
 			options.InstallMAS = (action == "pick")
 			continue
@@ -611,9 +1186,26 @@ func interactivePickAll(files []metadata.FileInfo, tempDir string, hasBrewfile,
 		case "PREF":
 			options.RestoreMacOSDefaults = (action == "pick")
 			continue
+		case "DESK":
+			if id := parsePlanPath(strings.Join(parts[1:], " ")); action == "pick" && id != "" {
+				options.RestoreDesktop = true
+				options.DesktopSections = append(options.DesktopSections, id)
+			}
+			continue
+		case "GPG":
+			options.ImportGPG = (action == "pick")
+			continue
+		case "EDIT":
+			if name := parsePlanPath(strings.Join(parts[1:], " ")); action == "pick" && name != "" {
+				options.EditorSettings = append(options.EditorSettings, name)
+			}
+			continue
 		case "HIST":
 			options.RestoreShellHistory = (action == "pick")
 			continue
+		case "GIT":
+			options.RestoreGitRepos = (action == "pick")
+			continue
 		}
 
 		// Handle file items
@@ -622,17 +1214,12 @@ func interactivePickAll(files []metadata.FileInfo, tempDir string, hasBrewfile,
 			continue
 		}
 
-		restOfLine := strings.Join(parts[1:], " ")
-		startIdx := strings.Index(restOfLine, "]")
-		endIdx := strings.LastIndex(restOfLine, "(")
-
-		if startIdx == -1 || endIdx == -1 || startIdx >= endIdx {
+		path := parsePlanPath(strings.Join(parts[1:], " "))
+		if path == "" {
 			fmt.Printf("⚠️  Warning: couldn't parse path on line %d\n", lineNum)
 			continue
 		}
 
-		path := strings.TrimSpace(restOfLine[startIdx+1 : endIdx])
-
 		if action == "pick" {
 			if fileInfo, ok := fileMap[path]; ok {
 				selected = append(selected, fileInfo)
@@ -643,19 +1230,31 @@ func interactivePickAll(files []metadata.FileInfo, tempDir string, hasBrewfile,
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, RestoreOptions{}, fmt.Errorf("failed to parse restore plan: %w", err)
+		return nil, RestoreOptions{}, nil, fmt.Errorf("failed to parse restore plan: %w", err)
+	}
+
+	return selected, options, conflictChoices, nil
+}
+
+// parsePlanPath extracts the path from "[TYPE] path (details)" in a restore plan line
+func parsePlanPath(restOfLine string) string {
+	startIdx := strings.Index(restOfLine, "]")
+	endIdx := strings.LastIndex(restOfLine, "(")
+
+	if startIdx == -1 || endIdx == -1 || startIdx >= endIdx {
+		return ""
 	}
 
-	return selected, options, nil
+	return strings.TrimSpace(restOfLine[startIdx+1 : endIdx])
 }
 
 func interactivePickFiles(files []metadata.FileInfo, tempDir string) ([]metadata.FileInfo, error) {
 	// Kept for backwards compatibility - just calls the new function
-	selected, _, err := interactivePickAll(files, tempDir, false, false, false, false, false, false)
+	selected, _, _, err := interactivePickAll(files, tempDir, nil, tui.ConflictAsk, tui.AvailableOptions{}, nil)
 	return selected, err
 }
 
-func promptRestoreOptions(hasBrewfile, hasMAS, hasVSCode, hasNPM, hasMacOSDefaults, hasShellHistory bool) (RestoreOptions, error) {
+func promptRestoreOptions(available tui.AvailableOptions) (RestoreOptions, error) {
 	reader := bufio.NewReader(os.Stdin)
 	options := RestoreOptions{}
 
@@ -667,42 +1266,120 @@ func promptRestoreOptions(hasBrewfile, hasMAS, hasVSCode, hasNPM, hasMacOSDefaul
 	options.RestoreFiles = true
 	fmt.Println("\n✓ Files (dotfiles, SSH, GPG, configs, etc.) - Always included")
 
-	if hasMacOSDefaults {
+	if available.HasMacOSDefaults {
 		fmt.Print("\n🔧 Restore macOS defaults (Dock, Finder, trackpad, etc.)? [Y/n]: ")
 		response, _ := reader.ReadString('\n')
 		options.RestoreMacOSDefaults = !strings.EqualFold(strings.TrimSpace(response), "n")
 	}
 
-	if hasShellHistory {
+	if available.HasDesktop {
+		fmt.Print("\n🖥️  Restore desktop settings (GNOME/KDE)? [Y/n]: ")
+		response, _ := reader.ReadString('\n')
+		options.RestoreDesktop = !strings.EqualFold(strings.TrimSpace(response), "n")
+	}
+
+	if available.HasGPG {
+		fmt.Print("\n🔑 Import GPG keys and trust? [Y/n]: ")
+		response, _ := reader.ReadString('\n')
+		options.ImportGPG = !strings.EqualFold(strings.TrimSpace(response), "n")
+	}
+
+	for _, editor := range available.Editors {
+		fmt.Printf("\n✏️  Restore %s settings (%d items)? [Y/n]: ", editor.Label, editor.Count)
+		response, _ := reader.ReadString('\n')
+		if !strings.EqualFold(strings.TrimSpace(response), "n") {
+			options.EditorSettings = append(options.EditorSettings, editor.Name)
+		}
+	}
+
+	if available.HasShellHistory {
 		fmt.Print("\n📜 Restore shell history? [Y/n]: ")
 		response, _ := reader.ReadString('\n')
 		options.RestoreShellHistory = !strings.EqualFold(strings.TrimSpace(response), "n")
 	}
 
-	if hasBrewfile {
+	if available.HasGitRepos {
+		fmt.Print("\n🌿 Clone git repositories and reapply saved work? [y/N]: ")
+		response, _ := reader.ReadString('\n')
+		options.RestoreGitRepos = strings.EqualFold(strings.TrimSpace(response), "y") || strings.EqualFold(strings.TrimSpace(response), "yes")
+	}
+
+	if available.HasBrewfile {
 		fmt.Print("\n🍺 Install Homebrew packages (this may take a while)? [Y/n]: ")
 		response, _ := reader.ReadString('\n')
 		options.InstallHomebrew = !strings.EqualFold(strings.TrimSpace(response), "n")
 	}
 
-	if hasMAS {
+	if available.HasServices {
+		fmt.Print("\n⚙️  Start the Homebrew services that were running? [Y/n]: ")
+		response, _ := reader.ReadString('\n')
+		options.StartServices = !strings.EqualFold(strings.TrimSpace(response), "n")
+	}
+
+	if available.HasSuggested {
+		fmt.Print("\n📦 Install Homebrew casks for apps that were installed manually? [y/N]: ")
+		response, _ := reader.ReadString('\n')
+		options.InstallSuggested = strings.EqualFold(strings.TrimSpace(response), "y") || strings.EqualFold(strings.TrimSpace(response), "yes")
+	}
+
+	if available.HasSystem {
+		fmt.Print("\n🐧 Install system packages (apt, dnf, pacman, flatpak, snap)? [Y/n]: ")
+		response, _ := reader.ReadString('\n')
+		options.InstallSystem = !strings.EqualFold(strings.TrimSpace(response), "n")
+	}
+
+	if available.HasToolchains {
+		fmt.Print("\n🧰 Install language runtimes (mise, asdf, nvm, pyenv, rbenv, rustup)? [Y/n]: ")
+		response, _ := reader.ReadString('\n')
+		options.InstallToolchains = !strings.EqualFold(strings.TrimSpace(response), "n")
+	}
+
+	if available.HasGo {
+		fmt.Print("\n🐹 Install Go binaries with go install? [Y/n]: ")
+		response, _ := reader.ReadString('\n')
+		options.InstallGo = !strings.EqualFold(strings.TrimSpace(response), "n")
+	}
+
+	for _, lang := range available.LanguageManagers {
+		var install bool
+		if lang.Default {
+			fmt.Printf("\n📦 Install %d %s? [Y/n]: ", lang.Count, lang.Label)
+			response, _ := reader.ReadString('\n')
+			install = !strings.EqualFold(strings.TrimSpace(response), "n")
+		} else {
+			fmt.Printf("\n📦 Install %d %s? [y/N]: ", lang.Count, lang.Label)
+			response, _ := reader.ReadString('\n')
+			install = strings.EqualFold(strings.TrimSpace(response), "y") || strings.EqualFold(strings.TrimSpace(response), "yes")
+		}
+		if install {
+			options.LanguagePackages = append(options.LanguagePackages, lang.Name)
+		}
+	}
+
+	if available.HasMAS {
 		fmt.Print("\n🏪 Install Mac App Store apps? [y/N]: ")
 		response, _ := reader.ReadString('\n')
 		options.InstallMAS = strings.EqualFold(strings.TrimSpace(response), "y") || strings.EqualFold(strings.TrimSpace(response), "yes")
 	}
 
-	if hasVSCode {
+	if available.HasVSCode {
 		fmt.Print("\n💻 Install VS Code extensions? [Y/n]: ")
 		response, _ := reader.ReadString('\n')
 		options.InstallVSCode = !strings.EqualFold(strings.TrimSpace(response), "n")
 	}
 
-	if hasNPM {
+	if available.HasNPM {
 		fmt.Print("\n📦 Install NPM global packages? [y/N]: ")
 		response, _ := reader.ReadString('\n')
 		options.InstallNPM = strings.EqualFold(strings.TrimSpace(response), "y") || strings.EqualFold(strings.TrimSpace(response), "yes")
 	}
 
+	if available.HasLock && !restoreExact && hasPackageInstalls(options) {
+		fmt.Print("\n🔒 Install the exact package versions from the backup where possible? [y/N]: ")
+		response, _ := reader.ReadString('\n')
+		options.ExactVersions = strings.EqualFold(strings.TrimSpace(response), "y") || strings.EqualFold(strings.TrimSpace(response), "yes")
+	}
+
 	fmt.Println("\n" + strings.Repeat("=", 50))
 	return options, nil
 }
@@ -0,0 +1,362 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/harshpatel5940/stash/internal/archiver"
+	"github.com/harshpatel5940/stash/internal/diff"
+	"github.com/harshpatel5940/stash/internal/metadata"
+	"github.com/harshpatel5940/stash/internal/tui"
+	"github.com/harshpatel5940/stash/internal/ui"
+)
+
+// restoredSuffix is appended to the backup copy when a conflict is saved aside
+const restoredSuffix = ".stash-restored"
+
+// maxConflictDiffLines caps how much of a diff is printed per conflict
+const maxConflictDiffLines = 40
+
+var conflictPolicies = []string{
+	tui.ConflictAsk,
+	tui.ConflictOverwrite,
+	tui.ConflictKeep,
+	tui.ConflictSaveAs,
+	tui.ConflictMerge,
+}
+
+// restoreConflict is a local file that would be overwritten by a backup copy
+// with different contents
+type restoreConflict struct {
+	Item       restoreItem
+	Source     string
+	Dest       string
+	Text       bool
+	Added      int
+	Removed    int
+	LocalNewer bool
+	Resolution string
+}
+
+// conflictSummary counts how conflicts were resolved
+type conflictSummary struct {
+	Overwritten int
+	Kept        int
+	Saved       int
+	Merged      int
+}
+
+// validateConflictPolicy checks an --on-conflict value
+func validateConflictPolicy(policy string) error {
+	for _, p := range conflictPolicies {
+		if policy == p {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid conflict policy %q (use %s)", policy, strings.Join(conflictPolicies, ", "))
+}
+
+// detectConflicts finds restore destinations that already exist with
+// contents different from the backup. Directories are checked file by file,
+// leaving out what the copy itself leaves out.
+func detectConflicts(items []restoreItem) []*restoreConflict {
+	var conflicts []*restoreConflict
+
+	for _, item := range items {
+		if !item.Info.IsDir {
+			if c := checkConflict(item, item.Source, item.Dest, item.Info.Checksum, item.Info.ModTime); c != nil {
+				conflicts = append(conflicts, c)
+			}
+			continue
+		}
+
+		_ = filepath.Walk(item.Source, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if item.Exclude == nil && path != item.Source && archiver.Excluded(info.Name()) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.IsDir() || !info.Mode().IsRegular() {
+				return nil
+			}
+			rel, err := filepath.Rel(item.Source, path)
+			if err != nil {
+				return nil
+			}
+			dest := filepath.Join(item.Dest, rel)
+			if item.Exclude != nil && item.Exclude(dest) {
+				return nil
+			}
+			if c := checkConflict(item, path, dest, "", info.ModTime()); c != nil {
+				conflicts = append(conflicts, c)
+			}
+			return nil
+		})
+	}
+
+	return conflicts
+}
+
+func checkConflict(item restoreItem, source, dest, backupChecksum string, backupModTime time.Time) *restoreConflict {
+	localInfo, err := os.Stat(dest)
+	if err != nil || !localInfo.Mode().IsRegular() {
+		return nil
+	}
+
+	localChecksum, err := metadata.Checksum(dest)
+	if err != nil {
+		return nil
+	}
+	if backupChecksum == "" {
+		if backupChecksum, err = metadata.Checksum(source); err != nil {
+			return nil
+		}
+	}
+	if localChecksum == backupChecksum {
+		return nil
+	}
+
+	c := &restoreConflict{
+		Item:       item,
+		Source:     source,
+		Dest:       dest,
+		LocalNewer: localInfo.ModTime().After(backupModTime),
+	}
+
+	localData, err := os.ReadFile(dest)
+	if err != nil {
+		return c
+	}
+	backupData, err := os.ReadFile(source)
+	if err != nil {
+		return c
+	}
+
+	c.Text = diff.IsText(localData) && diff.IsText(backupData)
+	if c.Text {
+		c.Added, c.Removed = diff.CountChanges(diff.DiffLines(diff.SplitLines(localData), diff.SplitLines(backupData)))
+	}
+
+	return c
+}
+
+// Describe returns a one-line summary of how the local file differs
+func (c *restoreConflict) Describe() string {
+	var parts []string
+	if c.Text {
+		parts = append(parts, fmt.Sprintf("+%d -%d", c.Added, c.Removed))
+	} else {
+		parts = append(parts, "binary")
+	}
+	if c.LocalNewer {
+		parts = append(parts, "local copy is newer")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Diff renders what overwriting the local file would change
+func (c *restoreConflict) Diff() string {
+	if !c.Text {
+		return ""
+	}
+	localData, err := os.ReadFile(c.Dest)
+	if err != nil {
+		return ""
+	}
+	backupData, err := os.ReadFile(c.Source)
+	if err != nil {
+		return ""
+	}
+	return diff.Unified("local: "+c.Dest, "backup: "+c.Dest, localData, backupData, 3)
+}
+
+// printConflictDiff prints a conflict's diff, truncated to keep the terminal readable
+func printConflictDiff(c *restoreConflict) {
+	out := c.Diff()
+	if out == "" {
+		ui.PrintDim("  (binary file, no diff)")
+		return
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	for i, line := range lines {
+		if i == maxConflictDiffLines {
+			ui.PrintDim("  ... %d more line(s)", len(lines)-maxConflictDiffLines)
+			break
+		}
+		fmt.Printf("  %s\n", line)
+	}
+}
+
+// resolveConflicts assigns a resolution to every conflict that doesn't have one
+// yet, either from the policy or by asking the user
+func resolveConflicts(conflicts []*restoreConflict, policy string, useNoTUI bool) error {
+	var pending []*restoreConflict
+	for _, c := range conflicts {
+		if c.Resolution == "" {
+			pending = append(pending, c)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	if policy == tui.ConflictAsk && !useNoTUI {
+		strategy, err := tui.ConflictStrategyForm(len(pending))
+		if err != nil {
+			return err
+		}
+		policy = strategy
+	}
+
+	if policy != tui.ConflictAsk {
+		for _, c := range pending {
+			c.Resolution = policy
+		}
+		return nil
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for _, c := range pending {
+		fmt.Println()
+		ui.PrintWarning("Conflict: %s (%s)", c.Dest, c.Describe())
+		printConflictDiff(c)
+
+		if useNoTUI {
+			c.Resolution = promptConflictResolution(reader, c.Text)
+			continue
+		}
+
+		resolution, err := tui.ConflictResolutionForm(c.Dest, c.Describe(), c.Text)
+		if err != nil {
+			return err
+		}
+		c.Resolution = resolution
+	}
+
+	return nil
+}
+
+// promptConflictResolution asks for a resolution on stdin, defaulting to overwrite
+func promptConflictResolution(reader *bufio.Reader, canMerge bool) string {
+	choices := "[O]verwrite, [k]eep, [s]ave as " + restoredSuffix
+	if canMerge {
+		choices += ", [m]erge"
+	}
+	fmt.Printf("%s? ", choices)
+
+	response, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return tui.ConflictOverwrite
+	}
+
+	switch strings.ToLower(strings.TrimSpace(response)) {
+	case "k", "keep":
+		return tui.ConflictKeep
+	case "s", "save", "save-as":
+		return tui.ConflictSaveAs
+	case "m", "merge":
+		if canMerge {
+			return tui.ConflictMerge
+		}
+	}
+	return tui.ConflictOverwrite
+}
+
+// conflictsByDest indexes conflicts by destination path
+func conflictsByDest(conflicts []*restoreConflict) map[string]*restoreConflict {
+	byDest := make(map[string]*restoreConflict, len(conflicts))
+	for _, c := range conflicts {
+		byDest[c.Dest] = c
+	}
+	return byDest
+}
+
+// applyConflictResolution writes a conflicting file according to its resolution
+func applyConflictResolution(arch *archiver.Archiver, c *restoreConflict, summary *conflictSummary) error {
+	resolution := c.Resolution
+	if resolution == tui.ConflictMerge && !c.Text {
+		ui.PrintVerbose("Cannot merge binary file %s, saving backup copy instead", c.Dest)
+		resolution = tui.ConflictSaveAs
+	}
+
+	switch resolution {
+	case tui.ConflictKeep:
+		summary.Kept++
+		ui.PrintVerbose("Kept local: %s", c.Dest)
+		return nil
+
+	case tui.ConflictSaveAs:
+		if err := arch.CopyFile(c.Source, c.Dest+restoredSuffix); err != nil {
+			return err
+		}
+		summary.Saved++
+		ui.PrintVerbose("Saved backup copy: %s%s", c.Dest, restoredSuffix)
+		return nil
+
+	case tui.ConflictMerge:
+		localData, err := os.ReadFile(c.Dest)
+		if err != nil {
+			return err
+		}
+		backupData, err := os.ReadFile(c.Source)
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(c.Dest)
+		if err != nil {
+			return err
+		}
+		merged := diff.Merge(localData, backupData, "local", "backup")
+		if err := os.WriteFile(c.Dest, merged, info.Mode().Perm()); err != nil {
+			return err
+		}
+		summary.Merged++
+		ui.PrintVerbose("Merged: %s", c.Dest)
+		return nil
+	}
+
+	if err := arch.CopyFile(c.Source, c.Dest); err != nil {
+		return err
+	}
+	if !c.Item.Info.IsDir {
+		_ = os.Chmod(c.Dest, c.Item.Info.Mode)
+	}
+	summary.Overwritten++
+	return nil
+}
+
+// conflictWritePaths lists every file conflict resolution will write: the
+// local file when it is overwritten or merged, the backup copy for save-as
+func conflictWritePaths(conflicts []*restoreConflict) []string {
+	paths := savedAsPaths(conflicts)
+	for _, c := range conflicts {
+		switch {
+		case c.Resolution == tui.ConflictKeep, c.Resolution == tui.ConflictSaveAs:
+		case c.Resolution == tui.ConflictMerge && !c.Text:
+		default:
+			paths = append(paths, c.Dest)
+		}
+	}
+	return paths
+}
+
+// savedAsPaths lists the extra files a restore will create for save-as conflicts
+func savedAsPaths(conflicts []*restoreConflict) []string {
+	var paths []string
+	for _, c := range conflicts {
+		if c.Resolution == tui.ConflictSaveAs || (c.Resolution == tui.ConflictMerge && !c.Text) {
+			paths = append(paths, c.Dest+restoredSuffix)
+		}
+	}
+	return paths
+}
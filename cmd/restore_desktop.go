@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/harshpatel5940/stash/internal/archiver"
+	"github.com/harshpatel5940/stash/internal/desktop"
+	"github.com/harshpatel5940/stash/internal/metadata"
+	"github.com/harshpatel5940/stash/internal/platform"
+	"github.com/harshpatel5940/stash/internal/tui"
+	"github.com/harshpatel5940/stash/internal/ui"
+)
+
+// printDesktopSettingsPlan lists the desktop settings a restore would apply
+func printDesktopSettingsPlan(settings *desktop.Settings) {
+	if settings == nil {
+		return
+	}
+	ui.PrintInfo("DRY RUN: Would restore %d desktop settings section(s)", len(settings.Sections))
+	if restoreVerbose {
+		for _, section := range settings.Sections {
+			fmt.Printf("  %s\n", section.Label())
+		}
+	}
+}
+
+// pickDesktopSections returns the sections to restore: those whose IDs were
+// chosen in the editor, the ones picked now when pick is set and none were
+// chosen, or every section
+func pickDesktopSections(settings *desktop.Settings, ids []string, pick bool) ([]desktop.Section, error) {
+	if ids == nil && pick {
+		var items []tui.DesktopSectionItem
+		for _, section := range settings.Sections {
+			items = append(items, tui.DesktopSectionItem{ID: section.ID(), Label: section.Label()})
+		}
+		selected, err := tui.DesktopSectionPickerForm(items)
+		if err != nil {
+			return nil, err
+		}
+		if len(selected) == 0 {
+			ui.PrintInfo("No desktop settings selected")
+			return nil, nil
+		}
+		ids = selected
+	}
+	if ids == nil {
+		return settings.Sections, nil
+	}
+
+	wanted := make(map[string]bool)
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	var sections []desktop.Section
+	for _, section := range settings.Sections {
+		if wanted[section.ID()] {
+			sections = append(sections, section)
+		}
+	}
+	return sections, nil
+}
+
+// planDesktopSettings stages the KDE rc files the sections would produce in
+// stagingDir and returns them as restore items, so they share the conflict
+// checks and rollback snapshot with files
+func planDesktopSettings(sections []desktop.Section, stagingDir string, resolver destResolver) []restoreItem {
+	configDir := resolver.Rooted(platform.Current().ConfigHome())
+
+	var items []restoreItem
+	for _, section := range sections {
+		if section.Source != desktop.SourceKDE {
+			continue
+		}
+		dest, ok := desktop.KDEFile(configDir, section)
+		if !ok {
+			continue
+		}
+		merged, err := desktop.MergeKDE(dest, section)
+		if err != nil {
+			ui.PrintVerbose("Skipping %s: %v", section.ID(), err)
+			continue
+		}
+		source, _ := desktop.KDEFile(stagingDir, section)
+		if err := os.MkdirAll(filepath.Dir(source), 0755); err != nil {
+			continue
+		}
+		if err := os.WriteFile(source, merged, 0644); err != nil {
+			continue
+		}
+		info, err := os.Stat(source)
+		if err != nil {
+			continue
+		}
+		items = append(items, restoreItem{
+			Info: metadata.FileInfo{
+				OriginalPath: dest,
+				Mode:         info.Mode(),
+				ModTime:      info.ModTime(),
+			},
+			Source: source,
+			Dest:   dest,
+		})
+	}
+	return items
+}
+
+// restoreDesktopSettings writes the planned KDE rc files, leaving
+// conflicting ones, which skip reports, to conflict resolution, and applies
+// the GNOME sections. dconf and gsettings can't be redirected, so they are
+// left alone when restoring into another root. It returns warnings for the
+// restore summary.
+func restoreDesktopSettings(settingsFile string, sections []desktop.Section, kdeItems []restoreItem, resolver destResolver, skip func(string) bool) []string {
+	ui.PrintVerbose("Restoring desktop settings...")
+	var warnings []string
+
+	arch := archiver.NewArchiver()
+	for _, item := range kdeItems {
+		if skip(item.Dest) {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(item.Dest), 0755); err != nil {
+			warnings = append(warnings, fmt.Sprintf("Desktop settings %s: %v", item.Dest, err))
+			continue
+		}
+		if err := arch.CopyFile(item.Source, item.Dest); err != nil {
+			warnings = append(warnings, fmt.Sprintf("Desktop settings %s: %v", item.Dest, err))
+			continue
+		}
+		ui.PrintVerbose("Restored: %s", item.Dest)
+	}
+
+	var gnome []string
+	for _, section := range sections {
+		if section.Source != desktop.SourceKDE {
+			gnome = append(gnome, section.ID())
+		}
+	}
+	if len(gnome) == 0 {
+		return warnings
+	}
+	if resolver.targetRoot != "" {
+		ui.PrintInfo("Skipping %d dconf/gsettings section(s), they can't be restored into %s", len(gnome), resolver.targetRoot)
+		return warnings
+	}
+
+	dm := desktop.NewDesktopManager("")
+	if err := dm.RestoreAll(settingsFile, gnome); err != nil {
+		ui.PrintVerbose("Desktop settings failed: %v", err)
+		warnings = append(warnings, fmt.Sprintf("Desktop settings: %v", err))
+	}
+	return warnings
+}
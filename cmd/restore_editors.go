@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/harshpatel5940/stash/internal/editors"
+	"github.com/harshpatel5940/stash/internal/metadata"
+	"github.com/harshpatel5940/stash/internal/tui"
+	"github.com/harshpatel5940/stash/internal/ui"
+)
+
+// availableEditors returns the editors whose settings are in the backup
+func availableEditors(editorsDir string) []tui.EditorOption {
+	records, err := editors.Load(filepath.Join(editorsDir, editors.ManifestFile))
+	if err != nil {
+		return nil
+	}
+	var options []tui.EditorOption
+	for _, record := range records {
+		if _, ok := editors.Lookup(record.Name); ok {
+			options = append(options, tui.EditorOption{Name: record.Name, Label: record.Label, Count: len(record.Paths)})
+		}
+	}
+	return options
+}
+
+// editorOptionNames returns the names of every option
+func editorOptionNames(options []tui.EditorOption) []string {
+	var names []string
+	for _, option := range options {
+		names = append(names, option.Name)
+	}
+	return names
+}
+
+// printEditorSettingsPlan lists where each picked editor's settings would go
+func printEditorSettingsPlan(editorsDir string, names []string, resolver destResolver) {
+	records, _ := editors.Load(filepath.Join(editorsDir, editors.ManifestFile))
+	for _, record := range pickedEditors(records, names) {
+		editor, _ := editors.Lookup(record.Name)
+		ui.PrintInfo("DRY RUN: Would restore %s settings to %s", record.Label, resolver.Rooted(editor.Dir))
+		if restoreVerbose {
+			for _, path := range record.Paths {
+				fmt.Printf("  %s\n", path)
+			}
+		}
+	}
+}
+
+// planEditorSettings returns the picked editors' saved settings as restore
+// items, along with the global storage files their profiles are merged into
+func planEditorSettings(editorsDir string, names []string, resolver destResolver) ([]restoreItem, []string) {
+	records, err := editors.Load(filepath.Join(editorsDir, editors.ManifestFile))
+	if err != nil {
+		return nil, nil
+	}
+
+	var items []restoreItem
+	var storage []string
+	for _, record := range pickedEditors(records, names) {
+		editor, ok := editors.Lookup(record.Name)
+		if !ok {
+			continue
+		}
+		destDir := resolver.Rooted(editor.Dir)
+		for _, target := range editors.Targets(editorsDir, record, destDir) {
+			info, err := os.Stat(target.Source)
+			if err != nil {
+				continue
+			}
+			item := restoreItem{
+				Info: metadata.FileInfo{
+					OriginalPath: target.Dest,
+					Mode:         info.Mode(),
+					ModTime:      info.ModTime(),
+					IsDir:        info.IsDir(),
+				},
+				Source: target.Source,
+				Dest:   target.Dest,
+			}
+			if item.Info.IsDir {
+				root := target.Dest
+				item.Exclude = func(destPath string) bool {
+					rel, _ := filepath.Rel(root, destPath)
+					return editors.Excluded(rel)
+				}
+			}
+			items = append(items, item)
+		}
+		if len(record.Profiles) > 0 {
+			storage = append(storage, editors.StoragePath(destDir))
+		}
+	}
+	return items, storage
+}
+
+// restoreEditorSettings copies the picked editors' settings into place,
+// leaving conflicting files, which skip reports, to conflict resolution. It
+// returns warnings for the restore summary.
+func restoreEditorSettings(editorsDir string, names []string, resolver destResolver, skip func(string) bool) []string {
+	records, err := editors.Load(filepath.Join(editorsDir, editors.ManifestFile))
+	if err != nil {
+		return []string{fmt.Sprintf("Editor settings: %v", err)}
+	}
+
+	var warnings []string
+	for _, record := range pickedEditors(records, names) {
+		editor, ok := editors.Lookup(record.Name)
+		if !ok {
+			continue
+		}
+		ui.PrintVerbose("Restoring %s settings...", record.Label)
+		count, err := editors.Restore(editorsDir, record, resolver.Rooted(editor.Dir), skip)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s settings: %v", record.Label, err))
+			continue
+		}
+		ui.PrintSuccess("Restored %s settings (%d items)", record.Label, count)
+	}
+	return warnings
+}
+
+// pickedEditors returns the records for names, in manifest order
+func pickedEditors(records []editors.Record, names []string) []editors.Record {
+	picked := make(map[string]bool)
+	for _, name := range names {
+		picked[name] = true
+	}
+	var result []editors.Record
+	for _, record := range records {
+		if picked[record.Name] {
+			result = append(result, record)
+		}
+	}
+	return result
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/harshpatel5940/stash/internal/archiver"
+	"github.com/harshpatel5940/stash/internal/metadata"
+)
+
+// categoryPrefix marks an --only/--exclude value that selects a backup category
+const categoryPrefix = "category:"
+
+// restoreFilter selects backed-up files by path glob or category
+type restoreFilter struct {
+	only    []string
+	exclude []string
+}
+
+// newRestoreFilter validates --only and --exclude values. Path patterns may
+// start with ~ and match a file or any directory above it.
+func newRestoreFilter(only, exclude []string, homeDir string) (*restoreFilter, error) {
+	f := &restoreFilter{}
+
+	var err error
+	if f.only, err = normalizeFilterPatterns(only, homeDir); err != nil {
+		return nil, err
+	}
+	if f.exclude, err = normalizeFilterPatterns(exclude, homeDir); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func normalizeFilterPatterns(patterns []string, homeDir string) ([]string, error) {
+	var normalized []string
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+
+		if strings.HasPrefix(pattern, categoryPrefix) {
+			category := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(pattern, categoryPrefix)))
+			if category == "" {
+				return nil, fmt.Errorf("empty category in filter %q", pattern)
+			}
+			normalized = append(normalized, categoryPrefix+category)
+			continue
+		}
+
+		pattern = filepath.Clean(metadata.ExpandHome(pattern, homeDir))
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		normalized = append(normalized, pattern)
+	}
+	return normalized, nil
+}
+
+// Active reports whether any filter was given
+func (f *restoreFilter) Active() bool {
+	return len(f.only) > 0 || len(f.exclude) > 0
+}
+
+// Includes reports whether a file with the given category and local path passes the filter
+func (f *restoreFilter) Includes(category, path string) bool {
+	if len(f.only) > 0 && !matchesFilter(f.only, category, path) {
+		return false
+	}
+	return !matchesFilter(f.exclude, category, path)
+}
+
+func matchesFilter(patterns []string, category, path string) bool {
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, categoryPrefix) {
+			if strings.TrimPrefix(pattern, categoryPrefix) == strings.ToLower(category) {
+				return true
+			}
+			continue
+		}
+
+		// A pattern matching a directory selects everything beneath it
+		for p := path; ; {
+			if ok, _ := filepath.Match(pattern, p); ok {
+				return true
+			}
+			parent := filepath.Dir(p)
+			if parent == p {
+				break
+			}
+			p = parent
+		}
+	}
+	return false
+}
+
+// Apply filters files from a backup extracted at extractDir, matching patterns
+// against where each file lands on this machine. Directories stored as a
+// single entry are kept whole when every file in them passes; otherwise they
+// are split into entries for the files that do.
+func (f *restoreFilter) Apply(files []metadata.FileInfo, extractDir string, resolver destResolver) []metadata.FileInfo {
+	if !f.Active() {
+		return files
+	}
+
+	var selected []metadata.FileInfo
+	for _, fileInfo := range files {
+		category := backupCategory(fileInfo.BackupPath)
+
+		if !fileInfo.IsDir {
+			if f.Includes(category, resolver.LocalizeFile(fileInfo)) {
+				selected = append(selected, fileInfo)
+			}
+			continue
+		}
+
+		var members []metadata.FileInfo
+		complete := true
+		source := filepath.Join(extractDir, filepath.Clean(fileInfo.BackupPath))
+
+		_ = filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			// The directory copy never restores these, so neither do members
+			if path != source && archiver.Excluded(info.Name()) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.IsDir() || !info.Mode().IsRegular() {
+				return nil
+			}
+			rel, err := filepath.Rel(source, path)
+			if err != nil {
+				return nil
+			}
+
+			originalPath := filepath.Join(fileInfo.OriginalPath, rel)
+			if !f.Includes(category, resolver.Localize(originalPath)) {
+				complete = false
+				return nil
+			}
+
+			members = append(members, metadata.FileInfo{
+				OriginalPath: originalPath,
+				BackupPath:   filepath.Join(fileInfo.BackupPath, rel),
+				Size:         info.Size(),
+				Mode:         info.Mode(),
+				ModTime:      info.ModTime(),
+			})
+			return nil
+		})
+
+		switch {
+		case complete && len(members) > 0:
+			selected = append(selected, fileInfo)
+		case complete:
+			if f.Includes(category, resolver.Localize(fileInfo.OriginalPath)) {
+				selected = append(selected, fileInfo)
+			}
+		default:
+			selected = append(selected, members...)
+		}
+	}
+
+	return selected
+}
+
+// backupCategory returns the top-level archive directory a file was stored in
+func backupCategory(backupPath string) string {
+	clean := filepath.ToSlash(filepath.Clean(backupPath))
+	if idx := strings.Index(clean, "/"); idx != -1 {
+		return clean[:idx]
+	}
+	return clean
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/harshpatel5940/stash/internal/gittracker"
+	"github.com/harshpatel5940/stash/internal/ui"
+)
+
+// hasGitRepos reports whether the backup lists repos that can be cloned or
+// have saved unpushed or uncommitted work
+func hasGitRepos(reposFile string) bool {
+	repos, err := gittracker.LoadRepos(reposFile)
+	if err != nil {
+		return false
+	}
+	for _, repo := range repos {
+		if repo.RemoteURL != "" || repo.HasWork() {
+			return true
+		}
+	}
+	return false
+}
+
+// printGitReposPlan lists what a restore would do with each repo
+func printGitReposPlan(reposFile string, resolver destResolver) {
+	repos, err := gittracker.LoadRepos(reposFile)
+	if err != nil {
+		return
+	}
+
+	var clone, existing int
+	for _, repo := range repos {
+		if fileExists(filepath.Join(resolver.Resolve(repo.Path), ".git")) {
+			existing++
+		} else if repo.RemoteURL != "" || repo.Bundle != "" {
+			clone++
+		}
+	}
+	ui.PrintInfo("DRY RUN: Would clone %d git repo(s), %d already present", clone, existing)
+
+	if restoreVerbose {
+		for _, repo := range repos {
+			fmt.Printf("  %s (%s)\n", resolver.Resolve(repo.Path), repo.Branch)
+		}
+	}
+}
+
+// restoreGitRepos clones the backup's repos into their (remapped) original
+// paths, then reapplies saved bundles and patches. Repos that already exist
+// are left in place apart from their saved work. It returns warnings for the
+// restore summary and the repos now present, at their local paths.
+func restoreGitRepos(reposFile string, resolver destResolver, workers int) ([]string, []gittracker.GitRepo) {
+	repos, err := gittracker.LoadRepos(reposFile)
+	if err != nil {
+		return []string{fmt.Sprintf("Git repos: %v", err)}, nil
+	}
+
+	ui.PrintInfo("Restoring %d git repo(s), %d at a time...", len(repos), workers)
+
+	done := 0
+	results := gittracker.CloneRepos(repos, filepath.Dir(reposFile), resolver.Resolve, workers, func(result gittracker.CloneResult) {
+		done++
+		ui.PrintVerbose("[%d/%d] %s: %s", done, len(repos), result.Status, result.Dest)
+	})
+
+	var cloned, existing int
+	var warnings []string
+	var present []gittracker.GitRepo
+	for _, result := range results {
+		switch result.Status {
+		case gittracker.CloneCloned:
+			cloned++
+		case gittracker.CloneExists:
+			existing++
+		case gittracker.CloneFailed:
+			warnings = append(warnings, fmt.Sprintf("Git clone %s: %v", result.Dest, result.Err))
+			continue
+		case gittracker.CloneSkipped:
+			ui.PrintVerbose("Skipped %s: %v", result.Dest, result.Err)
+			continue
+		}
+		present = append(present, gittracker.GitRepo{Path: resolver.Localize(result.Repo.Path), RemoteURL: result.Repo.RemoteURL})
+		if result.Err != nil {
+			warnings = append(warnings, fmt.Sprintf("Git work for %s: %v", result.Dest, result.Err))
+		}
+	}
+
+	ui.PrintSuccess("Cloned %d git repo(s), %d already present", cloned, existing)
+	return warnings, present
+}
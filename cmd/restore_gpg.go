@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/harshpatel5940/stash/internal/gpg"
+	"github.com/harshpatel5940/stash/internal/ui"
+)
+
+// hasGPGKeys reports whether the backup has keys exported with gpg
+func hasGPGKeys(keysDir string) bool {
+	return fileExists(filepath.Join(keysDir, gpg.PublicKeysFile))
+}
+
+// printGPGKeysPlan says where the exported GPG keys would be imported
+func printGPGKeysPlan(keysDir string, resolver destResolver) {
+	ui.PrintInfo("DRY RUN: Would import GPG keys and trust into %s", resolver.Rooted(gpg.HomeDir()))
+	if restoreVerbose {
+		entries, _ := os.ReadDir(keysDir)
+		for _, entry := range entries {
+			fmt.Printf("  %s\n", entry.Name())
+		}
+	}
+	if !gpg.Available() {
+		ui.PrintWarning("gpg is not installed; the keys would need importing by hand")
+	}
+}
+
+// importGPGKeys imports the exported keys and owner trust into the GPG
+// home and restores config files it doesn't have yet, returning warnings
+// for the restore summary
+func importGPGKeys(keysDir string, resolver destResolver) []string {
+	homeDir := resolver.Rooted(gpg.HomeDir())
+	gm := gpg.NewGPGManager(homeDir)
+
+	var warnings []string
+	skipped, err := gm.RestoreConfig(keysDir)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("GPG config: %v", err))
+	}
+	if len(skipped) > 0 {
+		ui.PrintInfo("Kept existing GPG config: %s", strings.Join(skipped, ", "))
+	}
+
+	if !gpg.Available() {
+		return append(warnings, "GPG keys: gpg is not installed; install GnuPG and restore again to import them")
+	}
+
+	ui.PrintVerbose("Importing GPG keys into %s...", homeDir)
+	count, err := gm.Import(keysDir)
+	if err != nil {
+		return append(warnings, fmt.Sprintf("GPG keys: %v", err))
+	}
+	ui.PrintSuccess("Imported GPG keys (%d in keyring)", count)
+	return warnings
+}
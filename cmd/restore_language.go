@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/harshpatel5940/stash/internal/packager"
+	"github.com/harshpatel5940/stash/internal/tui"
+	"github.com/harshpatel5940/stash/internal/ui"
+)
+
+// availableLanguageManagers returns the language package lists in the
+// backup that have something to install
+func availableLanguageManagers(packagesDir string) []tui.LanguageOption {
+	var options []tui.LanguageOption
+	for _, m := range packager.LanguageManagers {
+		packages, err := packager.LanguagePackages(m.Name, filepath.Join(packagesDir, m.File))
+		if err != nil || len(packages) == 0 {
+			continue
+		}
+		options = append(options, tui.LanguageOption{Name: m.Name, Label: m.Label, Count: len(packages), Default: m.Default})
+	}
+	return options
+}
+
+// languageOptionNames returns the names of every option
+func languageOptionNames(options []tui.LanguageOption) []string {
+	var names []string
+	for _, option := range options {
+		names = append(names, option.Name)
+	}
+	return names
+}
+
+// printLanguagePackagesPlan lists what installing the picked managers would
+// do, pinned to lock when it isn't nil
+func printLanguagePackagesPlan(packagesDir string, managers []string, lock *packager.Lock) {
+	for _, name := range managers {
+		m, ok := packager.LookupLanguageManager(name)
+		if !ok {
+			continue
+		}
+		packages, err := packager.LanguageTargets(m.Name, filepath.Join(packagesDir, m.File), lock)
+		if err != nil {
+			continue
+		}
+		ui.PrintInfo("DRY RUN: Would install %d %s", len(packages), m.Label)
+		if restoreVerbose {
+			fmt.Printf("  %s\n", strings.Join(packages, " "))
+		}
+	}
+}
+
+// planLanguagePackages adds each picked manager's list to the install plan,
+// pinned to lock, returning warnings for lists that can't be read
+func planLanguagePackages(plan *packager.InstallPlan, packagesDir string, managers []string, lock *packager.Lock) []string {
+	var warnings []string
+	for _, name := range managers {
+		m, ok := packager.LookupLanguageManager(name)
+		if !ok || !fileExists(filepath.Join(packagesDir, m.File)) {
+			continue
+		}
+		if err := plan.AddLanguageList(m.Name, filepath.Join(packagesDir, m.File), lock); err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: %v", m.Label, err))
+		}
+	}
+	return warnings
+}
+
+// loadPackageLock reads the backup's version lock, returning an empty lock
+// (which still pins Go binaries) when it can't be read
+func loadPackageLock(packagesDir string) *packager.Lock {
+	lock, err := packager.LoadLock(filepath.Join(packagesDir, packager.LockFile))
+	if err != nil {
+		ui.PrintWarning("Failed to read %s: %v", packager.LockFile, err)
+		return &packager.Lock{}
+	}
+	return lock
+}
+
+// hasPackageInstalls reports whether options install anything a lock can pin
+func hasPackageInstalls(options RestoreOptions) bool {
+	return options.InstallGo || len(options.LanguagePackages) > 0
+}
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/harshpatel5940/stash/internal/gittracker"
+	"github.com/harshpatel5940/stash/internal/metadata"
+	"github.com/harshpatel5940/stash/internal/platform"
+)
+
+// pathMapping rewrites paths under From to the same relative path under To
+type pathMapping struct {
+	From string
+	To   string
+}
+
+// destResolver maps the original path of a backed-up file to where it is
+// written on this machine
+type destResolver struct {
+	homeDir    string
+	sourceHome string
+	mappings   []pathMapping
+	repos      map[string]string // normalized remote URL -> local clone
+	targetRoot string
+}
+
+// newDestResolver creates a resolver with optional old=new prefix rules,
+// optionally rooted at an alternate directory
+func newDestResolver(target string, maps []string) (destResolver, error) {
+	homeDir, _ := os.UserHomeDir()
+	resolver := destResolver{homeDir: homeDir}
+
+	for _, rule := range maps {
+		mapping, err := parsePathMapping(rule, homeDir)
+		if err != nil {
+			return resolver, err
+		}
+		resolver.mappings = append(resolver.mappings, mapping)
+	}
+
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return resolver, nil
+	}
+
+	target = metadata.ExpandHome(target, homeDir)
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return resolver, fmt.Errorf("invalid target directory %s: %w", target, err)
+	}
+	if info, err := os.Stat(absTarget); err == nil && !info.IsDir() {
+		return resolver, fmt.Errorf("target is not a directory: %s", absTarget)
+	}
+
+	resolver.targetRoot = absTarget
+	return resolver, nil
+}
+
+// parsePathMapping parses an old=new rule. ~ on the new side is this machine's home.
+func parsePathMapping(rule, homeDir string) (pathMapping, error) {
+	from, to, ok := strings.Cut(rule, "=")
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	if !ok || from == "" || to == "" {
+		return pathMapping{}, fmt.Errorf("invalid path mapping %q (expected old=new)", rule)
+	}
+
+	return pathMapping{
+		From: filepath.Clean(from),
+		To:   filepath.Clean(metadata.ExpandHome(to, homeDir)),
+	}, nil
+}
+
+// SetSourceHome records the home directory of the machine the backup came
+// from so paths under it are moved to the current home
+func (r *destResolver) SetSourceHome(sourceHome string) {
+	if sourceHome != "" {
+		r.sourceHome = filepath.Clean(sourceHome)
+	}
+}
+
+// SetPlatforms maps per-user directories that live elsewhere on this
+// platform than on the one the backup was made on
+func (r *destResolver) SetPlatforms(source, current platform.Platform) {
+	if source.OS == current.OS {
+		return
+	}
+	r.mappings = append(r.mappings, pathMapping{
+		From: filepath.Clean(source.FontsDir()),
+		To:   filepath.Clean(current.FontsDir()),
+	})
+}
+
+// SetRepos records the git repositories cloned on this machine so files
+// backed up from a repository follow it to its new location
+func (r *destResolver) SetRepos(repos []gittracker.GitRepo) {
+	r.repos = gittracker.IndexByRemote(repos)
+}
+
+// AddRepos records repositories cloned by the restore. Clones found on this
+// machine beforehand keep precedence.
+func (r *destResolver) AddRepos(repos []gittracker.GitRepo) {
+	if r.repos == nil {
+		r.repos = make(map[string]string)
+	}
+	for remote, path := range gittracker.IndexByRemote(repos) {
+		if _, ok := r.repos[remote]; !ok {
+			r.repos[remote] = path
+		}
+	}
+}
+
+// RepoClone returns the local clone of the repository a file was backed up from
+func (r destResolver) RepoClone(info metadata.FileInfo) (string, bool) {
+	if info.GitRemote == "" || info.GitRelPath == "" {
+		return "", false
+	}
+	root, ok := r.repos[gittracker.NormalizeRemoteURL(info.GitRemote)]
+	return root, ok
+}
+
+// LocalizeFile is Localize for a backed-up file, preferring the current clone
+// of the repository it was found in
+func (r destResolver) LocalizeFile(info metadata.FileInfo) string {
+	if root, ok := r.RepoClone(info); ok {
+		return filepath.Join(root, filepath.FromSlash(info.GitRelPath))
+	}
+	return r.Localize(info.OriginalPath)
+}
+
+// ResolveFile returns the destination for a backed-up file
+func (r destResolver) ResolveFile(info metadata.FileInfo) string {
+	return r.Rooted(r.LocalizeFile(info))
+}
+
+// Localize returns where an original path belongs on this machine, before
+// any target root is applied. Mapping rules are matched against the path as
+// it was on the source machine; anything else under the source home moves to
+// the current home.
+func (r destResolver) Localize(originalPath string) string {
+	sourceHome := r.sourceHome
+	if sourceHome == "" {
+		sourceHome = r.homeDir
+	}
+	path := filepath.Clean(metadata.ExpandHome(originalPath, sourceHome))
+
+	// The longest matching prefix wins when rules overlap
+	matched, mappedPath := "", ""
+	for _, mapping := range r.mappings {
+		candidates := []string{mapping.From}
+		if strings.HasPrefix(mapping.From, "~") {
+			// A ~ rule matches the source home, or the current one for older backups
+			candidates = []string{
+				filepath.Clean(metadata.ExpandHome(mapping.From, sourceHome)),
+				filepath.Clean(metadata.ExpandHome(mapping.From, r.homeDir)),
+			}
+		}
+		for _, from := range candidates {
+			if rest, ok := cutPathPrefix(path, from); ok && len(from) > len(matched) {
+				matched, mappedPath = from, filepath.Join(mapping.To, rest)
+			}
+		}
+	}
+	if matched != "" {
+		return mappedPath
+	}
+
+	if sourceHome != r.homeDir {
+		if rest, ok := cutPathPrefix(path, sourceHome); ok {
+			return filepath.Join(r.homeDir, rest)
+		}
+	}
+
+	return path
+}
+
+// Resolve returns the destination for an original path. With a target root,
+// every path is placed underneath it as if the root were /.
+func (r destResolver) Resolve(originalPath string) string {
+	return r.Rooted(r.Localize(originalPath))
+}
+
+// Rooted places an already-local path under the target root, if any
+func (r destResolver) Rooted(path string) string {
+	if r.targetRoot == "" {
+		return path
+	}
+
+	if volume := filepath.VolumeName(path); volume != "" {
+		path = strings.TrimPrefix(path, volume)
+	}
+	return filepath.Join(r.targetRoot, path)
+}
+
+// cutPathPrefix reports whether path is prefix or lies beneath it, returning the remainder
+func cutPathPrefix(path, prefix string) (string, bool) {
+	if path == prefix {
+		return "", true
+	}
+	if rest, ok := strings.CutPrefix(path, prefix+string(filepath.Separator)); ok {
+		return rest, true
+	}
+	return "", false
+}
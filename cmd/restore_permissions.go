@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/harshpatel5940/stash/internal/config"
+	"github.com/harshpatel5940/stash/internal/ui"
+)
+
+// applyPermissionPolicy removes permissions the policy doesn't allow from
+// restored files and the directories created for them, since backups keep
+// file modes but parent directories are created 0755. It returns warnings
+// for the restore summary.
+func applyPermissionPolicy(cfg *config.Config, items []restoreItem, conflicts []*restoreConflict, resolver destResolver) []string {
+	homeDir, _ := os.UserHomeDir()
+	policy, err := permissionPolicy(cfg, resolver.Rooted(homeDir))
+	if err != nil {
+		return []string{fmt.Sprintf("Permissions: %v", err)}
+	}
+
+	paths := savedAsPaths(conflicts)
+	for _, item := range items {
+		paths = append(paths, item.Dest)
+	}
+
+	var warnings []string
+	fixed := 0
+	for _, path := range paths {
+		if _, err := os.Lstat(path); err != nil {
+			continue
+		}
+		n, err := policy.Apply(path)
+		fixed += n
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("Permissions %s: %v", path, err))
+		}
+	}
+	if fixed > 0 {
+		ui.PrintVerbose("Tightened permissions on %d restored path(s)", fixed)
+	}
+	return warnings
+}
@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/harshpatel5940/stash/internal/config"
+	"github.com/harshpatel5940/stash/internal/packager"
+	"github.com/harshpatel5940/stash/internal/tui"
+	"github.com/harshpatel5940/stash/internal/ui"
+)
+
+// installRetryBackoff is the wait before the first retry of a failed install
+const installRetryBackoff = 2 * time.Second
+
+// planLabels names the install plan's managers in messages
+var planLabels = map[string]string{
+	packager.ManagerBrew:   "Homebrew packages",
+	packager.ManagerMAS:    "Mac App Store apps",
+	packager.ManagerVSCode: "VS Code extensions",
+	packager.ManagerGo:     "Go binaries",
+	packager.ManagerNPM:    "NPM global packages",
+}
+
+// planLabel names a plan manager, including language package managers
+func planLabel(manager string) string {
+	if m, ok := packager.LookupLanguageManager(manager); ok {
+		return m.Label
+	}
+	if label, ok := planLabels[manager]; ok {
+		return label
+	}
+	return manager
+}
+
+// newPackageInstaller returns an installer using the configured parallelism
+// and retries
+func newPackageInstaller(cfg *config.Config) *packager.Installer {
+	installer := packager.NewInstaller(false)
+	installer.SetWorkers(cfg.GetInstallWorkers())
+	installer.SetRetries(cfg.GetInstallRetries(), installRetryBackoff)
+	return installer
+}
+
+// brewGroups heads the picker pages for Brewfile entries that aren't
+// formulae, casks or taps
+var brewGroups = map[string]string{
+	"mas":       "App Store",
+	"whalebrew": "Whalebrew",
+	"vscode":    "VS Code extensions",
+	"go":        "Go packages",
+	"cargo":     "Cargo crates",
+	"flatpak":   "Flatpak apps",
+}
+
+// pickBrewfile returns the Brewfile cut down to the entries to install,
+// letting the user choose tap by tap in the TUI unless useTUI is false
+func pickBrewfile(brewfilePath string, useTUI bool) *packager.Brewfile {
+	brewfile, err := packager.LoadBrewfile(brewfilePath)
+	if err != nil {
+		ui.PrintWarning("Failed to parse Brewfile: %v", err)
+		return nil
+	}
+	if !useTUI || len(brewfile.Items) == 0 {
+		return brewfile
+	}
+
+	var tuiItems []tui.BrewPackageItem
+	for _, item := range brewfile.Items {
+		group := item.Tap()
+		if group == "" {
+			group = brewGroups[item.Type]
+		}
+		tuiItems = append(tuiItems, tui.BrewPackageItem{
+			Type:  item.Type,
+			Name:  item.Name,
+			Label: packager.FormatBrewfileItem(item),
+			Group: group,
+		})
+	}
+
+	selectedItems, err := tui.BrewPackagePickerForm(tuiItems)
+	if err != nil {
+		ui.PrintWarning("Package selection failed: %v", err)
+		return nil
+	}
+	if len(selectedItems) == 0 {
+		ui.PrintInfo("No packages selected, skipping Homebrew installation")
+		return nil
+	}
+
+	selected := make(map[string]bool)
+	for _, tuiItem := range selectedItems {
+		selected[tuiItem.Type+" "+tuiItem.Name] = true
+	}
+	return brewfile.Filter(func(item packager.BrewfileItem) bool {
+		return selected[item.Type+" "+item.Name]
+	})
+}
+
+// startBrewServices starts the Homebrew services that were running at backup
+// time, returning warnings for the restore summary
+func startBrewServices(installer *packager.Installer, servicesFile string) []string {
+	ui.PrintVerbose("Starting Homebrew services...")
+	count, err := installer.StartBrewServices(servicesFile)
+	if count > 0 {
+		ui.PrintSuccess("Started %d Homebrew services", count)
+	}
+
+	var installErr *packager.PackageInstallError
+	switch {
+	case errors.As(err, &installErr):
+		return []string{fmt.Sprintf("Homebrew services failed to start: %s", strings.Join(installErr.FailedPackages, ", "))}
+	case err != nil:
+		return []string{fmt.Sprintf("Homebrew services: %v", err)}
+	}
+	return nil
+}
+
+// printSuggestedCasksPlan lists the casks a dry run would install for apps
+// that were installed outside Homebrew
+func printSuggestedCasksPlan(suggestedFile string) {
+	brewfile, err := packager.LoadBrewfile(suggestedFile)
+	if err != nil {
+		ui.PrintWarning("Failed to read suggested casks: %v", err)
+		return
+	}
+	ui.PrintInfo("DRY RUN: Would install %d Homebrew casks for manually installed apps", len(brewfile.Items))
+	if !restoreVerbose {
+		return
+	}
+	for _, item := range brewfile.Items {
+		fmt.Printf("  %s\n", strings.TrimSpace(item.RawLine))
+	}
+}
+
+// printBrewServicesPlan lists the services a dry run would start
+func printBrewServicesPlan(servicesFile string) {
+	services, err := packager.LoadBrewServices(servicesFile)
+	if err != nil {
+		ui.PrintWarning("Failed to read Homebrew services: %v", err)
+		return
+	}
+	ui.PrintInfo("DRY RUN: Would start %d Homebrew services", len(services))
+	if !restoreVerbose {
+		return
+	}
+	for _, service := range services {
+		fmt.Printf("  brew services start %s\n", service.Name)
+	}
+}
+
+// runInstallPlan installs one manager's share of the plan, returning
+// warnings for the restore summary
+func runInstallPlan(installer *packager.Installer, plan *packager.InstallPlan, manager string) []string {
+	label := planLabel(manager)
+	ui.PrintVerbose("Installing %s...", label)
+	count, err := installer.RunPlan(plan, manager)
+	if count > 0 {
+		ui.PrintSuccess("Installed %d %s", count, label)
+	}
+
+	var installErr *packager.PackageInstallError
+	switch {
+	case errors.As(err, &installErr):
+		return []string{fmt.Sprintf("%s failed packages: %s", label, strings.Join(installErr.FailedPackages, ", "))}
+	case err != nil:
+		return []string{fmt.Sprintf("%s: %v", label, err)}
+	}
+	return nil
+}
+
+// finishInstallPlan removes a completed plan, or says how to retry what's left
+func finishInstallPlan(plan *packager.InstallPlan) {
+	if len(plan.Items) == 0 || plan.Finish() {
+		return
+	}
+	ui.PrintDim("  %d package(s) not installed; retry with: stash restore --resume-install", plan.Remaining())
+}
+
+// resumeInstall continues the install plan an earlier restore left behind
+func resumeInstall(cfg *config.Config) error {
+	resolver, err := newDestResolver(restoreTarget, nil)
+	if err != nil {
+		return err
+	}
+	homeDir, _ := os.UserHomeDir()
+	planPath := filepath.Join(resolver.Rooted(filepath.Join(homeDir, "stash-backups")), "packages", packager.InstallPlanFile)
+
+	plan, err := packager.LoadInstallPlan(planPath)
+	if os.IsNotExist(err) {
+		ui.PrintInfo("No unfinished package install to resume")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	ui.PrintInfo("Resuming install from %s: %d package(s) left", plan.Backup, plan.Remaining())
+	if restoreDryRun {
+		for _, item := range plan.Items {
+			if item.Status != packager.ItemInstalled {
+				fmt.Printf("  %s %s (%s)\n", item.Manager, item.Name, item.Status)
+			}
+		}
+		return nil
+	}
+
+	installer := newPackageInstaller(cfg)
+	if plan.ExactVersions {
+		installer.UseLock(loadPackageLock(filepath.Dir(planPath)))
+	}
+	// Managers come back in restore order. System packages and runtimes
+	// aren't in the plan, so a resume relies on the first restore's
+	var warnings []string
+	for _, manager := range plan.Managers() {
+		warnings = append(warnings, runInstallPlan(installer, plan, manager)...)
+	}
+
+	if len(warnings) > 0 {
+		fmt.Println()
+		ui.PrintWarning("Install completed with warnings:")
+		for _, warning := range warnings {
+			ui.PrintDim("  - %s", warning)
+		}
+	}
+	finishInstallPlan(plan)
+	return nil
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/harshpatel5940/stash/internal/metadata"
+	"github.com/harshpatel5940/stash/internal/platform"
+)
+
+// foreignPlatformFiles splits off entries that only make sense on the
+// platform the backup was made on: everything under ~/Library when a macOS
+// backup is restored elsewhere, except fonts, which are remapped.
+func foreignPlatformFiles(files []metadata.FileInfo, source, current platform.Platform) (kept, skipped []metadata.FileInfo) {
+	if !source.IsMacOS() || current.IsMacOS() {
+		return files, nil
+	}
+
+	library := filepath.Join(source.HomeDir, "Library")
+	fonts := source.FontsDir()
+
+	for _, f := range files {
+		path := filepath.Clean(metadata.ExpandHome(f.OriginalPath, source.HomeDir))
+		_, inLibrary := cutPathPrefix(path, library)
+		_, inFonts := cutPathPrefix(path, fonts)
+		if inLibrary && !inFonts {
+			skipped = append(skipped, f)
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept, skipped
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/harshpatel5940/stash/internal/packager"
+	"github.com/harshpatel5940/stash/internal/ui"
+)
+
+// printSystemPackagesPlan lists what installing system packages would do
+func printSystemPackagesPlan(plans []packager.SystemPlan) {
+	for _, plan := range plans {
+		ui.PrintInfo("DRY RUN: Would install %s", plan.Summary())
+		if !restoreVerbose {
+			continue
+		}
+		for _, source := range plan.Sources {
+			fmt.Printf("  + source %s\n", source)
+		}
+		if len(plan.Packages) > 0 {
+			fmt.Printf("  %s\n", strings.Join(plan.Packages, " "))
+		}
+		if len(plan.Manual) > 0 {
+			fmt.Printf("  by hand: %s\n", strings.Join(plan.Manual, " "))
+		}
+	}
+}
+
+// installSystemPackages runs each plan with the package managers available
+// here, returning warnings for the restore summary
+func installSystemPackages(installer *packager.Installer, plans []packager.SystemPlan) []string {
+	var warnings []string
+	for _, plan := range plans {
+		ui.PrintVerbose("Installing %s...", plan.Summary())
+		count, err := installer.InstallSystemPackages(plan)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: %v", plan.Manager, err))
+		}
+		if count > 0 {
+			ui.PrintSuccess("Installed %d %s package(s)", count, plan.Manager)
+		}
+	}
+	return warnings
+}
@@ -2,8 +2,18 @@ package cmd
 
 import (
 	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/harshpatel5940/stash/internal/archiver"
+	"github.com/harshpatel5940/stash/internal/desktop"
+	"github.com/harshpatel5940/stash/internal/editors"
+	"github.com/harshpatel5940/stash/internal/gittracker"
+	"github.com/harshpatel5940/stash/internal/metadata"
+	"github.com/harshpatel5940/stash/internal/platform"
+	"github.com/harshpatel5940/stash/internal/tui"
 )
 
 func TestWrapDecryptError_KeyMismatch(t *testing.T) {
@@ -21,3 +31,494 @@ func TestWrapDecryptError_KeyMismatch(t *testing.T) {
 		t.Fatalf("expected command hint with -k, got: %s", msg)
 	}
 }
+
+func TestDestResolver_Target(t *testing.T) {
+	target := t.TempDir()
+	resolver := destResolver{homeDir: "/Users/alice", targetRoot: target}
+
+	tests := map[string]string{
+		"~/.zshrc":                         filepath.Join(target, "Users/alice/.zshrc"),
+		"/Users/alice/projects/app/.env":   filepath.Join(target, "Users/alice/projects/app/.env"),
+		"/etc/hosts":                       filepath.Join(target, "etc/hosts"),
+		"/Users/alice/../alice/.gitconfig": filepath.Join(target, "Users/alice/.gitconfig"),
+	}
+
+	for original, want := range tests {
+		if got := resolver.Resolve(original); got != want {
+			t.Errorf("Resolve(%q) = %q, want %q", original, got, want)
+		}
+	}
+}
+
+func TestDestResolver_NoTarget(t *testing.T) {
+	resolver := destResolver{homeDir: "/Users/alice"}
+
+	if got := resolver.Resolve("~/.ssh/config"); got != "/Users/alice/.ssh/config" {
+		t.Errorf("Resolve(~/.ssh/config) = %q", got)
+	}
+	if got := resolver.Resolve("/opt/app/.env"); got != "/opt/app/.env" {
+		t.Errorf("Resolve(/opt/app/.env) = %q", got)
+	}
+}
+
+func TestDestResolver_SourceHome(t *testing.T) {
+	resolver := destResolver{homeDir: "/home/bob"}
+	resolver.SetSourceHome("/Users/alice")
+
+	tests := map[string]string{
+		"~/.zshrc":                       "/home/bob/.zshrc",
+		"/Users/alice/projects/app/.env": "/home/bob/projects/app/.env",
+		"/Users/alice":                   "/home/bob",
+		"/Users/alicia/.zshrc":           "/Users/alicia/.zshrc",
+		"/etc/hosts":                     "/etc/hosts",
+	}
+
+	for original, want := range tests {
+		if got := resolver.Resolve(original); got != want {
+			t.Errorf("Resolve(%q) = %q, want %q", original, got, want)
+		}
+	}
+}
+
+func TestDestResolver_Platforms(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", "")
+
+	resolver := destResolver{homeDir: "/home/bob"}
+	resolver.SetSourceHome("/Users/alice")
+	resolver.SetPlatforms(platform.New(platform.MacOS, "/Users/alice"), platform.New(platform.Linux, "/home/bob"))
+
+	tests := map[string]string{
+		"~/Library/Fonts":             "/home/bob/.local/share/fonts",
+		"~/Library/Fonts/Mono.ttf":    "/home/bob/.local/share/fonts/Mono.ttf",
+		"/Users/alice/.config/nvim":   "/home/bob/.config/nvim",
+		"~/Library/FontsExtra/x.conf": "/home/bob/Library/FontsExtra/x.conf",
+	}
+	for original, want := range tests {
+		if got := resolver.Resolve(original); got != want {
+			t.Errorf("Resolve(%q) = %q, want %q", original, got, want)
+		}
+	}
+}
+
+func TestForeignPlatformFiles(t *testing.T) {
+	files := []metadata.FileInfo{
+		{OriginalPath: "~/.zshrc"},
+		{OriginalPath: "~/Library/Preferences"},
+		{OriginalPath: "~/Library/Application Support/Google/Chrome"},
+		{OriginalPath: "~/Library/Fonts"},
+	}
+	mac := platform.New(platform.MacOS, "/Users/alice")
+	linux := platform.New(platform.Linux, "/home/bob")
+
+	kept, skipped := foreignPlatformFiles(files, mac, linux)
+	if len(kept) != 2 || kept[0].OriginalPath != "~/.zshrc" || kept[1].OriginalPath != "~/Library/Fonts" {
+		t.Errorf("Unexpected kept files: %+v", kept)
+	}
+	if len(skipped) != 2 {
+		t.Errorf("Expected 2 macOS-only files skipped, got %+v", skipped)
+	}
+
+	if kept, skipped := foreignPlatformFiles(files, mac, platform.New(platform.MacOS, "/Users/bob")); len(kept) != len(files) || skipped != nil {
+		t.Errorf("Expected nothing skipped between macOS machines, got %d kept", len(kept))
+	}
+}
+
+func TestDestResolver_Mappings(t *testing.T) {
+	var mappings []pathMapping
+	for _, rule := range []string{"/Users/alice/projects=~/code", "~/projects/work=/srv/work"} {
+		mapping, err := parsePathMapping(rule, "/home/bob")
+		if err != nil {
+			t.Fatalf("parsePathMapping(%q) failed: %v", rule, err)
+		}
+		mappings = append(mappings, mapping)
+	}
+
+	resolver := destResolver{homeDir: "/home/bob", mappings: mappings}
+	resolver.SetSourceHome("/Users/alice")
+
+	tests := map[string]string{
+		"/Users/alice/projects/app/.env":  "/home/bob/code/app/.env",
+		"~/projects/work/api/.env":        "/srv/work/api/.env",
+		"/Users/alice/projects/work/.env": "/srv/work/.env",
+		"~/.zshrc":                        "/home/bob/.zshrc",
+	}
+
+	for original, want := range tests {
+		if got := resolver.Resolve(original); got != want {
+			t.Errorf("Resolve(%q) = %q, want %q", original, got, want)
+		}
+	}
+}
+
+func TestDestResolver_RepoFiles(t *testing.T) {
+	resolver := destResolver{homeDir: "/home/bob"}
+	resolver.SetSourceHome("/Users/alice")
+	resolver.SetRepos([]gittracker.GitRepo{
+		{Path: "/home/bob/src/app", RemoteURL: "https://github.com/acme/app"},
+	})
+
+	cloned := metadata.FileInfo{
+		OriginalPath: "~/projects/app/api/.env",
+		GitRemote:    "git@github.com:acme/app.git",
+		GitRelPath:   "api/.env",
+	}
+	if got := resolver.ResolveFile(cloned); got != "/home/bob/src/app/api/.env" {
+		t.Errorf("Expected file in local clone, got %q", got)
+	}
+
+	missing := metadata.FileInfo{
+		OriginalPath: "~/projects/other/.env",
+		GitRemote:    "git@github.com:acme/other.git",
+		GitRelPath:   ".env",
+	}
+	if got := resolver.ResolveFile(missing); got != "/home/bob/projects/other/.env" {
+		t.Errorf("Expected fallback to remapped path, got %q", got)
+	}
+
+	unmatched := unmatchedRepoFiles([]metadata.FileInfo{cloned, missing}, resolver)
+	if len(unmatched) != 1 || !strings.Contains(unmatched[0], "acme/other") {
+		t.Errorf("Unexpected unmatched report: %v", unmatched)
+	}
+}
+
+func TestParsePathMapping_Invalid(t *testing.T) {
+	for _, rule := range []string{"", "/a", "=/b", "/a="} {
+		if _, err := parsePathMapping(rule, "/home/me"); err == nil {
+			t.Errorf("Expected error for %q", rule)
+		}
+	}
+}
+
+func TestNewDestResolver_RejectsFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "not-a-dir")
+	if err := os.WriteFile(file, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newDestResolver(file, nil); err == nil {
+		t.Error("Expected error for file target")
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDetectConflicts(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+
+	writeTestFile(t, filepath.Join(src, "zshrc"), "export A=1\n")
+	writeTestFile(t, filepath.Join(dest, "zshrc"), "export A=2\n")
+	writeTestFile(t, filepath.Join(src, "same"), "same\n")
+	writeTestFile(t, filepath.Join(dest, "same"), "same\n")
+	writeTestFile(t, filepath.Join(src, "config", "a.toml"), "a = 1\n")
+	writeTestFile(t, filepath.Join(dest, "config", "a.toml"), "a = 2\n")
+	writeTestFile(t, filepath.Join(src, "config", "new.toml"), "new\n")
+	// Left out by the directory copy, so never a conflict
+	writeTestFile(t, filepath.Join(src, "config", "cache", "state"), "backup\n")
+	writeTestFile(t, filepath.Join(dest, "config", "cache", "state"), "local\n")
+	writeTestFile(t, filepath.Join(src, "config", ".DS_Store"), "backup\n")
+	writeTestFile(t, filepath.Join(dest, "config", ".DS_Store"), "local\n")
+
+	items := []restoreItem{
+		{Info: metadata.FileInfo{OriginalPath: "~/.zshrc"}, Source: filepath.Join(src, "zshrc"), Dest: filepath.Join(dest, "zshrc")},
+		{Info: metadata.FileInfo{OriginalPath: "~/.same"}, Source: filepath.Join(src, "same"), Dest: filepath.Join(dest, "same")},
+		{Info: metadata.FileInfo{OriginalPath: "~/.config", IsDir: true}, Source: filepath.Join(src, "config"), Dest: filepath.Join(dest, "config")},
+	}
+
+	conflicts := detectConflicts(items)
+	if len(conflicts) != 2 {
+		t.Fatalf("Expected 2 conflicts, got %d", len(conflicts))
+	}
+
+	byDest := conflictsByDest(conflicts)
+	c, ok := byDest[filepath.Join(dest, "zshrc")]
+	if !ok {
+		t.Fatal("Expected conflict for zshrc")
+	}
+	if !c.Text || c.Added != 1 || c.Removed != 1 {
+		t.Errorf("Unexpected conflict details: %+v", c)
+	}
+	if _, ok := byDest[filepath.Join(dest, "config", "a.toml")]; !ok {
+		t.Error("Expected conflict inside directory")
+	}
+}
+
+func TestPlanEditorSettings(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	zed, _ := editors.Lookup("zed")
+
+	editorsDir := t.TempDir()
+	writeTestFile(t, filepath.Join(editorsDir, editors.ManifestFile),
+		`[{"name": "zed", "label": "Zed", "paths": ["settings.json", "snippets", "../escape"]}]`)
+	writeTestFile(t, filepath.Join(editorsDir, "zed", "settings.json"), `{"vim_mode": true}`)
+	writeTestFile(t, filepath.Join(editorsDir, "zed", "snippets", "go.json"), `{}`)
+	writeTestFile(t, filepath.Join(editorsDir, "zed", "snippets", "History", "old.json"), `{}`)
+	writeTestFile(t, filepath.Join(zed.Dir, "settings.json"), `{"vim_mode": false}`)
+	writeTestFile(t, filepath.Join(zed.Dir, "snippets", "History", "old.json"), `{"local": true}`)
+
+	resolver, _ := newDestResolver("", nil)
+	items, storage := planEditorSettings(editorsDir, []string{"zed"}, resolver)
+	if len(items) != 2 || len(storage) != 0 {
+		t.Fatalf("Unexpected plan: %+v, %v", items, storage)
+	}
+
+	// Only settings.json conflicts; the cache directory isn't restored
+	conflicts := detectConflicts(items)
+	if len(conflicts) != 1 || conflicts[0].Dest != filepath.Join(zed.Dir, "settings.json") {
+		t.Fatalf("Unexpected conflicts: %+v", conflicts)
+	}
+
+	warnings := restoreEditorSettings(editorsDir, []string{"zed"}, resolver, func(dest string) bool {
+		return dest == conflicts[0].Dest
+	})
+	if len(warnings) != 0 {
+		t.Fatalf("Unexpected warnings: %v", warnings)
+	}
+	if data, _ := os.ReadFile(filepath.Join(zed.Dir, "settings.json")); string(data) != `{"vim_mode": false}` {
+		t.Errorf("Expected conflicting settings.json to be left for resolution, got %s", data)
+	}
+	if _, err := os.Stat(filepath.Join(zed.Dir, "snippets", "go.json")); err != nil {
+		t.Errorf("Expected snippets to be restored: %v", err)
+	}
+}
+
+func TestPlanDesktopSettingsTarget(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	// dconf and gsettings must not run for a sandbox restore
+	t.Setenv("PATH", t.TempDir())
+	writeTestFile(t, filepath.Join(home, ".config", "kwinrc"), "[Desktops]\nNumber=2\n")
+
+	target := t.TempDir()
+	writeTestFile(t, filepath.Join(target, home, ".config", "kdeglobals"), "[General]\nfont=Noto\n")
+	resolver, err := newDestResolver(target, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sections := []desktop.Section{
+		{Source: desktop.SourceKDE, Name: "kwinrc", Groups: map[string]map[string]string{"Desktops": {"Number": "4"}}},
+		{Source: desktop.SourceKDE, Name: "kdeglobals", Groups: map[string]map[string]string{"General": {"font": "Inter"}}},
+		{Source: desktop.SourceKDE, Name: "../escaperc", Groups: map[string]map[string]string{"A": {"b": "c"}}},
+		{Source: desktop.SourceDconf, Name: "/org/gnome/desktop/interface/", Groups: map[string]map[string]string{"/": {"gtk-theme": "'Adwaita'"}}},
+	}
+	items := planDesktopSettings(sections, t.TempDir(), resolver)
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 KDE items, got %+v", items)
+	}
+	for _, item := range items {
+		if !strings.HasPrefix(item.Dest, target) {
+			t.Errorf("Expected %s under the target", item.Dest)
+		}
+	}
+
+	// Only the existing kdeglobals in the sandbox conflicts
+	conflicts := detectConflicts(items)
+	if len(conflicts) != 1 || filepath.Base(conflicts[0].Dest) != "kdeglobals" {
+		t.Fatalf("Unexpected conflicts: %+v", conflicts)
+	}
+
+	settingsFile := filepath.Join(t.TempDir(), desktop.SettingsFile)
+	warnings := restoreDesktopSettings(settingsFile, sections, items, resolver, func(dest string) bool {
+		return dest == conflicts[0].Dest
+	})
+	if len(warnings) != 0 {
+		t.Fatalf("Unexpected warnings: %v", warnings)
+	}
+	if data, _ := os.ReadFile(filepath.Join(target, home, ".config", "kwinrc")); !strings.Contains(string(data), "[Desktops]\nNumber=4\n") {
+		t.Errorf("Unexpected kwinrc in the target:\n%s", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(home, ".config", "kwinrc")); string(data) != "[Desktops]\nNumber=2\n" {
+		t.Errorf("Expected the real kwinrc to be untouched, got:\n%s", data)
+	}
+	if data, _ := os.ReadFile(conflicts[0].Dest); string(data) != "[General]\nfont=Noto\n" {
+		t.Errorf("Expected conflicting kdeglobals to be left for resolution, got:\n%s", data)
+	}
+}
+
+func TestApplyConflictResolution(t *testing.T) {
+	dir := t.TempDir()
+	arch := archiver.NewArchiver()
+
+	newConflict := func(name, resolution string) *restoreConflict {
+		source := filepath.Join(dir, "backup", name)
+		dest := filepath.Join(dir, "home", name)
+		writeTestFile(t, source, "line\nbackup\n")
+		writeTestFile(t, dest, "line\nlocal\n")
+		return &restoreConflict{
+			Item:       restoreItem{Info: metadata.FileInfo{Mode: 0644}},
+			Source:     source,
+			Dest:       dest,
+			Text:       true,
+			Resolution: resolution,
+		}
+	}
+
+	var summary conflictSummary
+
+	keep := newConflict("keep", tui.ConflictKeep)
+	saveAs := newConflict("save", tui.ConflictSaveAs)
+	merge := newConflict("merge", tui.ConflictMerge)
+	overwrite := newConflict("overwrite", tui.ConflictOverwrite)
+
+	for _, c := range []*restoreConflict{keep, saveAs, merge, overwrite} {
+		if err := applyConflictResolution(arch, c, &summary); err != nil {
+			t.Fatalf("applyConflictResolution(%s) failed: %v", c.Resolution, err)
+		}
+	}
+
+	if data, _ := os.ReadFile(keep.Dest); string(data) != "line\nlocal\n" {
+		t.Errorf("keep modified local file: %q", data)
+	}
+	if data, _ := os.ReadFile(saveAs.Dest + restoredSuffix); string(data) != "line\nbackup\n" {
+		t.Errorf("save-as wrote %q", data)
+	}
+	if data, _ := os.ReadFile(saveAs.Dest); string(data) != "line\nlocal\n" {
+		t.Errorf("save-as modified local file: %q", data)
+	}
+	if data, _ := os.ReadFile(merge.Dest); !strings.Contains(string(data), "<<<<<<< local") {
+		t.Errorf("merge missing conflict markers: %q", data)
+	}
+	if data, _ := os.ReadFile(overwrite.Dest); string(data) != "line\nbackup\n" {
+		t.Errorf("overwrite wrote %q", data)
+	}
+
+	if summary.Kept != 1 || summary.Saved != 1 || summary.Merged != 1 || summary.Overwritten != 1 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+}
+
+func TestCopiedFileCount(t *testing.T) {
+	src := t.TempDir()
+	writeTestFile(t, filepath.Join(src, "config"), "a")
+	writeTestFile(t, filepath.Join(src, "sub", "kept"), "b")
+	writeTestFile(t, filepath.Join(src, "sub", "written"), "c")
+	writeTestFile(t, filepath.Join(src, "node_modules", "pkg", "index.js"), "d")
+	writeTestFile(t, filepath.Join(src, ".DS_Store"), "e")
+
+	dest := "/home/me/.app"
+	skip := func(path string) bool { return path == filepath.Join(dest, "sub", "kept") }
+	if got := copiedFileCount(src, dest, skip); got != 2 {
+		t.Errorf("Expected 2 copied files, got %d", got)
+	}
+}
+
+func TestValidateConflictPolicy(t *testing.T) {
+	for _, policy := range []string{"ask", "overwrite", "keep", "save-as", "merge"} {
+		if err := validateConflictPolicy(policy); err != nil {
+			t.Errorf("Expected %q to be valid: %v", policy, err)
+		}
+	}
+	if err := validateConflictPolicy("clobber"); err == nil {
+		t.Error("Expected invalid policy error")
+	}
+}
+
+func TestParsePlanPath(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{"[FILE] ~/.zshrc (1.2 KB)", "~/.zshrc"},
+		{"[CONF] /home/me/.zshrc (+3 -1, local copy is newer)", "/home/me/.zshrc"},
+		{"[FILE] no details", ""},
+	}
+
+	for _, tt := range tests {
+		if got := parsePlanPath(tt.line); got != tt.expected {
+			t.Errorf("parsePlanPath(%q) = %q, want %q", tt.line, got, tt.expected)
+		}
+	}
+}
+
+func TestRestoreFilterApply(t *testing.T) {
+	extractDir := t.TempDir()
+	writeTestFile(t, filepath.Join(extractDir, "ssh", "id_ed25519"), "key")
+	writeTestFile(t, filepath.Join(extractDir, "ssh", "known_hosts"), "hosts")
+	// Left out by the directory copy, so never split into members
+	writeTestFile(t, filepath.Join(extractDir, "ssh", ".DS_Store"), "x")
+	writeTestFile(t, filepath.Join(extractDir, "ssh", "cache", "id_cached"), "x")
+	writeTestFile(t, filepath.Join(extractDir, "dotfiles", ".zshrc"), "zsh")
+	writeTestFile(t, filepath.Join(extractDir, "env-files", "app.env"), "A=1")
+
+	files := []metadata.FileInfo{
+		{OriginalPath: "/home/me/.ssh", BackupPath: "ssh", IsDir: true},
+		{OriginalPath: "/home/me/.zshrc", BackupPath: "dotfiles/.zshrc"},
+		{OriginalPath: "/home/me/projects/app/.env", BackupPath: "env-files/app.env"},
+	}
+
+	paths := func(files []metadata.FileInfo) []string {
+		var out []string
+		for _, f := range files {
+			out = append(out, f.OriginalPath)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name     string
+		only     []string
+		exclude  []string
+		expected []string
+	}{
+		{"no filter", nil, nil, []string{"/home/me/.ssh", "/home/me/.zshrc", "/home/me/projects/app/.env"}},
+		{"directory glob", []string{"~/.ssh/*"}, nil, []string{"/home/me/.ssh"}},
+		{"file inside directory", []string{"~/.ssh/id_*"}, nil, []string{"/home/me/.ssh/id_ed25519"}},
+		{"category", []string{"category:env-files"}, nil, []string{"/home/me/projects/app/.env"}},
+		{"exclude inside directory", []string{"category:ssh"}, []string{"~/.ssh/known_hosts"}, []string{"/home/me/.ssh/id_ed25519"}},
+		{"exclude only", nil, []string{"category:SSH", "~/projects"}, []string{"/home/me/.zshrc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := newRestoreFilter(tt.only, tt.exclude, "/home/me")
+			if err != nil {
+				t.Fatalf("newRestoreFilter failed: %v", err)
+			}
+
+			got := paths(filter.Apply(files, extractDir, destResolver{homeDir: "/home/me"}))
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Apply() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRestoreFilterSplitKeepsBackupPaths(t *testing.T) {
+	extractDir := t.TempDir()
+	writeTestFile(t, filepath.Join(extractDir, "config", "nvim", "init.lua"), "vim")
+	writeTestFile(t, filepath.Join(extractDir, "config", "git", "config"), "git")
+
+	filter, err := newRestoreFilter([]string{"~/.config/nvim"}, nil, "/home/me")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := filter.Apply([]metadata.FileInfo{{OriginalPath: "~/.config", BackupPath: "config", IsDir: true}}, extractDir, destResolver{homeDir: "/home/me"})
+	if len(got) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(got))
+	}
+	if got[0].IsDir || got[0].BackupPath != filepath.Join("config", "nvim", "init.lua") || got[0].OriginalPath != "~/.config/nvim/init.lua" {
+		t.Errorf("Unexpected entry: %+v", got[0])
+	}
+}
+
+func TestNewRestoreFilter_Invalid(t *testing.T) {
+	if _, err := newRestoreFilter([]string{"~/[bad"}, nil, "/home/me"); err == nil {
+		t.Error("Expected error for malformed glob")
+	}
+	if _, err := newRestoreFilter(nil, []string{"category:"}, "/home/me"); err == nil {
+		t.Error("Expected error for empty category")
+	}
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/harshpatel5940/stash/internal/packager"
+	"github.com/harshpatel5940/stash/internal/ui"
+)
+
+// printToolchainsPlan lists the runtimes a restore would install
+func printToolchainsPlan(toolchainsFile string) {
+	toolchains, err := packager.LoadToolchains(toolchainsFile)
+	if err != nil {
+		ui.PrintWarning("Failed to read toolchains: %v", err)
+		return
+	}
+	ui.PrintInfo("DRY RUN: Would install %d runtime version(s)", packager.CountToolchainVersions(toolchains))
+	if !restoreVerbose {
+		return
+	}
+	for _, line := range packager.ToolchainPlan(toolchains) {
+		fmt.Printf("  %s\n", line)
+	}
+}
+
+// installToolchains reinstalls runtimes, returning warnings for the restore summary
+func installToolchains(installer *packager.Installer, toolchainsFile string) []string {
+	ui.PrintVerbose("Installing language runtimes...")
+	count, err := installer.InstallToolchains(toolchainsFile)
+	if count > 0 {
+		ui.PrintSuccess("Installed %d runtime version(s)", count)
+	}
+	if err != nil {
+		return []string{fmt.Sprintf("Runtimes: %v", err)}
+	}
+	return nil
+}
+
+// printGoBinariesPlan lists the Go binaries a restore would reinstall, at
+// their recorded versions when exact is set and @latest otherwise
+func printGoBinariesPlan(goBinariesFile string, exact bool) {
+	targets, err := packager.LoadGoBinaries(goBinariesFile)
+	if err != nil {
+		ui.PrintWarning("Failed to read Go binaries: %v", err)
+		return
+	}
+	ui.PrintInfo("DRY RUN: Would install %d Go binaries", len(targets))
+	if !restoreVerbose {
+		return
+	}
+	for _, target := range targets {
+		if !exact {
+			path, _, _ := strings.Cut(target, "@")
+			target = path + "@latest"
+		}
+		fmt.Printf("  go install %s\n", target)
+	}
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/harshpatel5940/stash/internal/config"
+	"github.com/harshpatel5940/stash/internal/finder"
+	"github.com/harshpatel5940/stash/internal/secrets"
+	"github.com/harshpatel5940/stash/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var secretsAuditJSON bool
+
+// secretsAuditReport is the --json output of stash secrets audit
+type secretsAuditReport struct {
+	Total    int               `json:"total"`
+	Flagged  int               `json:"flagged"`
+	Findings []secrets.Finding `json:"findings"`
+}
+
+// secretKindLabels name each kind of finding in summaries
+var secretKindLabels = []struct{ kind, label string }{
+	{secrets.KindSSHKey, "SSH key(s)"},
+	{secrets.KindCertificate, "certificate(s)"},
+	{secrets.KindPrivateKey, "PEM key(s)"},
+	{secrets.KindAWSProfile, "AWS profile(s)"},
+	{secrets.KindKubeUser, "kube user(s)"},
+}
+
+// maxFlaggedSecrets caps how many flagged secrets the backup summary lists
+const maxFlaggedSecrets = 10
+
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Inspect the keys and credentials stash backs up",
+}
+
+var secretsAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "List keys, certificates and credentials and flag risky ones",
+	Long: `Takes inventory of SSH private keys in ~/.ssh, certificates and private
+keys in .pem files under your search paths, AWS credential profiles and
+kubeconfig users. Secret values are never shown.
+
+Flags keys without a passphrase, RSA keys under 2048 bits, DSA keys,
+certificates that have expired or expire within 30 days, unencrypted
+PEM keys, long-lived AWS access keys, and kube users with plaintext
+tokens, passwords or embedded client keys.
+
+Use --json for machine-readable output.
+
+Examples:
+  stash secrets audit
+  stash secrets audit --json`,
+	Args: cobra.NoArgs,
+	RunE: runSecretsAudit,
+}
+
+func init() {
+	rootCmd.AddCommand(secretsCmd)
+	secretsCmd.AddCommand(secretsAuditCmd)
+	secretsAuditCmd.Flags().BoolVar(&secretsAuditJSON, "json", false, "Output as JSON")
+}
+
+func runSecretsAudit(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	cfg.ExpandPaths()
+
+	pemFiles, err := finder.NewEnvFilesFinder(cfg.SearchPaths, cfg.Exclude).FindPemFiles()
+	if err != nil {
+		return fmt.Errorf("failed to find .pem files: %w", err)
+	}
+	homeDir, _ := os.UserHomeDir()
+	report := secrets.NewAuditor(homeDir, pemFiles).Run()
+
+	if secretsAuditJSON {
+		out := secretsAuditReport{
+			Total:    len(report.Findings),
+			Flagged:  len(report.Flagged()),
+			Findings: report.Findings,
+		}
+		if out.Findings == nil {
+			out.Findings = []secrets.Finding{}
+		}
+		data, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(report.Findings) == 0 {
+		ui.PrintInfo("No keys, certificates or credentials found")
+		return nil
+	}
+
+	var rows [][]string
+	for _, f := range report.Findings {
+		status := "ok"
+		if len(f.Issues) > 0 {
+			status = strings.Join(f.Issues, "; ")
+		}
+		rows = append(rows, []string{f.Kind, f.Name, shortenRemindPath(f.Path, homeDir), f.Details(), status})
+	}
+	ui.PrintTable([]string{"KIND", "NAME", "PATH", "DETAILS", "STATUS"}, rows)
+
+	fmt.Println()
+	if flagged := len(report.Flagged()); flagged > 0 {
+		ui.PrintWarning("%d of %d need attention", flagged, len(report.Findings))
+	} else {
+		ui.PrintSuccess("%s, none flagged", secretCounts(report))
+	}
+	return nil
+}
+
+// secretCounts describes how many of each kind a report found
+func secretCounts(report *secrets.Report) string {
+	counts := report.Counts()
+	var parts []string
+	for _, kl := range secretKindLabels {
+		if n := counts[kl.kind]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, kl.label))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// printSecretsAudit adds the audit of the backed up secrets to the backup
+// summary
+func printSecretsAudit(report *secrets.Report) {
+	if report == nil || len(report.Findings) == 0 {
+		return
+	}
+
+	flagged := report.Flagged()
+	if len(flagged) == 0 {
+		ui.PrintDim("  Secrets: %s, none flagged", secretCounts(report))
+		return
+	}
+
+	homeDir, _ := os.UserHomeDir()
+	ui.PrintWarning("%d of %d secret(s) need attention (%s):", len(flagged), len(report.Findings), secretCounts(report))
+	for i, f := range flagged {
+		if i == maxFlaggedSecrets && !backupVerbose {
+			ui.PrintDim("  ... and %d more (run `stash secrets audit`)", len(flagged)-maxFlaggedSecrets)
+			break
+		}
+		name := shortenRemindPath(f.Path, homeDir)
+		if f.Kind == secrets.KindAWSProfile || f.Kind == secrets.KindKubeUser {
+			name = fmt.Sprintf("%s %s", f.Kind, f.Name)
+		}
+		ui.PrintDim("  %s: %s", name, strings.Join(f.Issues, ", "))
+	}
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/harshpatel5940/stash/internal/config"
+	"github.com/harshpatel5940/stash/internal/rollback"
+	"github.com/harshpatel5940/stash/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	undoDecryptKey string
+	undoList       bool
+	undoVerbose    bool
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo [restore-id]",
+	Short: "Revert a previous restore",
+	Long: `Puts the machine back exactly as it was before a restore.
+
+Every restore saves the files it is about to overwrite into an encrypted
+rollback snapshot. Undo puts those files back and removes files and
+directories the restore created.
+
+Git repos the restore cloned are not undone: new clones, and the .git
+directory and checked out files added to an existing directory, are left
+in place. Remove them by hand if needed.
+
+Without a restore ID, the most recent restore is reverted.
+
+Examples:
+  stash undo
+  stash undo restore-2026-04-06-171328
+  stash undo --list`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runUndo,
+}
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+	undoCmd.Flags().StringVarP(&undoDecryptKey, "decrypt-key", "k", "", "Path to decryption key (default: ~/.stash.key)")
+	undoCmd.Flags().BoolVarP(&undoList, "list", "l", false, "List restores that can be undone")
+	undoCmd.Flags().BoolVarP(&undoVerbose, "verbose", "v", false, "Show detailed output")
+}
+
+func runUndo(cmd *cobra.Command, args []string) error {
+	ui.Verbose = undoVerbose
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	cfg.ExpandPaths()
+
+	rb := rollback.NewManager(cfg.BackupDir)
+
+	if undoList {
+		return printRestoreJournals(rb)
+	}
+
+	var journal *rollback.Journal
+	if len(args) == 1 {
+		journal, err = rb.Load(strings.TrimSpace(args[0]))
+	} else {
+		journal, err = rb.Latest()
+	}
+	if err != nil {
+		return err
+	}
+
+	keyPath := strings.TrimSpace(undoDecryptKey)
+	if keyPath == "" {
+		keyPath = strings.TrimSpace(cfg.EncryptionKey)
+	}
+	if keyPath == "" {
+		homeDir, _ := os.UserHomeDir()
+		keyPath = filepath.Join(homeDir, ".stash.key")
+	}
+
+	ui.PrintVerbose("Undoing %s (restore of %s)", journal.ID, journal.BackupName)
+
+	result, err := rb.Undo(journal, keyPath)
+	if err != nil {
+		return fmt.Errorf("failed to undo %s: %w", journal.ID, err)
+	}
+
+	ui.PrintSuccess("Undid %s: %d file(s) restored, %d removed", journal.ID, result.Restored, result.Removed)
+	if len(result.Failed) > 0 {
+		ui.PrintWarning("Could not revert %d path(s):", len(result.Failed))
+		for _, path := range result.Failed {
+			ui.PrintDim("  - %s", path)
+		}
+	}
+
+	return nil
+}
+
+func printRestoreJournals(rb *rollback.Manager) error {
+	journals, err := rb.List()
+	if err != nil {
+		return err
+	}
+
+	if len(journals) == 0 {
+		ui.PrintInfo("No restores recorded")
+		return nil
+	}
+
+	headers := []string{"ID", "DATE", "BACKUP", "FILES", "STATUS"}
+	var rows [][]string
+	for _, journal := range journals {
+		status := "active"
+		if journal.Undone {
+			status = "undone"
+		}
+		rows = append(rows, []string{
+			journal.ID,
+			journal.Timestamp.Format("2006-01-02 15:04"),
+			truncateInfo(journal.BackupName, 35),
+			fmt.Sprintf("%d", journal.FileCount()),
+			status,
+		})
+	}
+
+	ui.PrintTable(headers, rows)
+	return nil
+}
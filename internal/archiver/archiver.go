@@ -193,10 +193,16 @@ func (a *Archiver) CopyFile(src, dest string) error {
 }
 
 func (a *Archiver) CopyDir(src, dest string) error {
-	return a.copyDirWithExclusions(src, dest, getConfigExclusions())
+	return a.copyDirWithExclusions(src, dest, getConfigExclusions(), nil)
 }
 
-func (a *Archiver) copyDirWithExclusions(src, dest string, exclusions []string) error {
+// CopyDirExcept copies a directory like CopyDir but leaves alone any
+// destination file for which skip returns true
+func (a *Archiver) CopyDirExcept(src, dest string, skip func(destPath string) bool) error {
+	return a.copyDirWithExclusions(src, dest, getConfigExclusions(), skip)
+}
+
+func (a *Archiver) copyDirWithExclusions(src, dest string, exclusions []string, skip func(string) bool) error {
 	// Sanitize paths
 	src = security.CleanPath(src)
 	dest = security.CleanPath(dest)
@@ -242,11 +248,14 @@ func (a *Archiver) copyDirWithExclusions(src, dest string, exclusions []string)
 
 		if entry.IsDir() {
 
-			if err := a.copyDirWithExclusions(srcPath, destPath, exclusions); err != nil {
+			if err := a.copyDirWithExclusions(srcPath, destPath, exclusions, skip); err != nil {
 
 				continue
 			}
 		} else {
+			if skip != nil && skip(destPath) {
+				continue
+			}
 
 			if err := a.CopyFile(srcPath, destPath); err != nil {
 
@@ -258,6 +267,12 @@ func (a *Archiver) copyDirWithExclusions(src, dest string, exclusions []string)
 	return nil
 }
 
+// Excluded reports whether CopyDir leaves out a file or directory with
+// this name
+func Excluded(name string) bool {
+	return shouldExcludeConfigPath(name, getConfigExclusions())
+}
+
 func getConfigExclusions() []string {
 	return []string{
 		"node_modules",
@@ -4,6 +4,7 @@
 package backuputil
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -18,14 +19,27 @@ import (
 // Handles both encrypted (.age) and unencrypted (.tar.gz) backups.
 // If keyPath is empty, it defaults to ~/.stash.key for encrypted backups.
 func ExtractMetadata(backupPath, keyPath string) (*metadata.Metadata, error) {
-	// Check if backup file exists
-	if _, err := os.Stat(backupPath); err != nil {
-		return nil, fmt.Errorf("backup file not found: %w", err)
+	files, err := ExtractFiles(backupPath, keyPath, "metadata.json")
+	if err != nil {
+		return nil, err
 	}
 
-	// Determine if backup is encrypted
-	isEncrypted := strings.HasSuffix(backupPath, ".age")
+	data, ok := files["metadata.json"]
+	if !ok {
+		return nil, fmt.Errorf("metadata.json not found in backup archive")
+	}
+
+	var meta metadata.Metadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata: %w", err)
+	}
+	return &meta, nil
+}
 
+// ExtractFiles reads the named files, given as paths relative to the archive
+// root, from a backup. Files missing from the archive are left out of the
+// result.
+func ExtractFiles(backupPath, keyPath string, names ...string) (map[string][]byte, error) {
 	// Create temp directory for extraction
 	tempDir, err := os.MkdirTemp("", "stash-metadata-*")
 	if err != nil {
@@ -33,6 +47,53 @@ func ExtractMetadata(backupPath, keyPath string) (*metadata.Metadata, error) {
 	}
 	defer os.RemoveAll(tempDir)
 
+	extractDir, err := extract(backupPath, keyPath, tempDir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(extractDir, filepath.FromSlash(name)))
+		if err == nil {
+			files[name] = data
+		}
+	}
+	return files, nil
+}
+
+// ExtractDir copies a directory, given as a path relative to the archive
+// root, out of a backup into dest
+func ExtractDir(backupPath, keyPath, name, dest string) error {
+	tempDir, err := os.MkdirTemp("", "stash-extract-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	extractDir, err := extract(backupPath, keyPath, tempDir)
+	if err != nil {
+		return err
+	}
+
+	src := filepath.Join(extractDir, filepath.FromSlash(name))
+	if info, err := os.Stat(src); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s not found in backup archive", name)
+	}
+	return archiver.NewArchiver().CopyDir(src, dest)
+}
+
+// extract decrypts the backup if needed and unpacks it inside tempDir,
+// returning the directory it was unpacked to
+func extract(backupPath, keyPath, tempDir string) (string, error) {
+	// Check if backup file exists
+	if _, err := os.Stat(backupPath); err != nil {
+		return "", fmt.Errorf("backup file not found: %w", err)
+	}
+
+	// Determine if backup is encrypted
+	isEncrypted := strings.HasSuffix(backupPath, ".age")
+
 	var archivePath string
 
 	if isEncrypted {
@@ -40,21 +101,21 @@ func ExtractMetadata(backupPath, keyPath string) (*metadata.Metadata, error) {
 		if keyPath == "" {
 			homeDir, err := os.UserHomeDir()
 			if err != nil {
-				return nil, fmt.Errorf("failed to get home directory: %w", err)
+				return "", fmt.Errorf("failed to get home directory: %w", err)
 			}
 			keyPath = filepath.Join(homeDir, ".stash.key")
 		}
 
 		// Check if key exists
 		if _, err := os.Stat(keyPath); err != nil {
-			return nil, fmt.Errorf("encryption key not found at %s: %w", keyPath, err)
+			return "", fmt.Errorf("encryption key not found at %s: %w", keyPath, err)
 		}
 
 		// Decrypt to temp file
 		decryptedPath := filepath.Join(tempDir, "backup.tar.gz")
 		enc := crypto.NewEncryptor(keyPath)
 		if err := enc.Decrypt(backupPath, decryptedPath); err != nil {
-			return nil, fmt.Errorf("failed to decrypt backup: %w", err)
+			return "", fmt.Errorf("failed to decrypt backup: %w", err)
 		}
 		archivePath = decryptedPath
 	} else {
@@ -64,22 +125,14 @@ func ExtractMetadata(backupPath, keyPath string) (*metadata.Metadata, error) {
 	// Extract the archive
 	extractDir := filepath.Join(tempDir, "extracted")
 	if err := os.MkdirAll(extractDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create extraction directory: %w", err)
+		return "", fmt.Errorf("failed to create extraction directory: %w", err)
 	}
 
 	arch := archiver.NewArchiver()
 	if err := arch.Extract(archivePath, extractDir); err != nil {
-		return nil, fmt.Errorf("failed to extract backup: %w", err)
+		return "", fmt.Errorf("failed to extract backup: %w", err)
 	}
-
-	// Look for metadata.json in the extracted contents
-	metadataPath := filepath.Join(extractDir, "metadata.json")
-	if _, err := os.Stat(metadataPath); err != nil {
-		return nil, fmt.Errorf("metadata.json not found in backup archive")
-	}
-
-	// Load and return the metadata
-	return metadata.Load(metadataPath)
+	return extractDir, nil
 }
 
 // IsEncrypted returns true if the backup file is encrypted (has .age extension)
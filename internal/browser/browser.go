@@ -6,11 +6,13 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/harshpatel5940/stash/internal/platform"
 	"github.com/harshpatel5940/stash/internal/security"
 )
 
 type BrowserManager struct {
 	outputDir string
+	platform  platform.Platform
 }
 
 type BrowserInfo struct {
@@ -20,48 +22,78 @@ type BrowserInfo struct {
 }
 
 func NewBrowserManager(outputDir string) *BrowserManager {
+	return NewBrowserManagerFor(outputDir, platform.Current())
+}
+
+// NewBrowserManagerFor returns a manager for the browsers of platform p
+func NewBrowserManagerFor(outputDir string, p platform.Platform) *BrowserManager {
 	return &BrowserManager{
 		outputDir: outputDir,
+		platform:  p,
 	}
 }
 
+// chromiumFiles are the profile files kept for Chromium-based browsers
+var chromiumFiles = []string{
+	"Default/Bookmarks",
+	"Default/Preferences",
+	"Default/Extensions",
+	"Local State",
+}
+
+// GetBrowsers returns the browsers stash knows about on this platform
 func (bm *BrowserManager) GetBrowsers() []BrowserInfo {
-	homeDir, _ := os.UserHomeDir()
+	return browsersFor(bm.platform)
+}
 
-	browsers := []BrowserInfo{
-		{
-			Name: "Chrome",
-			Path: filepath.Join(homeDir, "Library/Application Support/Google/Chrome"),
-			FilesToBackup: []string{
-				"Default/Bookmarks",
-				"Default/Preferences",
-				"Default/Extensions",
-				"Local State",
+func browsersFor(p platform.Platform) []BrowserInfo {
+	if p.IsMacOS() {
+		appSupport := p.AppSupportDir()
+		return []BrowserInfo{
+			{Name: "Chrome", Path: filepath.Join(appSupport, "Google/Chrome"), FilesToBackup: chromiumFiles},
+			{Name: "Brave", Path: filepath.Join(appSupport, "BraveSoftware/Brave-Browser"), FilesToBackup: chromiumFiles},
+			{Name: "Edge", Path: filepath.Join(appSupport, "Microsoft Edge"), FilesToBackup: chromiumFiles},
+			{
+				Name: "Opera",
+				Path: filepath.Join(appSupport, "com.operasoftware.Opera"),
+				FilesToBackup: []string{
+					"Bookmarks",
+					"Preferences",
+					"Extensions",
+					"Local State",
+				},
 			},
-		},
-		{
-			Name: "Brave",
-			Path: filepath.Join(homeDir, "Library/Application Support/BraveSoftware/Brave-Browser"),
-			FilesToBackup: []string{
-				"Default/Bookmarks",
-				"Default/Preferences",
-				"Default/Extensions",
-				"Local State",
+			{Name: "Vivaldi", Path: filepath.Join(appSupport, "Vivaldi"), FilesToBackup: chromiumFiles},
+			{Name: "Firefox", Path: filepath.Join(appSupport, "Firefox"), FilesToBackup: []string{"profiles.ini"}},
+			{
+				Name: "Safari",
+				Path: filepath.Join(p.HomeDir, "Library/Safari"),
+				FilesToBackup: []string{
+					"Bookmarks.plist",
+					"TopSites.plist",
+				},
 			},
-		},
-		{
-			Name: "Edge",
-			Path: filepath.Join(homeDir, "Library/Application Support/Microsoft Edge"),
-			FilesToBackup: []string{
-				"Default/Bookmarks",
-				"Default/Preferences",
-				"Default/Extensions",
-				"Local State",
+			{
+				Name: "Arc",
+				Path: filepath.Join(appSupport, "Arc"),
+				FilesToBackup: []string{
+					"User Data/Default/Bookmarks",
+					"User Data/Default/Preferences",
+				},
 			},
-		},
+		}
+	}
+
+	// Linux and other XDG desktops
+	config := p.ConfigHome()
+	return []BrowserInfo{
+		{Name: "Chrome", Path: filepath.Join(config, "google-chrome"), FilesToBackup: chromiumFiles},
+		{Name: "Chromium", Path: filepath.Join(config, "chromium"), FilesToBackup: chromiumFiles},
+		{Name: "Brave", Path: filepath.Join(config, "BraveSoftware/Brave-Browser"), FilesToBackup: chromiumFiles},
+		{Name: "Edge", Path: filepath.Join(config, "microsoft-edge"), FilesToBackup: chromiumFiles},
 		{
 			Name: "Opera",
-			Path: filepath.Join(homeDir, "Library/Application Support/com.operasoftware.Opera"),
+			Path: filepath.Join(config, "opera"),
 			FilesToBackup: []string{
 				"Bookmarks",
 				"Preferences",
@@ -69,42 +101,9 @@ func (bm *BrowserManager) GetBrowsers() []BrowserInfo {
 				"Local State",
 			},
 		},
-		{
-			Name: "Vivaldi",
-			Path: filepath.Join(homeDir, "Library/Application Support/Vivaldi"),
-			FilesToBackup: []string{
-				"Default/Bookmarks",
-				"Default/Preferences",
-				"Default/Extensions",
-				"Local State",
-			},
-		},
-		{
-			Name: "Firefox",
-			Path: filepath.Join(homeDir, "Library/Application Support/Firefox"),
-			FilesToBackup: []string{
-				"profiles.ini",
-			},
-		},
-		{
-			Name: "Safari",
-			Path: filepath.Join(homeDir, "Library/Safari"),
-			FilesToBackup: []string{
-				"Bookmarks.plist",
-				"TopSites.plist",
-			},
-		},
-		{
-			Name: "Arc",
-			Path: filepath.Join(homeDir, "Library/Application Support/Arc"),
-			FilesToBackup: []string{
-				"User Data/Default/Bookmarks",
-				"User Data/Default/Preferences",
-			},
-		},
+		{Name: "Vivaldi", Path: filepath.Join(config, "vivaldi"), FilesToBackup: chromiumFiles},
+		{Name: "Firefox", Path: filepath.Join(p.HomeDir, ".mozilla/firefox"), FilesToBackup: []string{"profiles.ini"}},
 	}
-
-	return browsers
 }
 
 func (bm *BrowserManager) BackupAll() (map[string]int, error) {
@@ -140,7 +139,11 @@ func (bm *BrowserManager) BackupAll() (map[string]int, error) {
 					continue
 				}
 
-				destPath := filepath.Join(browserDir, filepath.Base(file))
+				// Keep the layout so the backup restores over the browser directory
+				destPath := filepath.Join(browserDir, filepath.FromSlash(file))
+				if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+					continue
+				}
 
 				if info.IsDir() {
 					if err := copyDir(srcPath, destPath); err != nil {
@@ -187,33 +190,30 @@ Consider exporting/merging bookmarks manually if needed.
 }
 
 func (bm *BrowserManager) backupFirefoxProfiles(firefoxPath, outputDir string) int {
-	profilesPath := filepath.Join(firefoxPath, "Profiles")
-	if _, err := os.Stat(profilesPath); os.IsNotExist(err) {
-		return 0
-	}
-
 	profilesIni := filepath.Join(firefoxPath, "profiles.ini")
 	if _, err := os.Stat(profilesIni); err == nil {
 		copyFile(profilesIni, filepath.Join(outputDir, "profiles.ini"))
 	}
 
-	entries, err := os.ReadDir(profilesPath)
+	profiles, err := firefoxProfiles(firefoxPath)
 	if err != nil {
 		return 0
 	}
 
 	fileCount := 0
-	for _, entry := range entries {
-		if !entry.IsDir() {
+	for _, profilePath := range profiles {
+		info, err := os.Stat(profilePath)
+		if err != nil || !info.IsDir() {
 			continue
 		}
 
-		if !strings.Contains(entry.Name(), "default") {
+		// Profiles kept outside the Firefox directory can't be restored
+		// to the same place, so only the usual ones are backed up
+		rel, err := filepath.Rel(firefoxPath, profilePath)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
 			continue
 		}
-
-		profilePath := filepath.Join(profilesPath, entry.Name())
-		profileBackupDir := filepath.Join(outputDir, "profile-"+entry.Name())
+		profileBackupDir := filepath.Join(outputDir, rel)
 
 		importantFiles := []string{
 			"places.sqlite",
@@ -247,6 +247,49 @@ func (bm *BrowserManager) backupFirefoxProfiles(firefoxPath, outputDir string) i
 	return fileCount
 }
 
+// firefoxProfiles returns the profile directories listed in profiles.ini.
+// Relative paths are resolved against the Firefox directory.
+func firefoxProfiles(firefoxPath string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(firefoxPath, "profiles.ini"))
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles []string
+	inProfile, relative, path := false, true, ""
+	addProfile := func() {
+		if !inProfile || path == "" {
+			return
+		}
+		if relative {
+			path = filepath.Join(firefoxPath, filepath.FromSlash(path))
+		}
+		profiles = append(profiles, filepath.Clean(path))
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			addProfile()
+			inProfile, relative, path = strings.HasPrefix(line, "[Profile"), true, ""
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "Path":
+			path = strings.TrimSpace(value)
+		case "IsRelative":
+			relative = strings.TrimSpace(value) != "0"
+		}
+	}
+	addProfile()
+
+	return profiles, nil
+}
+
 func copyFile(src, dst string) error {
 	// Sanitize paths
 	src = security.CleanPath(src)
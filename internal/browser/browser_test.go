@@ -0,0 +1,88 @@
+package browser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/harshpatel5940/stash/internal/platform"
+)
+
+func TestBrowsersFor(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	paths := make(map[string]string)
+	for _, b := range browsersFor(platform.New(platform.Linux, "/home/bob")) {
+		paths[b.Name] = b.Path
+	}
+	if paths["Chrome"] != filepath.FromSlash("/home/bob/.config/google-chrome") {
+		t.Errorf("Unexpected Chrome path on Linux: %s", paths["Chrome"])
+	}
+	if paths["Firefox"] != filepath.FromSlash("/home/bob/.mozilla/firefox") {
+		t.Errorf("Unexpected Firefox path on Linux: %s", paths["Firefox"])
+	}
+	if _, ok := paths["Safari"]; ok {
+		t.Error("Expected no Safari on Linux")
+	}
+
+	for _, b := range browsersFor(platform.New(platform.MacOS, "/Users/alice")) {
+		if b.Name == "Chrome" && b.Path != filepath.FromSlash("/Users/alice/Library/Application Support/Google/Chrome") {
+			t.Errorf("Unexpected Chrome path on macOS: %s", b.Path)
+		}
+	}
+}
+
+func TestBackupFirefoxProfilesKeepsLayout(t *testing.T) {
+	// Linux layout: profiles sit next to profiles.ini, alongside directories
+	// that aren't profiles
+	firefox := t.TempDir()
+	profile := filepath.Join(firefox, "abcd.default-release")
+	os.MkdirAll(profile, 0755)
+	os.MkdirAll(filepath.Join(firefox, "Crash Reports"), 0755)
+	os.MkdirAll(filepath.Join(firefox, "old.default"), 0755)
+	os.WriteFile(filepath.Join(firefox, "old.default", "prefs.js"), []byte("// stale\n"), 0644)
+	os.WriteFile(filepath.Join(firefox, "profiles.ini"),
+		[]byte("[Install4F96D1932A9F858E]\nDefault=abcd.default-release\n\n[Profile0]\nName=default-release\nIsRelative=1\nPath=abcd.default-release\n"), 0644)
+	os.WriteFile(filepath.Join(profile, "prefs.js"), []byte("// prefs\n"), 0644)
+
+	out := t.TempDir()
+	bm := NewBrowserManagerFor(out, platform.New(platform.Linux, "/home/bob"))
+	if count := bm.backupFirefoxProfiles(firefox, out); count != 1 {
+		t.Errorf("Expected 1 profile file, got %d", count)
+	}
+	if _, err := os.Stat(filepath.Join(out, "abcd.default-release", "prefs.js")); err != nil {
+		t.Errorf("Expected profile to keep its layout: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "old.default")); !os.IsNotExist(err) {
+		t.Error("Expected a directory missing from profiles.ini to be skipped")
+	}
+	if _, err := os.Stat(filepath.Join(out, "profiles.ini")); err != nil {
+		t.Errorf("Expected profiles.ini to be copied: %v", err)
+	}
+}
+
+func TestBackupFirefoxProfilesMacOS(t *testing.T) {
+	firefox := t.TempDir()
+	profile := filepath.Join(firefox, "Profiles", "wxyz.default")
+	os.MkdirAll(profile, 0755)
+	os.WriteFile(filepath.Join(profile, "prefs.js"), []byte("// prefs\n"), 0644)
+	external := filepath.Join(t.TempDir(), "work")
+	os.MkdirAll(external, 0755)
+	os.WriteFile(filepath.Join(external, "logins.json"), []byte("{}\n"), 0644)
+	os.WriteFile(filepath.Join(firefox, "profiles.ini"),
+		[]byte("[Profile0]\nIsRelative=1\nPath=Profiles/wxyz.default\n\n[Profile1]\nIsRelative=0\nPath="+external+"\n"), 0644)
+
+	out := t.TempDir()
+	bm := NewBrowserManagerFor(out, platform.New(platform.MacOS, "/Users/alice"))
+	if count := bm.backupFirefoxProfiles(firefox, out); count != 1 {
+		t.Errorf("Expected 1 profile file, got %d", count)
+	}
+	// The backup mirrors the Firefox directory, so it restores over it
+	if _, err := os.Stat(filepath.Join(out, "Profiles", "wxyz.default", "prefs.js")); err != nil {
+		t.Errorf("Expected the profile under Profiles: %v", err)
+	}
+	entries, _ := os.ReadDir(out)
+	if len(entries) != 2 {
+		t.Errorf("Expected only profiles.ini and Profiles, got %v", entries)
+	}
+}
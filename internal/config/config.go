@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
@@ -40,6 +41,17 @@ type DotfilesConfig struct {
 // SecretsConfig controls which secret directories are backed up
 type SecretsConfig struct {
 	Directories []string `yaml:"directories" mapstructure:"directories"`
+	// Permissions add to or override the built-in permission rules for
+	// ~/.ssh, ~/.gnupg, ~/.aws and ~/.kube
+	Permissions []PermissionRuleConfig `yaml:"permissions,omitempty" mapstructure:"permissions"`
+}
+
+// PermissionRuleConfig caps the permissions of a path under the home
+// directory and everything in it. Modes are octal strings such as "0700".
+type PermissionRuleConfig struct {
+	Path     string `yaml:"path" mapstructure:"path"` // may contain globs, e.g. .ssh/*.pub
+	DirMode  string `yaml:"dir_mode,omitempty" mapstructure:"dir_mode"`
+	FileMode string `yaml:"file_mode,omitempty" mapstructure:"file_mode"`
 }
 
 // ShellHistoryConfig controls shell history backup
@@ -52,6 +64,13 @@ type GitConfig struct {
 	SearchDirs []string `yaml:"search_dirs" mapstructure:"search_dirs"`
 	MaxDepth   int      `yaml:"max_depth" mapstructure:"max_depth"`
 	SkipDirs   []string `yaml:"skip_dirs" mapstructure:"skip_dirs"`
+	Bundles    bool     `yaml:"bundles" mapstructure:"bundles"` // Save unpushed commits and uncommitted changes
+	// CloneWorkers limits how many repos restore clones at once
+	CloneWorkers int `yaml:"clone_workers" mapstructure:"clone_workers"`
+	// ScanWorkers limits how many repos are inspected at once while scanning
+	ScanWorkers    int    `yaml:"scan_workers" mapstructure:"scan_workers"`
+	CommandTimeout string `yaml:"command_timeout" mapstructure:"command_timeout"` // Per git command, e.g. "30s"
+	ScanCache      bool   `yaml:"scan_cache" mapstructure:"scan_cache"`           // Reuse results for unchanged repos
 }
 
 // MacOSDefaultsConfig controls which macOS preferences to backup
@@ -60,16 +79,34 @@ type MacOSDefaultsConfig struct {
 	Domains []string `yaml:"domains" mapstructure:"domains"`
 }
 
+// DesktopSettingsConfig controls which Linux desktop settings to backup
+type DesktopSettingsConfig struct {
+	Enabled bool     `yaml:"enabled" mapstructure:"enabled"`
+	Dconf   []string `yaml:"dconf" mapstructure:"dconf"` // dconf paths (/org/gnome/...) or gsettings schemas
+	KDE     []string `yaml:"kde" mapstructure:"kde"`     // rc files in ~/.config
+}
+
 // BrowsersConfig controls browser data backup
 type BrowsersConfig struct {
 	Enabled bool     `yaml:"enabled" mapstructure:"enabled"`
 	Include []string `yaml:"include,omitempty" mapstructure:"include"`
 }
 
+// EditorsConfig controls editor and IDE settings backup
+type EditorsConfig struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+}
+
 // RestoreConfig controls restore behavior
 type RestoreConfig struct {
-	UseTUI              bool `yaml:"use_tui" mapstructure:"use_tui"`
-	FilePickerThreshold int  `yaml:"file_picker_threshold" mapstructure:"file_picker_threshold"`
+	UseTUI              bool   `yaml:"use_tui" mapstructure:"use_tui"`
+	FilePickerThreshold int    `yaml:"file_picker_threshold" mapstructure:"file_picker_threshold"`
+	OnConflict          string `yaml:"on_conflict,omitempty" mapstructure:"on_conflict"`
+	// InstallWorkers limits how many packages install at once, for package
+	// managers that allow it
+	InstallWorkers int `yaml:"install_workers" mapstructure:"install_workers"`
+	// InstallRetries is how many more times a failed package install is tried
+	InstallRetries int `yaml:"install_retries" mapstructure:"install_retries"`
 }
 
 // DiffConfig controls diff display
@@ -89,15 +126,17 @@ type Config struct {
 	Cloud              *CloudConfig       `yaml:"cloud,omitempty" mapstructure:"cloud"`
 
 	// New configurable sections
-	Backup        *BackupConfig        `yaml:"backup,omitempty" mapstructure:"backup"`
-	Dotfiles      *DotfilesConfig      `yaml:"dotfiles,omitempty" mapstructure:"dotfiles"`
-	Secrets       *SecretsConfig       `yaml:"secrets,omitempty" mapstructure:"secrets"`
-	ShellHistory  *ShellHistoryConfig  `yaml:"shell_history,omitempty" mapstructure:"shell_history"`
-	Git           *GitConfig           `yaml:"git,omitempty" mapstructure:"git"`
-	MacOSDefaults *MacOSDefaultsConfig `yaml:"macos_defaults,omitempty" mapstructure:"macos_defaults"`
-	Browsers      *BrowsersConfig      `yaml:"browsers,omitempty" mapstructure:"browsers"`
-	Restore       *RestoreConfig       `yaml:"restore,omitempty" mapstructure:"restore"`
-	Diff          *DiffConfig          `yaml:"diff,omitempty" mapstructure:"diff"`
+	Backup        *BackupConfig          `yaml:"backup,omitempty" mapstructure:"backup"`
+	Dotfiles      *DotfilesConfig        `yaml:"dotfiles,omitempty" mapstructure:"dotfiles"`
+	Secrets       *SecretsConfig         `yaml:"secrets,omitempty" mapstructure:"secrets"`
+	ShellHistory  *ShellHistoryConfig    `yaml:"shell_history,omitempty" mapstructure:"shell_history"`
+	Git           *GitConfig             `yaml:"git,omitempty" mapstructure:"git"`
+	MacOSDefaults *MacOSDefaultsConfig   `yaml:"macos_defaults,omitempty" mapstructure:"macos_defaults"`
+	Desktop       *DesktopSettingsConfig `yaml:"desktop_settings,omitempty" mapstructure:"desktop_settings"`
+	Browsers      *BrowsersConfig        `yaml:"browsers,omitempty" mapstructure:"browsers"`
+	Editors       *EditorsConfig         `yaml:"editors,omitempty" mapstructure:"editors"`
+	Restore       *RestoreConfig         `yaml:"restore,omitempty" mapstructure:"restore"`
+	Diff          *DiffConfig            `yaml:"diff,omitempty" mapstructure:"diff"`
 }
 
 func DefaultConfig() *Config {
@@ -158,6 +197,10 @@ func DefaultConfig() *Config {
 				"venv", ".venv", "dist", "build",
 				"Library", "Applications",
 			},
+			CloneWorkers:   4,
+			ScanWorkers:    8,
+			CommandTimeout: "30s",
+			ScanCache:      true,
 		},
 		MacOSDefaults: &MacOSDefaultsConfig{
 			Enabled: true,
@@ -186,13 +229,46 @@ func DefaultConfig() *Config {
 				"com.apple.TimeMachine",
 			},
 		},
+		Desktop: &DesktopSettingsConfig{
+			Enabled: true,
+			Dconf: []string{
+				"/org/gnome/desktop/interface/",
+				"/org/gnome/desktop/input-sources/",
+				"/org/gnome/desktop/peripherals/",
+				"/org/gnome/desktop/wm/keybindings/",
+				"/org/gnome/desktop/wm/preferences/",
+				"/org/gnome/settings-daemon/plugins/media-keys/",
+				"/org/gnome/shell/",
+				"/org/gnome/mutter/",
+				"/org/gnome/nautilus/preferences/",
+				"/org/gnome/terminal/legacy/",
+			},
+			KDE: []string{
+				"kdeglobals",
+				"kwinrc",
+				"kglobalshortcutsrc",
+				"kcminputrc",
+				"kxkbrc",
+				"plasmarc",
+				"plasma-org.kde.plasma.desktop-appletsrc",
+				"kscreenlockerrc",
+				"dolphinrc",
+				"konsolerc",
+			},
+		},
 		Browsers: &BrowsersConfig{
 			Enabled: false,
 			Include: []string{}, // Empty means all supported browsers
 		},
+		Editors: &EditorsConfig{
+			Enabled: true,
+		},
 		Restore: &RestoreConfig{
 			UseTUI:              true,
 			FilePickerThreshold: 100,
+			OnConflict:          "ask",
+			InstallWorkers:      4,
+			InstallRetries:      2,
 		},
 		Diff: &DiffConfig{
 			DisplayLimit: 10,
@@ -300,6 +376,48 @@ func (c *Config) GetGitSkipDirs() []string {
 	return []string{"node_modules", ".npm", ".cache", "vendor", "venv", ".venv", "dist", "build", "Library", "Applications"}
 }
 
+// IsGitBundlesEnabled returns whether unpushed and uncommitted git work is saved
+func (c *Config) IsGitBundlesEnabled() bool {
+	if c.Git != nil {
+		return c.Git.Bundles
+	}
+	return false
+}
+
+// GetGitCloneWorkers returns how many repos restore clones in parallel
+func (c *Config) GetGitCloneWorkers() int {
+	if c.Git != nil && c.Git.CloneWorkers > 0 {
+		return c.Git.CloneWorkers
+	}
+	return 4
+}
+
+// GetGitScanWorkers returns how many repos are inspected in parallel
+func (c *Config) GetGitScanWorkers() int {
+	if c.Git != nil && c.Git.ScanWorkers > 0 {
+		return c.Git.ScanWorkers
+	}
+	return 8
+}
+
+// GetGitCommandTimeout returns how long a single git command may run while scanning
+func (c *Config) GetGitCommandTimeout() time.Duration {
+	if c.Git != nil {
+		if d, err := time.ParseDuration(c.Git.CommandTimeout); err == nil && d > 0 {
+			return d
+		}
+	}
+	return 30 * time.Second
+}
+
+// IsGitScanCacheEnabled returns whether scans reuse results for unchanged repos
+func (c *Config) IsGitScanCacheEnabled() bool {
+	if c.Git != nil {
+		return c.Git.ScanCache
+	}
+	return true
+}
+
 // GetShellHistoryFiles returns shell history files to backup
 func (c *Config) GetShellHistoryFiles() []string {
 	if c.ShellHistory != nil && len(c.ShellHistory.Files) > 0 {
@@ -316,6 +434,15 @@ func (c *Config) GetSecretDirs() []string {
 	return []string{".ssh", ".gnupg", ".aws"}
 }
 
+// GetPermissionRules returns the configured permission rules, which apply
+// after the built-in ones
+func (c *Config) GetPermissionRules() []PermissionRuleConfig {
+	if c.Secrets != nil {
+		return c.Secrets.Permissions
+	}
+	return nil
+}
+
 // GetDotfilesIgnoredDirs returns directories to ignore when scanning dotfiles
 func (c *Config) GetDotfilesIgnoredDirs() []string {
 	if c.Dotfiles != nil && len(c.Dotfiles.IgnoredDirs) > 0 {
@@ -340,6 +467,30 @@ func (c *Config) IsMacOSDefaultsEnabled() bool {
 	return true
 }
 
+// GetDesktopDconfPaths returns dconf paths and gsettings schemas to backup
+func (c *Config) GetDesktopDconfPaths() []string {
+	if c.Desktop != nil && len(c.Desktop.Dconf) > 0 {
+		return c.Desktop.Dconf
+	}
+	return []string{"/org/gnome/desktop/interface/", "/org/gnome/desktop/wm/keybindings/"}
+}
+
+// GetDesktopKDEFiles returns KDE rc files to backup
+func (c *Config) GetDesktopKDEFiles() []string {
+	if c.Desktop != nil && len(c.Desktop.KDE) > 0 {
+		return c.Desktop.KDE
+	}
+	return []string{"kdeglobals", "kwinrc", "kglobalshortcutsrc"}
+}
+
+// IsDesktopSettingsEnabled returns whether Linux desktop settings backup is enabled
+func (c *Config) IsDesktopSettingsEnabled() bool {
+	if c.Desktop != nil {
+		return c.Desktop.Enabled
+	}
+	return true
+}
+
 // IsBrowsersEnabled returns whether browser data backup is enabled
 func (c *Config) IsBrowsersEnabled() bool {
 	if c.Browsers != nil {
@@ -348,6 +499,14 @@ func (c *Config) IsBrowsersEnabled() bool {
 	return false
 }
 
+// IsEditorsEnabled returns whether editor settings backup is enabled
+func (c *Config) IsEditorsEnabled() bool {
+	if c.Editors != nil {
+		return c.Editors.Enabled
+	}
+	return true
+}
+
 // GetRestoreFilePickerThreshold returns the file count threshold for TUI picker
 func (c *Config) GetRestoreFilePickerThreshold() int {
 	if c.Restore != nil {
@@ -364,6 +523,30 @@ func (c *Config) IsRestoreTUIEnabled() bool {
 	return true
 }
 
+// GetRestoreConflictPolicy returns how restore handles files that changed locally
+func (c *Config) GetRestoreConflictPolicy() string {
+	if c.Restore != nil && c.Restore.OnConflict != "" {
+		return c.Restore.OnConflict
+	}
+	return "ask"
+}
+
+// GetInstallWorkers returns how many packages restore installs at once
+func (c *Config) GetInstallWorkers() int {
+	if c.Restore != nil && c.Restore.InstallWorkers > 0 {
+		return c.Restore.InstallWorkers
+	}
+	return 4
+}
+
+// GetInstallRetries returns how many times restore retries a failed package install
+func (c *Config) GetInstallRetries() int {
+	if c.Restore != nil && c.Restore.InstallRetries >= 0 {
+		return c.Restore.InstallRetries
+	}
+	return 2
+}
+
 // GetDiffDisplayLimit returns the display limit for diff output
 func (c *Config) GetDiffDisplayLimit() int {
 	if c.Diff != nil {
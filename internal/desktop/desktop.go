@@ -0,0 +1,394 @@
+// Package desktop backs up Linux desktop settings, the counterpart of macOS
+// defaults. GNOME settings are read from dconf paths or gsettings schemas,
+// KDE settings from rc files in ~/.config. Everything is stored in one
+// structured file so settings can be restored selectively and compared
+// between backups.
+package desktop
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/harshpatel5940/stash/internal/config"
+	"github.com/harshpatel5940/stash/internal/platform"
+)
+
+// SettingsFile is the name of the file written to the output directory
+const SettingsFile = "desktop-settings.json"
+
+// Section sources
+const (
+	SourceDconf     = "dconf"
+	SourceGsettings = "gsettings"
+	SourceKDE       = "kde"
+)
+
+// Settings is the structured dump of every configured section
+type Settings struct {
+	// Desktop is $XDG_CURRENT_DESKTOP when the backup was made
+	Desktop  string    `json:"desktop,omitempty"`
+	Sections []Section `json:"sections"`
+}
+
+// Section is one dconf path, gsettings schema or KDE rc file. Groups map a
+// dconf subdirectory, gsettings schema or KDE group to its key=value pairs.
+// Values are kept as written: GVariant text for GNOME, raw strings for KDE.
+type Section struct {
+	Source string                       `json:"source"`
+	Name   string                       `json:"name"`
+	Groups map[string]map[string]string `json:"groups"`
+}
+
+// ID identifies the section across backups, e.g. "kde:kwinrc"
+func (s Section) ID() string {
+	return s.Source + ":" + s.Name
+}
+
+// KeyCount returns the number of settings in the section
+func (s Section) KeyCount() int {
+	count := 0
+	for _, keys := range s.Groups {
+		count += len(keys)
+	}
+	return count
+}
+
+// Label describes the section for pickers and plans
+func (s Section) Label() string {
+	return fmt.Sprintf("%s %s (%d keys)", s.Source, s.Name, s.KeyCount())
+}
+
+type DesktopManager struct {
+	outputDir string
+	cfg       *config.Config
+	platform  platform.Platform
+}
+
+func NewDesktopManager(outputDir string) *DesktopManager {
+	return NewDesktopManagerWithConfig(outputDir, nil)
+}
+
+func NewDesktopManagerWithConfig(outputDir string, cfg *config.Config) *DesktopManager {
+	if cfg == nil {
+		cfg, _ = config.Load()
+		if cfg == nil {
+			cfg = config.DefaultConfig()
+		}
+	}
+	return &DesktopManager{
+		outputDir: outputDir,
+		cfg:       cfg,
+		platform:  platform.Current(),
+	}
+}
+
+// ConfiguredSections returns the number of dconf paths, schemas and KDE files to back up
+func (d *DesktopManager) ConfiguredSections() int {
+	return len(d.cfg.GetDesktopDconfPaths()) + len(d.cfg.GetDesktopKDEFiles())
+}
+
+func (d *DesktopManager) BackupAll() error {
+	if !d.cfg.IsDesktopSettingsEnabled() {
+		return nil
+	}
+
+	if err := os.MkdirAll(d.outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	settings := Settings{
+		Desktop:  os.Getenv("XDG_CURRENT_DESKTOP"),
+		Sections: []Section{},
+	}
+
+	for _, path := range d.cfg.GetDesktopDconfPaths() {
+		var section Section
+		var err error
+		if strings.HasPrefix(path, "/") {
+			section, err = readDconf(path)
+		} else {
+			section, err = readGsettings(path)
+		}
+		if err != nil || section.KeyCount() == 0 {
+			continue
+		}
+		settings.Sections = append(settings.Sections, section)
+	}
+
+	for _, name := range d.cfg.GetDesktopKDEFiles() {
+		data, err := os.ReadFile(filepath.Join(d.platform.ConfigHome(), name))
+		if err != nil {
+			continue
+		}
+		section := Section{Source: SourceKDE, Name: name, Groups: parseKeyfile(string(data)).values()}
+		if section.KeyCount() > 0 {
+			settings.Sections = append(settings.Sections, section)
+		}
+	}
+
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal desktop settings: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(d.outputDir, SettingsFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write desktop settings file: %w", err)
+	}
+
+	return nil
+}
+
+// readDconf dumps a dconf directory. Groups are subdirectories relative to
+// path, with "/" for keys directly in it.
+func readDconf(path string) (Section, error) {
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+
+	output, err := exec.Command("dconf", "dump", path).Output()
+	if err != nil {
+		return Section{}, fmt.Errorf("dconf dump %s failed: %w", path, err)
+	}
+	return Section{Source: SourceDconf, Name: path, Groups: parseKeyfile(string(output)).values()}, nil
+}
+
+// readGsettings lists a schema and its child schemas, grouped by schema id
+func readGsettings(schema string) (Section, error) {
+	output, err := exec.Command("gsettings", "list-recursively", schema).Output()
+	if err != nil {
+		return Section{}, fmt.Errorf("gsettings list-recursively %s failed: %w", schema, err)
+	}
+
+	section := Section{Source: SourceGsettings, Name: schema, Groups: make(map[string]map[string]string)}
+	for _, line := range strings.Split(string(output), "\n") {
+		// "schema key value", where the value may contain spaces
+		fields := strings.SplitN(strings.TrimSpace(line), " ", 3)
+		if len(fields) < 3 {
+			continue
+		}
+		if section.Groups[fields[0]] == nil {
+			section.Groups[fields[0]] = make(map[string]string)
+		}
+		section.Groups[fields[0]][fields[1]] = fields[2]
+	}
+	return section, nil
+}
+
+// Load reads a desktop settings file
+func Load(backupFile string) (*Settings, error) {
+	data, err := os.ReadFile(backupFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup file: %w", err)
+	}
+
+	var settings Settings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse backup file: %w", err)
+	}
+	return &settings, nil
+}
+
+// RestoreAll restores the sections whose IDs are listed in only, or every
+// section when only is empty
+func (d *DesktopManager) RestoreAll(backupFile string, only []string) error {
+	settings, err := Load(backupFile)
+	if err != nil {
+		return err
+	}
+
+	wanted := make(map[string]bool)
+	for _, id := range only {
+		wanted[id] = true
+	}
+
+	for _, section := range settings.Sections {
+		if len(wanted) > 0 && !wanted[section.ID()] {
+			continue
+		}
+		if err := d.restoreSection(section); err != nil {
+			fmt.Printf("  ⚠️  Failed to restore %s: %v\n", section.ID(), err)
+			continue
+		}
+		fmt.Printf("  ✓ Restored %s\n", section.ID())
+	}
+
+	fmt.Println("\n⚠️  Note: Some changes only take effect after logging out and back in")
+
+	return nil
+}
+
+func (d *DesktopManager) restoreSection(section Section) error {
+	switch section.Source {
+	case SourceDconf:
+		return restoreDconf(section)
+	case SourceGsettings:
+		return restoreGsettings(section)
+	case SourceKDE:
+		return d.restoreKDE(section)
+	}
+	return fmt.Errorf("unknown settings source %q", section.Source)
+}
+
+// restoreDconf loads the section back with `dconf load`, which only
+// changes the keys it is given
+func restoreDconf(section Section) error {
+	kf := &keyfile{}
+	kf.merge(section.Groups)
+
+	cmd := exec.Command("dconf", "load", section.Name)
+	cmd.Stdin = strings.NewReader(kf.String())
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func restoreGsettings(section Section) error {
+	var failed []string
+	for _, schema := range sortedKeys(section.Groups) {
+		for _, key := range sortedKeys(section.Groups[schema]) {
+			if err := exec.Command("gsettings", "set", schema, key, section.Groups[schema][key]).Run(); err != nil {
+				failed = append(failed, schema+" "+key)
+			}
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to set %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// restoreKDE merges the saved keys into the rc file, leaving keys the
+// backup doesn't mention untouched
+func (d *DesktopManager) restoreKDE(section Section) error {
+	path, ok := KDEFile(d.platform.ConfigHome(), section)
+	if !ok {
+		return fmt.Errorf("invalid rc file name %q", section.Name)
+	}
+
+	merged, err := MergeKDE(path, section)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, merged, 0644)
+}
+
+// KDEFile returns the rc file a KDE section is restored to, inside
+// configDir. It reports false for names that would leave configDir.
+func KDEFile(configDir string, section Section) (string, bool) {
+	name := filepath.FromSlash(section.Name)
+	if !filepath.IsLocal(name) {
+		return "", false
+	}
+	return filepath.Join(configDir, name), true
+}
+
+// MergeKDE returns the rc file at path with the section's keys merged in,
+// as restore would write it
+func MergeKDE(path string, section Section) ([]byte, error) {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	kf := parseKeyfile(string(existing))
+	kf.merge(section.Groups)
+	return []byte(kf.String()), nil
+}
+
+func (d *DesktopManager) GetStats(backupFile string) (int, error) {
+	settings, err := Load(backupFile)
+	if err != nil {
+		return 0, err
+	}
+	return len(settings.Sections), nil
+}
+
+// Change is a setting that differs between two backups
+type Change struct {
+	Section string
+	Group   string
+	Key     string
+	Old     string
+	New     string
+	Kind    byte // '+' added, '-' removed, '~' changed
+}
+
+// String formats the change as "section [group] key: old → new"
+func (c Change) String() string {
+	location := fmt.Sprintf("%s [%s] %s", c.Section, c.Group, c.Key)
+	switch c.Kind {
+	case '+':
+		return fmt.Sprintf("%s = %s", location, c.New)
+	case '-':
+		return fmt.Sprintf("%s (was %s)", location, c.Old)
+	}
+	return fmt.Sprintf("%s: %s → %s", location, c.Old, c.New)
+}
+
+// Diff lists settings added, removed or changed from old to new. Either
+// may be nil for backups without desktop settings.
+func Diff(old, new *Settings) []Change {
+	oldValues := flatten(old)
+	newValues := flatten(new)
+
+	var changes []Change
+	for id, newValue := range newValues {
+		oldValue, ok := oldValues[id]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Section: id.section, Group: id.group, Key: id.key, New: newValue, Kind: '+'})
+		case oldValue != newValue:
+			changes = append(changes, Change{Section: id.section, Group: id.group, Key: id.key, Old: oldValue, New: newValue, Kind: '~'})
+		}
+	}
+	for id, oldValue := range oldValues {
+		if _, ok := newValues[id]; !ok {
+			changes = append(changes, Change{Section: id.section, Group: id.group, Key: id.key, Old: oldValue, Kind: '-'})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.Section != b.Section {
+			return a.Section < b.Section
+		}
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		return a.Key < b.Key
+	})
+	return changes
+}
+
+type settingID struct {
+	section, group, key string
+}
+
+func flatten(settings *Settings) map[settingID]string {
+	values := make(map[settingID]string)
+	if settings == nil {
+		return values
+	}
+	for _, section := range settings.Sections {
+		for group, keys := range section.Groups {
+			for key, value := range keys {
+				values[settingID{section.ID(), group, key}] = value
+			}
+		}
+	}
+	return values
+}
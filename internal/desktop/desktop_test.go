@@ -0,0 +1,142 @@
+package desktop
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/harshpatel5940/stash/internal/config"
+)
+
+// fakeCommand puts a shell script named name in dir
+func fakeCommand(t *testing.T, dir, name, script string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKeyfileMerge(t *testing.T) {
+	existing := `[General]
+Name=Breeze
+ColorScheme=BreezeLight
+
+[Desktops][Layout]
+Rows=1
+`
+	kf := parseKeyfile(existing)
+	kf.merge(map[string]map[string]string{
+		"General":          {"ColorScheme": "BreezeDark"},
+		"Desktops][Layout": {"Columns": "2"},
+		"Windows":          {"BorderlessMaximizedWindows": "true"},
+	})
+
+	want := `[General]
+Name=Breeze
+ColorScheme=BreezeDark
+
+[Desktops][Layout]
+Rows=1
+Columns=2
+
+[Windows]
+BorderlessMaximizedWindows=true
+`
+	if got := kf.String(); got != want {
+		t.Errorf("Merged keyfile:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	bin := t.TempDir()
+	t.Setenv("PATH", bin)
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	loaded := filepath.Join(t.TempDir(), "dconf-load")
+
+	fakeCommand(t, bin, "dconf", `case "$1" in
+dump) printf '[/]\ngtk-theme=%s\n\n[custom]\nname=%s\n' "'Adwaita-dark'" "'Term'" ;;
+load) echo "$2" > `+loaded+`; while read -r line; do echo "$line" >> `+loaded+`; done ;;
+esac`)
+	fakeCommand(t, bin, "gsettings", `echo "org.gnome.desktop.interface clock-format '24h'"`)
+
+	os.WriteFile(filepath.Join(configHome, "kwinrc"), []byte("[Desktops]\nNumber=4\n"), 0644)
+
+	cfg := config.DefaultConfig()
+	cfg.Desktop = &config.DesktopSettingsConfig{
+		Enabled: true,
+		Dconf:   []string{"/org/gnome/desktop/interface", "org.gnome.desktop.interface"},
+		KDE:     []string{"kwinrc", "missingrc"},
+	}
+
+	out := t.TempDir()
+	dm := NewDesktopManagerWithConfig(out, cfg)
+	if err := dm.BackupAll(); err != nil {
+		t.Fatalf("BackupAll failed: %v", err)
+	}
+
+	settings, err := Load(filepath.Join(out, SettingsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, s := range settings.Sections {
+		ids = append(ids, s.ID())
+	}
+	want := []string{"dconf:/org/gnome/desktop/interface/", "gsettings:org.gnome.desktop.interface", "kde:kwinrc"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Fatalf("Unexpected sections: %v", ids)
+	}
+	if got := settings.Sections[0].Groups["/"]["gtk-theme"]; got != "'Adwaita-dark'" {
+		t.Errorf("Unexpected dconf value: %s", got)
+	}
+
+	// Change the local file, then restore only the dconf and KDE sections
+	os.WriteFile(filepath.Join(configHome, "kwinrc"), []byte("[Desktops]\nNumber=2\nRows=1\n"), 0644)
+	if err := dm.RestoreAll(filepath.Join(out, SettingsFile), []string{"kde:kwinrc", "dconf:/org/gnome/desktop/interface/"}); err != nil {
+		t.Fatal(err)
+	}
+
+	kwinrc, _ := os.ReadFile(filepath.Join(configHome, "kwinrc"))
+	if string(kwinrc) != "[Desktops]\nNumber=4\nRows=1\n" {
+		t.Errorf("Unexpected kwinrc after restore:\n%s", kwinrc)
+	}
+
+	dump, _ := os.ReadFile(loaded)
+	if !strings.HasPrefix(string(dump), "/org/gnome/desktop/interface/\n[/]\ngtk-theme='Adwaita-dark'") {
+		t.Errorf("Unexpected dconf load input:\n%s", dump)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := &Settings{Sections: []Section{
+		{Source: SourceKDE, Name: "kwinrc", Groups: map[string]map[string]string{
+			"Desktops": {"Number": "2", "Rows": "1"},
+		}},
+	}}
+	new := &Settings{Sections: []Section{
+		{Source: SourceKDE, Name: "kwinrc", Groups: map[string]map[string]string{
+			"Desktops": {"Number": "4", "Columns": "2"},
+		}},
+	}}
+
+	changes := Diff(old, new)
+	var got []string
+	for _, c := range changes {
+		got = append(got, string(c.Kind)+" "+c.String())
+	}
+	want := []string{
+		"+ kde:kwinrc [Desktops] Columns = 2",
+		"~ kde:kwinrc [Desktops] Number: 2 → 4",
+		"- kde:kwinrc [Desktops] Rows (was 1)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() =\n%s", strings.Join(got, "\n"))
+	}
+
+	if changes := Diff(nil, nil); len(changes) != 0 {
+		t.Errorf("Expected no changes between backups without settings, got %v", changes)
+	}
+}
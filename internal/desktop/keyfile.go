@@ -0,0 +1,138 @@
+package desktop
+
+import (
+	"sort"
+	"strings"
+)
+
+// keyfile is an INI-style file as written by `dconf dump` and KDE's
+// KConfig. Groups and entries keep their order, and lines that aren't
+// key=value pairs are kept as they are, so a file can be edited and written
+// back without losing anything.
+type keyfile struct {
+	groups []*keyfileGroup
+}
+
+type keyfileGroup struct {
+	name    string
+	entries []keyfileEntry
+}
+
+type keyfileEntry struct {
+	key   string
+	value string
+	raw   string // set for comments and blank lines
+}
+
+// parseKeyfile reads INI text. Group names are taken verbatim from between
+// the outer brackets, so KDE's nested "[A][B]" groups become "A][B". Lines
+// before the first group go in a group with an empty name.
+func parseKeyfile(text string) *keyfile {
+	kf := &keyfile{}
+	current := kf.group("")
+
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]"):
+			current = kf.group(trimmed[1 : len(trimmed)-1])
+		case trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";"):
+			current.entries = append(current.entries, keyfileEntry{raw: line})
+		default:
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				current.entries = append(current.entries, keyfileEntry{raw: line})
+				continue
+			}
+			current.set(strings.TrimSpace(key), value)
+		}
+	}
+	return kf
+}
+
+// group returns the named group, adding it at the end if it doesn't exist
+func (kf *keyfile) group(name string) *keyfileGroup {
+	for _, g := range kf.groups {
+		if g.name == name {
+			return g
+		}
+	}
+	g := &keyfileGroup{name: name}
+	kf.groups = append(kf.groups, g)
+	return g
+}
+
+func (g *keyfileGroup) set(key, value string) {
+	for i, e := range g.entries {
+		if e.raw == "" && e.key == key {
+			g.entries[i].value = value
+			return
+		}
+	}
+	// Keep a trailing blank line after the new key, as KConfig writes them
+	n := len(g.entries)
+	for n > 0 && strings.TrimSpace(g.entries[n-1].raw) == "" && g.entries[n-1].key == "" {
+		n--
+	}
+	g.entries = append(g.entries[:n], append([]keyfileEntry{{key: key, value: value}}, g.entries[n:]...)...)
+}
+
+// values returns the key=value pairs by group, leaving out empty groups
+func (kf *keyfile) values() map[string]map[string]string {
+	values := make(map[string]map[string]string)
+	for _, g := range kf.groups {
+		for _, e := range g.entries {
+			if e.raw != "" || e.key == "" {
+				continue
+			}
+			if values[g.name] == nil {
+				values[g.name] = make(map[string]string)
+			}
+			values[g.name][e.key] = e.value
+		}
+	}
+	return values
+}
+
+// merge sets every value in groups, adding groups and keys that are missing
+func (kf *keyfile) merge(groups map[string]map[string]string) {
+	for _, name := range sortedKeys(groups) {
+		g := kf.group(name)
+		for _, key := range sortedKeys(groups[name]) {
+			g.set(key, groups[name][key])
+		}
+	}
+}
+
+func (kf *keyfile) String() string {
+	var b strings.Builder
+	for i, g := range kf.groups {
+		if g.name == "" && len(g.entries) == 0 {
+			continue
+		}
+		if g.name != "" {
+			// Separate groups with a blank line unless the previous one ended with one
+			if i > 0 && b.Len() > 0 && !strings.HasSuffix(b.String(), "\n\n") {
+				b.WriteString("\n")
+			}
+			b.WriteString("[" + g.name + "]\n")
+		}
+		for _, e := range g.entries {
+			if e.key == "" {
+				b.WriteString(e.raw + "\n")
+			} else {
+				b.WriteString(e.key + "=" + e.value + "\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -1,17 +1,27 @@
 // Package diff provides backup comparison functionality.
 // It compares two backups and reports added, removed, and modified files,
-// as well as changes to package manager counts and overall size changes.
+// as well as changes to package manager counts and versions, Linux desktop
+// settings and overall size changes.
 //
 // Use this package to understand what changed between two points in time.
 package diff
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"sort"
 
 	"github.com/harshpatel5940/stash/internal/backuputil"
+	"github.com/harshpatel5940/stash/internal/desktop"
 	"github.com/harshpatel5940/stash/internal/metadata"
+	"github.com/harshpatel5940/stash/internal/packager"
+)
+
+// Paths of the structured files compared besides metadata
+const (
+	desktopSettingsPath = "desktop-settings/" + desktop.SettingsFile
+	packageLockPath     = "packages/" + packager.LockFile
 )
 
 // FileChange represents a change to a file between backups
@@ -38,6 +48,11 @@ type BackupDiff struct {
 	RemovedSize    int64
 	ModifiedSize   int64
 	PackageChanges map[string]PackageChange
+	// SettingsChanges lists desktop settings that differ, sorted by section
+	SettingsChanges []desktop.Change
+	// VersionChanges lists packages whose version differs, from the
+	// backups' package locks
+	VersionChanges []packager.VersionChange
 }
 
 // PackageChange represents changes in a package manager
@@ -61,15 +76,17 @@ func Compare(oldBackupPath, newBackupPath string) (*BackupDiff, error) {
 // CompareWithOptions compares two backups with custom options
 func CompareWithOptions(oldBackupPath, newBackupPath string, opts CompareOptions) (*BackupDiff, error) {
 	// Load metadata from both backups
-	oldMeta, err := loadBackupMetadata(oldBackupPath, opts.KeyPath)
+	oldBackup, err := loadBackup(oldBackupPath, opts.KeyPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load old backup metadata: %w", err)
 	}
+	oldMeta := oldBackup.meta
 
-	newMeta, err := loadBackupMetadata(newBackupPath, opts.KeyPath)
+	newBackup, err := loadBackup(newBackupPath, opts.KeyPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load new backup metadata: %w", err)
 	}
+	newMeta := newBackup.meta
 
 	// Get backup sizes
 	oldStat, _ := os.Stat(oldBackupPath)
@@ -95,16 +112,19 @@ func CompareWithOptions(oldBackupPath, newBackupPath string, opts CompareOptions
 		PackageChanges: make(map[string]PackageChange),
 	}
 
-	// Create maps for quick lookup
+	// Create maps for quick lookup, keyed by home-relative path so backups
+	// with absolute and ~/ paths (or from different machines) line up
 	oldFiles := make(map[string]metadata.FileInfo)
 	newFiles := make(map[string]metadata.FileInfo)
 
+	oldHome := oldMeta.SourceHome()
 	for _, file := range oldMeta.Files {
-		oldFiles[file.OriginalPath] = file
+		oldFiles[metadata.ContractHome(file.OriginalPath, oldHome)] = file
 	}
 
+	newHome := newMeta.SourceHome()
 	for _, file := range newMeta.Files {
-		newFiles[file.OriginalPath] = file
+		newFiles[metadata.ContractHome(file.OriginalPath, newHome)] = file
 	}
 
 	// Find added and modified files
@@ -168,6 +188,13 @@ func CompareWithOptions(oldBackupPath, newBackupPath string, opts CompareOptions
 		}
 	}
 
+	diff.SettingsChanges = desktop.Diff(oldBackup.settings, newBackup.settings)
+
+	// Backups from before package locks only show count changes
+	if oldBackup.lock != nil && newBackup.lock != nil {
+		diff.VersionChanges = packager.DiffLocks(oldBackup.lock, newBackup.lock)
+	}
+
 	// Sort results for consistent output
 	sort.Slice(diff.AddedFiles, func(i, j int) bool {
 		return diff.AddedFiles[i].OriginalPath < diff.AddedFiles[j].OriginalPath
@@ -184,16 +211,48 @@ func CompareWithOptions(oldBackupPath, newBackupPath string, opts CompareOptions
 	return diff, nil
 }
 
-// loadBackupMetadata loads metadata from a backup
-func loadBackupMetadata(backupPath string, keyPath string) (*metadata.Metadata, error) {
+// loadedBackup is what a comparison reads from a backup. Settings and lock
+// are nil when the backup has none.
+type loadedBackup struct {
+	meta     *metadata.Metadata
+	settings *desktop.Settings
+	lock     *packager.Lock
+}
+
+// loadBackup loads metadata, desktop settings and the package lock from a backup
+func loadBackup(backupPath string, keyPath string) (*loadedBackup, error) {
 	// First, try to find a sidecar metadata file (for backwards compatibility)
 	metadataPath := backupPath + ".metadata.json"
 	if _, err := os.Stat(metadataPath); err == nil {
-		return metadata.Load(metadataPath)
+		meta, err := metadata.Load(metadataPath)
+		return &loadedBackup{meta: meta}, err
+	}
+
+	// Extract from the backup archive (handles both encrypted and unencrypted)
+	files, err := backuputil.ExtractFiles(backupPath, keyPath, "metadata.json", desktopSettingsPath, packageLockPath)
+	if err != nil {
+		return nil, err
 	}
 
-	// Extract metadata from the backup archive (handles both encrypted and unencrypted)
-	return backuputil.ExtractMetadata(backupPath, keyPath)
+	data, ok := files["metadata.json"]
+	if !ok {
+		return nil, fmt.Errorf("metadata.json not found in backup archive")
+	}
+	backup := &loadedBackup{meta: &metadata.Metadata{}}
+	if err := json.Unmarshal(data, backup.meta); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata: %w", err)
+	}
+
+	if data, ok := files[desktopSettingsPath]; ok {
+		backup.settings = &desktop.Settings{}
+		if err := json.Unmarshal(data, backup.settings); err != nil {
+			backup.settings = nil
+		}
+	}
+	if data, ok := files[packageLockPath]; ok {
+		backup.lock, _ = packager.ParseLock(data)
+	}
+	return backup, nil
 }
 
 // GetAddedFilesCount returns the number of added files (excluding directories)
@@ -225,7 +284,8 @@ func (d *BackupDiff) GetModifiedFilesCount() int {
 
 // HasChanges returns true if there are any changes between the backups
 func (d *BackupDiff) HasChanges() bool {
-	return len(d.AddedFiles) > 0 || len(d.RemovedFiles) > 0 || len(d.ModifiedFiles) > 0 || len(d.PackageChanges) > 0
+	return len(d.AddedFiles) > 0 || len(d.RemovedFiles) > 0 || len(d.ModifiedFiles) > 0 || len(d.PackageChanges) > 0 ||
+		len(d.SettingsChanges) > 0 || len(d.VersionChanges) > 0
 }
 
 // GetTotalFileChanges returns the total number of file changes
@@ -299,6 +359,14 @@ func (d *BackupDiff) Summary() string {
 		summary += fmt.Sprintf("Package changes: %d package managers affected\n", len(d.PackageChanges))
 	}
 
+	if len(d.VersionChanges) > 0 {
+		summary += fmt.Sprintf("Package version changes: %d\n", len(d.VersionChanges))
+	}
+
+	if len(d.SettingsChanges) > 0 {
+		summary += fmt.Sprintf("Desktop settings changes: %d\n", len(d.SettingsChanges))
+	}
+
 	return summary
 }
 
@@ -5,7 +5,9 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/harshpatel5940/stash/internal/desktop"
 	"github.com/harshpatel5940/stash/internal/metadata"
+	"github.com/harshpatel5940/stash/internal/packager"
 )
 
 func TestFileChange(t *testing.T) {
@@ -122,6 +124,22 @@ func TestBackupDiffHasChanges(t *testing.T) {
 	if !diff4.HasChanges() {
 		t.Error("Diff with package changes should have changes")
 	}
+
+	// With desktop settings changes
+	diff5 := &BackupDiff{
+		SettingsChanges: []desktop.Change{{Section: "kde:kwinrc", Group: "Desktops", Key: "Number", Old: "2", New: "4", Kind: '~'}},
+	}
+	if !diff5.HasChanges() {
+		t.Error("Diff with desktop settings changes should have changes")
+	}
+
+	// With package version changes
+	diff6 := &BackupDiff{
+		VersionChanges: []packager.VersionChange{{Manager: "cargo", Name: "ripgrep", Old: "13.0.0", New: "14.1.0"}},
+	}
+	if !diff6.HasChanges() {
+		t.Error("Diff with package version changes should have changes")
+	}
 }
 
 func TestBackupDiffGetTotalFileChanges(t *testing.T) {
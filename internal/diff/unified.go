@@ -0,0 +1,239 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// maxLineDiffCells bounds the LCS table so huge files don't exhaust memory
+const maxLineDiffCells = 4_000_000
+
+// LineOp is a single line in a line-level diff
+type LineOp struct {
+	Kind byte // ' ' unchanged, '-' only in old, '+' only in new
+	Text string
+}
+
+// IsText reports whether data looks like text (no NUL bytes in the first 8 KB)
+func IsText(data []byte) bool {
+	sample := data
+	if len(sample) > 8000 {
+		sample = sample[:8000]
+	}
+	return bytes.IndexByte(sample, 0) == -1
+}
+
+// SplitLines splits content into lines without trailing newlines
+func SplitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	text := strings.TrimSuffix(string(data), "\n")
+	return strings.Split(text, "\n")
+}
+
+// DiffLines returns the line operations that turn oldLines into newLines
+func DiffLines(oldLines, newLines []string) []LineOp {
+	// Trim common prefix and suffix to keep the LCS table small
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	var ops []LineOp
+	for _, line := range oldLines[:prefix] {
+		ops = append(ops, LineOp{Kind: ' ', Text: line})
+	}
+
+	a := oldLines[prefix : len(oldLines)-suffix]
+	b := newLines[prefix : len(newLines)-suffix]
+	ops = append(ops, lcsDiff(a, b)...)
+
+	for _, line := range oldLines[len(oldLines)-suffix:] {
+		ops = append(ops, LineOp{Kind: ' ', Text: line})
+	}
+
+	return ops
+}
+
+// lcsDiff diffs two line slices using a longest-common-subsequence table
+func lcsDiff(a, b []string) []LineOp {
+	var ops []LineOp
+
+	if len(a)*len(b) > maxLineDiffCells {
+		for _, line := range a {
+			ops = append(ops, LineOp{Kind: '-', Text: line})
+		}
+		for _, line := range b {
+			ops = append(ops, LineOp{Kind: '+', Text: line})
+		}
+		return ops
+	}
+
+	// lcs[i][j] = length of LCS of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, LineOp{Kind: ' ', Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, LineOp{Kind: '-', Text: a[i]})
+			i++
+		default:
+			ops = append(ops, LineOp{Kind: '+', Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, LineOp{Kind: '-', Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, LineOp{Kind: '+', Text: b[j]})
+	}
+
+	return ops
+}
+
+// CountChanges returns how many lines were added and removed
+func CountChanges(ops []LineOp) (added, removed int) {
+	for _, op := range ops {
+		switch op.Kind {
+		case '+':
+			added++
+		case '-':
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// Unified renders a unified diff between two contents with the given context lines
+func Unified(oldName, newName string, oldData, newData []byte, context int) string {
+	ops := DiffLines(SplitLines(oldData), SplitLines(newData))
+
+	added, removed := CountChanges(ops)
+	if added == 0 && removed == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+
+	// Find change indices and group them into hunks
+	var changes []int
+	for idx, op := range ops {
+		if op.Kind != ' ' {
+			changes = append(changes, idx)
+		}
+	}
+
+	for start := 0; start < len(changes); {
+		end := start
+		for end+1 < len(changes) && changes[end+1]-changes[end] <= 2*context+1 {
+			end++
+		}
+
+		from := changes[start] - context
+		if from < 0 {
+			from = 0
+		}
+		to := changes[end] + context + 1
+		if to > len(ops) {
+			to = len(ops)
+		}
+
+		oldStart, newStart := 1, 1
+		for _, op := range ops[:from] {
+			if op.Kind != '+' {
+				oldStart++
+			}
+			if op.Kind != '-' {
+				newStart++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, op := range ops[from:to] {
+			if op.Kind != '+' {
+				oldCount++
+			}
+			if op.Kind != '-' {
+				newCount++
+			}
+		}
+
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, op := range ops[from:to] {
+			out.WriteByte(op.Kind)
+			out.WriteString(op.Text)
+			out.WriteByte('\n')
+		}
+
+		start = end + 1
+	}
+
+	return out.String()
+}
+
+// Merge combines two versions of a text file. Lines both sides agree on are
+// kept; every differing region is wrapped in git-style conflict markers.
+func Merge(localData, otherData []byte, localLabel, otherLabel string) []byte {
+	ops := DiffLines(SplitLines(localData), SplitLines(otherData))
+
+	var out strings.Builder
+	var local, other []string
+
+	flush := func() {
+		if len(local) == 0 && len(other) == 0 {
+			return
+		}
+		out.WriteString("<<<<<<< " + localLabel + "\n")
+		for _, line := range local {
+			out.WriteString(line + "\n")
+		}
+		out.WriteString("=======\n")
+		for _, line := range other {
+			out.WriteString(line + "\n")
+		}
+		out.WriteString(">>>>>>> " + otherLabel + "\n")
+		local, other = nil, nil
+	}
+
+	for _, op := range ops {
+		switch op.Kind {
+		case '-':
+			local = append(local, op.Text)
+		case '+':
+			other = append(other, op.Text)
+		default:
+			flush()
+			out.WriteString(op.Text + "\n")
+		}
+	}
+	flush()
+
+	return []byte(out.String())
+}
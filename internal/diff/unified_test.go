@@ -0,0 +1,88 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	oldData := []byte("a\nb\nc\nd\n")
+	newData := []byte("a\nB\nc\nd\ne\n")
+
+	out := Unified("local", "backup", oldData, newData, 1)
+
+	expected := `--- local
++++ backup
+@@ -1,4 +1,5 @@
+ a
+-b
++B
+ c
+ d
++e
+`
+	if out != expected {
+		t.Errorf("Unexpected diff:\n%s\nwant:\n%s", out, expected)
+	}
+}
+
+func TestUnifiedIdentical(t *testing.T) {
+	if out := Unified("a", "b", []byte("same\n"), []byte("same\n"), 3); out != "" {
+		t.Errorf("Expected empty diff, got %q", out)
+	}
+}
+
+func TestUnifiedSeparateHunks(t *testing.T) {
+	var oldLines, newLines []string
+	for i := 0; i < 20; i++ {
+		line := string(rune('a' + i))
+		oldLines = append(oldLines, line)
+		newLines = append(newLines, line)
+	}
+	newLines[1] = "X"
+	newLines[18] = "Y"
+
+	out := Unified("old", "new",
+		[]byte(strings.Join(oldLines, "\n")+"\n"),
+		[]byte(strings.Join(newLines, "\n")+"\n"), 2)
+
+	if strings.Count(out, "@@ ") != 2 {
+		t.Errorf("Expected 2 hunks, got:\n%s", out)
+	}
+}
+
+func TestCountChanges(t *testing.T) {
+	ops := DiffLines([]string{"a", "b"}, []string{"a", "c", "d"})
+	added, removed := CountChanges(ops)
+	if added != 2 || removed != 1 {
+		t.Errorf("Expected +2 -1, got +%d -%d", added, removed)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	local := []byte("export A=1\nexport B=local\n")
+	backup := []byte("export A=1\nexport B=backup\nexport C=3\n")
+
+	merged := string(Merge(local, backup, "local", "backup"))
+
+	expected := `export A=1
+<<<<<<< local
+export B=local
+=======
+export B=backup
+export C=3
+>>>>>>> backup
+`
+	if merged != expected {
+		t.Errorf("Unexpected merge:\n%s\nwant:\n%s", merged, expected)
+	}
+}
+
+func TestIsText(t *testing.T) {
+	if !IsText([]byte("hello\n")) {
+		t.Error("Expected text")
+	}
+	if IsText([]byte{0x7f, 'E', 'L', 'F', 0x00, 0x01}) {
+		t.Error("Expected binary")
+	}
+}
@@ -0,0 +1,316 @@
+// Package editors backs up editor and IDE user settings: settings,
+// keybindings, snippets and profiles for VS Code, VS Code Insiders and
+// Cursor, Zed's settings and keymap, and the options, keymaps and code
+// styles of JetBrains IDEs. Each editor is restored into wherever it keeps
+// its settings on the restoring machine, so a macOS backup restores on
+// Linux too.
+package editors
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/harshpatel5940/stash/internal/archiver"
+	"github.com/harshpatel5940/stash/internal/platform"
+)
+
+// ManifestFile lists the editors in a backup and the paths saved for each
+const ManifestFile = "editors.json"
+
+// Editor is an editor whose settings stash knows how to find
+type Editor struct {
+	Name  string
+	Label string
+	// Dir is where the editor keeps user settings on this platform
+	Dir string
+	// Paths are files and directories under Dir; they may be globs
+	Paths []string
+}
+
+// Record is one editor in a backup
+type Record struct {
+	Name  string   `json:"name"`
+	Label string   `json:"label"`
+	Paths []string `json:"paths"`
+	// Profiles is VS Code's list of profiles, which it keeps in
+	// globalStorage/storage.json rather than in the profiles directory
+	Profiles json.RawMessage `json:"profiles,omitempty"`
+}
+
+// vscodePaths are the user files of VS Code and editors built from it
+var vscodePaths = []string{"settings.json", "keybindings.json", "tasks.json", "mcp.json", "snippets", "profiles"}
+
+// jetbrainsPaths are the settings of each JetBrains IDE version directory
+var jetbrainsPaths = []string{
+	"*/options", "*/keymaps", "*/codestyles", "*/colors", "*/templates",
+	"*/fileTemplates", "*/inspection", "*/tools", "*/idea.vmoptions",
+}
+
+// skipNames are caches and machine state inside settings directories
+var skipNames = map[string]bool{
+	"globalStorage":       true,
+	"workspaceStorage":    true,
+	"History":             true,
+	"recentProjects.xml":  true,
+	"recentSolutions.xml": true,
+	"window.state.xml":    true,
+	"jdk.table.xml":       true,
+	"updates.xml":         true,
+}
+
+// Editors returns the editors stash knows about on this platform
+func Editors() []Editor {
+	return editorsFor(platform.Current())
+}
+
+func editorsFor(p platform.Platform) []Editor {
+	appSupport := p.AppSupportDir()
+	return []Editor{
+		{Name: "vscode", Label: "VS Code", Dir: filepath.Join(appSupport, "Code", "User"), Paths: vscodePaths},
+		{Name: "vscode-insiders", Label: "VS Code Insiders", Dir: filepath.Join(appSupport, "Code - Insiders", "User"), Paths: vscodePaths},
+		{Name: "cursor", Label: "Cursor", Dir: filepath.Join(appSupport, "Cursor", "User"), Paths: vscodePaths},
+		// Zed uses ~/.config/zed on macOS as well
+		{Name: "zed", Label: "Zed", Dir: filepath.Join(p.ConfigHome(), "zed"), Paths: []string{"settings.json", "keymap.json", "tasks.json", "snippets", "themes"}},
+		{Name: "jetbrains", Label: "JetBrains IDEs", Dir: filepath.Join(appSupport, "JetBrains"), Paths: jetbrainsPaths},
+	}
+}
+
+// Lookup returns the editor with the given name on this platform
+func Lookup(name string) (Editor, bool) {
+	for _, editor := range Editors() {
+		if editor.Name == name {
+			return editor, true
+		}
+	}
+	return Editor{}, false
+}
+
+// EditorsManager copies editor settings in and out of a backup directory
+type EditorsManager struct {
+	outputDir string
+	editors   []Editor
+}
+
+func NewEditorsManager(outputDir string) *EditorsManager {
+	return &EditorsManager{outputDir: outputDir, editors: Editors()}
+}
+
+// BackupAll copies the settings of every editor found into a directory per
+// editor and writes the manifest. It returns what was saved.
+func (em *EditorsManager) BackupAll() ([]Record, error) {
+	arch := archiver.NewArchiver()
+	var records []Record
+	for _, editor := range em.editors {
+		paths := editor.existingPaths()
+		if len(paths) == 0 {
+			continue
+		}
+
+		editorDir := filepath.Join(em.outputDir, editor.Name)
+		var saved []string
+		for _, path := range paths {
+			if err := copyPath(arch, filepath.Join(editor.Dir, path), filepath.Join(editorDir, path)); err != nil {
+				continue
+			}
+			saved = append(saved, filepath.ToSlash(path))
+		}
+		if len(saved) == 0 {
+			continue
+		}
+
+		record := Record{Name: editor.Name, Label: editor.Label, Paths: saved}
+		record.Profiles = readProfiles(editor.Dir)
+		records = append(records, record)
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no editor settings found")
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(em.outputDir, ManifestFile), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write editors manifest: %w", err)
+	}
+	return records, nil
+}
+
+// existingPaths expands the editor's paths to those present, relative to Dir
+func (e Editor) existingPaths() []string {
+	var paths []string
+	for _, pattern := range e.Paths {
+		matches, _ := filepath.Glob(filepath.Join(e.Dir, pattern))
+		for _, match := range matches {
+			if rel, err := filepath.Rel(e.Dir, match); err == nil {
+				paths = append(paths, rel)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Load reads the manifest in a backup's editor settings directory
+func Load(manifestPath string) ([]Record, error) {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	var records []Record
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse editors manifest: %w", err)
+	}
+	return records, nil
+}
+
+// Target is a saved path in a backup and where Restore writes it
+type Target struct {
+	Source string
+	Dest   string
+}
+
+// Targets returns where Restore writes each of the record's saved paths
+// from backupDir, the directory holding the manifest, into destDir
+func Targets(backupDir string, record Record, destDir string) []Target {
+	var targets []Target
+	for _, path := range record.Paths {
+		rel := filepath.FromSlash(path)
+		// The manifest comes from the backup, so keep it inside both directories
+		if !filepath.IsLocal(rel) {
+			continue
+		}
+		targets = append(targets, Target{
+			Source: filepath.Join(backupDir, record.Name, rel),
+			Dest:   filepath.Join(destDir, rel),
+		})
+	}
+	return targets
+}
+
+// Restore copies one editor's saved settings from backupDir, the directory
+// holding the manifest, into destDir, replacing files of the same name
+// unless skip, if set, returns true for them. It returns how many paths
+// were restored.
+func Restore(backupDir string, record Record, destDir string, skip func(destPath string) bool) (int, error) {
+	arch := archiver.NewArchiver()
+	restored := 0
+	for _, target := range Targets(backupDir, record, destDir) {
+		if skip != nil && skip(target.Dest) {
+			continue
+		}
+		if err := copyPathExcept(arch, target.Source, target.Dest, skip); err != nil {
+			rel, _ := filepath.Rel(destDir, target.Dest)
+			return restored, fmt.Errorf("failed to restore %s: %w", filepath.ToSlash(rel), err)
+		}
+		restored++
+	}
+
+	if len(record.Profiles) > 0 {
+		if err := mergeProfiles(destDir, record.Profiles); err != nil {
+			return restored, err
+		}
+	}
+	return restored, nil
+}
+
+// Excluded reports whether a path relative to a saved directory is a cache
+// or machine state, which is neither backed up nor restored
+func Excluded(rel string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+		if skipNames[part] {
+			return true
+		}
+	}
+	return false
+}
+
+// copyPath copies a file or directory, leaving out caches and machine state
+func copyPath(arch *archiver.Archiver, src, dest string) error {
+	return copyPathExcept(arch, src, dest, nil)
+}
+
+// copyPathExcept is copyPath that also leaves alone destination files for
+// which skip returns true
+func copyPathExcept(arch *archiver.Archiver, src, dest string, skip func(string) bool) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return arch.CopyFile(src, dest)
+	}
+	return arch.CopyDirExcept(src, dest, func(destPath string) bool {
+		rel, _ := filepath.Rel(dest, destPath)
+		return Excluded(rel) || (skip != nil && skip(destPath))
+	})
+}
+
+// StoragePath is where VS Code-based editors in dir keep global state,
+// including the list of profiles Restore merges into
+func StoragePath(dir string) string {
+	return filepath.Join(dir, "globalStorage", "storage.json")
+}
+
+// readProfiles returns the profile list from an editor's global storage
+func readProfiles(dir string) json.RawMessage {
+	data, err := os.ReadFile(StoragePath(dir))
+	if err != nil {
+		return nil
+	}
+	var storage map[string]json.RawMessage
+	if json.Unmarshal(data, &storage) != nil {
+		return nil
+	}
+	return storage["userDataProfiles"]
+}
+
+// mergeProfiles adds the backup's profiles to the editor's global storage,
+// keeping its other state and any profiles it already has
+func mergeProfiles(dir string, profiles json.RawMessage) error {
+	var saved []map[string]any
+	if err := json.Unmarshal(profiles, &saved); err != nil {
+		return fmt.Errorf("failed to parse profiles: %w", err)
+	}
+
+	storage := make(map[string]json.RawMessage)
+	if data, err := os.ReadFile(StoragePath(dir)); err == nil {
+		if err := json.Unmarshal(data, &storage); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", StoragePath(dir), err)
+		}
+	}
+
+	var current []map[string]any
+	if existing, ok := storage["userDataProfiles"]; ok {
+		_ = json.Unmarshal(existing, &current)
+	}
+	// Profiles are identified by their directory under profiles/
+	known := make(map[string]bool)
+	for _, profile := range current {
+		known[fmt.Sprint(profile["location"])] = true
+	}
+	for _, profile := range saved {
+		if !known[fmt.Sprint(profile["location"])] {
+			current = append(current, profile)
+		}
+	}
+
+	merged, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	storage["userDataProfiles"] = merged
+	data, err := json.MarshalIndent(storage, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(StoragePath(dir)), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(StoragePath(dir), data, 0644)
+}
@@ -0,0 +1,132 @@
+package editors
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/harshpatel5940/stash/internal/platform"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEditorsFor(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "")
+	want := map[string]map[string]string{
+		platform.MacOS: {
+			"vscode":    "/Users/me/Library/Application Support/Code/User",
+			"cursor":    "/Users/me/Library/Application Support/Cursor/User",
+			"zed":       "/Users/me/.config/zed",
+			"jetbrains": "/Users/me/Library/Application Support/JetBrains",
+		},
+		platform.Linux: {
+			"vscode":    "/home/me/.config/Code/User",
+			"cursor":    "/home/me/.config/Cursor/User",
+			"zed":       "/home/me/.config/zed",
+			"jetbrains": "/home/me/.config/JetBrains",
+		},
+	}
+	homes := map[string]string{platform.MacOS: "/Users/me", platform.Linux: "/home/me"}
+
+	for goos, dirs := range want {
+		for _, editor := range editorsFor(platform.New(goos, homes[goos])) {
+			if dir, ok := dirs[editor.Name]; ok && editor.Dir != dir {
+				t.Errorf("%s %s: Dir = %q, want %q", goos, editor.Name, editor.Dir, dir)
+			}
+		}
+	}
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "")
+	home := t.TempDir()
+	mac := platform.New(platform.MacOS, home)
+	code := filepath.Join(home, "Library/Application Support/Code/User")
+	jetbrains := filepath.Join(home, "Library/Application Support/JetBrains")
+
+	writeFile(t, filepath.Join(code, "settings.json"), `{"editor.fontSize": 14}`)
+	writeFile(t, filepath.Join(code, "snippets/go.json"), `{}`)
+	writeFile(t, filepath.Join(code, "profiles/-4f2a/settings.json"), `{"workbench.colorTheme": "Solarized Light"}`)
+	writeFile(t, filepath.Join(code, "profiles/-4f2a/globalStorage/state.vscdb"), "cache")
+	writeFile(t, filepath.Join(code, "workspaceStorage/abc/state.vscdb"), "cache")
+	writeFile(t, filepath.Join(code, "globalStorage/storage.json"),
+		`{"windowsState": {"lastActiveWindow": {}}, "userDataProfiles": [{"location": "-4f2a", "name": "Writing"}]}`)
+	writeFile(t, filepath.Join(home, ".config/zed/keymap.json"), `[]`)
+	writeFile(t, filepath.Join(jetbrains, "GoLand2024.3/options/editor.xml"), "<application/>")
+	writeFile(t, filepath.Join(jetbrains, "GoLand2024.3/options/recentProjects.xml"), "<application/>")
+	writeFile(t, filepath.Join(jetbrains, "GoLand2024.3/keymaps/Mine.xml"), "<keymap/>")
+	writeFile(t, filepath.Join(jetbrains, "GoLand2024.3/plugins/big.jar"), "jar")
+
+	backupDir := t.TempDir()
+	em := &EditorsManager{outputDir: backupDir, editors: editorsFor(mac)}
+	records, err := em.BackupAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved := make(map[string][]string)
+	for _, record := range records {
+		saved[record.Name] = record.Paths
+	}
+	want := map[string][]string{
+		"vscode":    {"profiles", "settings.json", "snippets"},
+		"zed":       {"keymap.json"},
+		"jetbrains": {"GoLand2024.3/keymaps", "GoLand2024.3/options"},
+	}
+	if !reflect.DeepEqual(saved, want) {
+		t.Errorf("Unexpected saved paths: %v", saved)
+	}
+
+	loaded, err := Load(filepath.Join(backupDir, ManifestFile))
+	if err != nil || len(loaded) != 3 {
+		t.Fatalf("Load() = %v, %v", loaded, err)
+	}
+
+	// Restore VS Code on Linux, which already has a profile of its own
+	linuxCode := filepath.Join(t.TempDir(), ".config/Code/User")
+	writeFile(t, filepath.Join(linuxCode, "globalStorage/storage.json"),
+		`{"theme": "vs-dark", "userDataProfiles": [{"location": "7c1e", "name": "Work"}]}`)
+
+	count, err := Restore(backupDir, loaded[0], linuxCode, nil)
+	if err != nil || count != 3 {
+		t.Fatalf("Restore() = %d, %v", count, err)
+	}
+	for _, path := range []string{"settings.json", "snippets/go.json", "profiles/-4f2a/settings.json"} {
+		if _, err := os.Stat(filepath.Join(linuxCode, path)); err != nil {
+			t.Errorf("Expected %s to be restored", path)
+		}
+	}
+	for _, path := range []string{"profiles/-4f2a/globalStorage/state.vscdb", "workspaceStorage"} {
+		if _, err := os.Stat(filepath.Join(linuxCode, path)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be left out", path)
+		}
+	}
+
+	data, _ := os.ReadFile(filepath.Join(linuxCode, "globalStorage/storage.json"))
+	var storage struct {
+		Theme    string              `json:"theme"`
+		Profiles []map[string]string `json:"userDataProfiles"`
+	}
+	if err := json.Unmarshal(data, &storage); err != nil {
+		t.Fatal(err)
+	}
+	wantProfiles := []map[string]string{{"location": "7c1e", "name": "Work"}, {"location": "-4f2a", "name": "Writing"}}
+	if storage.Theme != "vs-dark" || !reflect.DeepEqual(storage.Profiles, wantProfiles) {
+		t.Errorf("Unexpected storage.json: %s", data)
+	}
+
+	// Machine state inside JetBrains options stays behind
+	if _, err := os.Stat(filepath.Join(backupDir, "jetbrains/GoLand2024.3/options/recentProjects.xml")); !os.IsNotExist(err) {
+		t.Error("Expected recentProjects.xml to be left out")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/harshpatel5940/stash/internal/config"
+	"github.com/harshpatel5940/stash/internal/gittracker"
 )
 
 type DotfilesFinder struct {
@@ -114,6 +115,11 @@ func (df *DotfilesFinder) Find(additional []string) ([]string, error) {
 			continue
 		}
 
+		// Machine-specific git scan cache left behind by older versions
+		if name == gittracker.LegacyScanCacheName {
+			continue
+		}
+
 		path := filepath.Join(df.homeDir, name)
 
 		if entry.Type().IsRegular() {
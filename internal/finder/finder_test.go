@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/harshpatel5940/stash/internal/gittracker"
 )
 
 func TestNewDotfilesFinder(t *testing.T) {
@@ -366,3 +368,32 @@ func TestNonexistentSearchPath(t *testing.T) {
 		t.Error("Should return empty results for nonexistent path")
 	}
 }
+
+func TestFindDotfilesSkipsGitScanCache(t *testing.T) {
+	tempHome := t.TempDir()
+	t.Setenv("HOME", tempHome)
+	t.Setenv("XDG_CACHE_HOME", "")
+
+	cachePath := gittracker.DefaultScanCachePath()
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(cachePath, []byte("{}"), 0600)
+	os.WriteFile(filepath.Join(tempHome, gittracker.LegacyScanCacheName), []byte("{}"), 0600)
+	os.WriteFile(filepath.Join(tempHome, ".zshrc"), []byte("test"), 0644)
+
+	finder, err := NewDotfilesFinder()
+	if err != nil {
+		t.Fatalf("Failed to create finder: %v", err)
+	}
+	dotfiles, err := finder.Find(nil)
+	if err != nil {
+		t.Fatalf("Failed to find dotfiles: %v", err)
+	}
+
+	for _, f := range dotfiles {
+		if f == cachePath || filepath.Base(f) == gittracker.LegacyScanCacheName {
+			t.Errorf("Expected the git scan cache to be skipped, found %s", f)
+		}
+	}
+}
@@ -3,24 +3,32 @@ package fonts
 import (
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 
+	"github.com/harshpatel5940/stash/internal/platform"
 	"github.com/harshpatel5940/stash/internal/security"
 )
 
 type FontsManager struct {
 	outputDir string
+	platform  platform.Platform
 }
 
 func NewFontsManager(outputDir string) *FontsManager {
 	return &FontsManager{
 		outputDir: outputDir,
+		platform:  platform.Current(),
 	}
 }
 
+// FontsDir returns the per-user font directory on this platform
+func (fm *FontsManager) FontsDir() string {
+	return fm.platform.FontsDir()
+}
+
 func (fm *FontsManager) BackupAll() (int, error) {
-	homeDir, _ := os.UserHomeDir()
-	fontsDir := filepath.Join(homeDir, "Library", "Fonts")
+	fontsDir := fm.FontsDir()
 
 	if _, err := os.Stat(fontsDir); os.IsNotExist(err) {
 		return 0, fmt.Errorf("fonts directory not found")
@@ -68,7 +76,14 @@ func (fm *FontsManager) BackupAll() (int, error) {
 	}
 
 	readmePath := filepath.Join(fm.outputDir, "README.txt")
-	readme := `Custom Fonts Backup
+	os.WriteFile(readmePath, []byte(fm.readme()), 0644)
+
+	return count, nil
+}
+
+func (fm *FontsManager) readme() string {
+	if fm.platform.IsMacOS() {
+		return `Custom Fonts Backup
 
 This directory contains your custom fonts from ~/Library/Fonts
 
@@ -83,14 +98,27 @@ Font formats supported:
 - .ttc (TrueType Collection)
 - .dfont (Mac DFONT)
 `
-	os.WriteFile(readmePath, []byte(readme), 0644)
+	}
 
-	return count, nil
+	return fmt.Sprintf(`Custom Fonts Backup
+
+This directory contains your custom fonts from %s
+
+To restore:
+1. Copy all font files to %s/
+2. Run fc-cache -f to refresh the font cache
+3. Fonts will be available to your user after installation
+
+Font formats supported:
+- .ttf (TrueType Font)
+- .otf (OpenType Font)
+- .ttc (TrueType Collection)
+- .dfont (Mac DFONT)
+`, fm.FontsDir(), fm.FontsDir())
 }
 
 func (fm *FontsManager) RestoreAll(backupDir string) (int, error) {
-	homeDir, _ := os.UserHomeDir()
-	fontsDir := filepath.Join(homeDir, "Library", "Fonts")
+	fontsDir := fm.FontsDir()
 
 	if err := os.MkdirAll(fontsDir, 0755); err != nil {
 		return 0, err
@@ -118,6 +146,13 @@ func (fm *FontsManager) RestoreAll(backupDir string) (int, error) {
 		count++
 	}
 
+	// Linux font lookups go through fontconfig's cache
+	if count > 0 && !fm.platform.IsMacOS() {
+		if _, err := exec.LookPath("fc-cache"); err == nil {
+			_ = exec.Command("fc-cache", "-f", fontsDir).Run()
+		}
+	}
+
 	return count, nil
 }
 
@@ -0,0 +1,363 @@
+package gittracker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// bundlesDir holds bundles and patches inside the tracker output directory
+const bundlesDir = "bundles"
+
+// ErrNotCloned is returned when saved work can't be applied because the
+// repository has not been cloned at the destination
+var ErrNotCloned = errors.New("repository is not cloned")
+
+// HasWork reports whether a bundle or patch was saved for the repo
+func (r *GitRepo) HasWork() bool {
+	return r.Bundle != "" || r.Patch != "" || r.StagedPatch != ""
+}
+
+// CreateBundles saves work that a clone would lose: a bundle of every ref for
+// repos without a remote, a bundle of refs not on any remote otherwise, the
+// stash, and patches of staged and unstaged changes including untracked
+// files. It returns how many repos had work.
+func (gt *GitTracker) CreateBundles() (int, error) {
+	dir := filepath.Join(gt.outputDir, bundlesDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	var errs []error
+	for i := range gt.repos {
+		repo := &gt.repos[i]
+		prefix := fmt.Sprintf("%03d-%s", i+1, filepath.Base(repo.Path))
+
+		var stashes []Stash
+		if repo.StashCount > 0 {
+			var err error
+			if stashes, err = listStashes(repo.Path); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", repo.Path, err))
+			}
+		}
+
+		bundleName := filepath.Join(bundlesDir, prefix+".bundle")
+		created, err := createBundle(repo.Path, filepath.Join(gt.outputDir, bundleName), repo.RemoteURL == "", stashes)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", repo.Path, err))
+		} else if created {
+			repo.Bundle = filepath.ToSlash(bundleName)
+			repo.Stashes = stashes
+		}
+
+		if repo.Dirty {
+			stagedName := filepath.Join(bundlesDir, prefix+".staged.patch")
+			if ok, err := writeDiff(repo.Path, filepath.Join(gt.outputDir, stagedName), "--cached"); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", repo.Path, err))
+			} else if ok {
+				repo.StagedPatch = filepath.ToSlash(stagedName)
+			}
+
+			patchName := filepath.Join(bundlesDir, prefix+".patch")
+			if ok, err := writeWorktreeDiff(repo.Path, filepath.Join(gt.outputDir, patchName)); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", repo.Path, err))
+			} else if ok {
+				repo.Patch = filepath.ToSlash(patchName)
+			}
+		}
+
+		if repo.HasWork() {
+			count++
+		}
+	}
+
+	return count, errors.Join(errs...)
+}
+
+// listStashes returns the repo's stash entries, newest first
+func listStashes(repoPath string) ([]Stash, error) {
+	output, err := exec.Command("git", "-C", repoPath, "stash", "list", "--format=%H %gs").Output()
+	if err != nil {
+		return nil, fmt.Errorf("git stash list failed: %w", err)
+	}
+
+	var stashes []Stash
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		commit, message, ok := strings.Cut(line, " ")
+		if ok {
+			stashes = append(stashes, Stash{Commit: commit, Message: message})
+		}
+	}
+	return stashes, nil
+}
+
+// createBundle writes a bundle of all refs, or only of refs missing from the
+// remotes, plus the stash. It returns false when there was nothing to bundle.
+func createBundle(repoPath, bundlePath string, allRefs bool, stashes []Stash) (bool, error) {
+	args := []string{"-C", repoPath, "bundle", "create", "-q", bundlePath}
+	if allRefs {
+		args = append(args, "--all")
+	} else {
+		args = append(args, "--branches", "--tags")
+		if len(stashes) > 0 {
+			args = append(args, "refs/stash")
+		}
+	}
+	// Only refs/stash is named in the bundle; older entries are in its reflog,
+	// so their commits are added by ID
+	for i, stash := range stashes {
+		if i > 0 {
+			args = append(args, stash.Commit)
+		}
+	}
+	if !allRefs {
+		args = append(args, "--not", "--remotes")
+	}
+
+	output, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		if strings.Contains(string(output), "empty bundle") {
+			return false, nil
+		}
+		return false, fmt.Errorf("git bundle failed: %s", strings.TrimSpace(string(output)))
+	}
+	return true, nil
+}
+
+// writeDiff saves `git diff --binary` output, returning false when there were no changes
+func writeDiff(repoPath, patchPath string, extraArgs ...string) (bool, error) {
+	args := append([]string{"-C", repoPath, "diff", "--binary"}, extraArgs...)
+	output, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return false, fmt.Errorf("git diff failed: %w", err)
+	}
+	if len(output) == 0 {
+		return false, nil
+	}
+	return true, os.WriteFile(patchPath, output, 0600)
+}
+
+// writeWorktreeDiff saves unstaged changes along with untracked files, which
+// are marked intent-to-add in a copy of the index so the repo's own index is
+// left alone. It returns false when there were no changes.
+func writeWorktreeDiff(repoPath, patchPath string) (bool, error) {
+	output, err := exec.Command("git", "-C", repoPath, "ls-files", "-z", "--others", "--exclude-standard").Output()
+	if err != nil {
+		return false, fmt.Errorf("git ls-files failed: %w", err)
+	}
+	if len(output) == 0 {
+		return writeDiff(repoPath, patchPath)
+	}
+	untracked := strings.Split(strings.TrimRight(string(output), "\x00"), "\x00")
+
+	indexPath, err := exec.Command("git", "-C", repoPath, "rev-parse", "--git-path", "index").Output()
+	if err != nil {
+		return false, fmt.Errorf("git rev-parse failed: %w", err)
+	}
+	index := strings.TrimSpace(string(indexPath))
+	if !filepath.IsAbs(index) {
+		index = filepath.Join(repoPath, index)
+	}
+
+	tmp, err := os.MkdirTemp("", "stash-index-")
+	if err != nil {
+		return false, err
+	}
+	defer os.RemoveAll(tmp)
+	tmpIndex := filepath.Join(tmp, "index")
+	if data, err := os.ReadFile(index); err == nil {
+		if err := os.WriteFile(tmpIndex, data, 0600); err != nil {
+			return false, err
+		}
+	} else if !os.IsNotExist(err) {
+		return false, err
+	}
+	env := append(os.Environ(), "GIT_INDEX_FILE="+tmpIndex)
+
+	add := exec.Command("git", append([]string{"-C", repoPath, "add", "--intent-to-add", "--"}, untracked...)...)
+	add.Env = env
+	if output, err := add.CombinedOutput(); err != nil {
+		return false, fmt.Errorf("git add failed: %s", strings.TrimSpace(string(output)))
+	}
+
+	diff := exec.Command("git", "-C", repoPath, "diff", "--binary")
+	diff.Env = env
+	output, err = diff.Output()
+	if err != nil {
+		return false, fmt.Errorf("git diff failed: %w", err)
+	}
+	if len(output) == 0 {
+		return false, nil
+	}
+	return true, os.WriteFile(patchPath, output, 0600)
+}
+
+// LoadRepos reads a git-repos.json written by Save
+func LoadRepos(path string) ([]GitRepo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []GitRepo
+	if err := json.Unmarshal(data, &repos); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", filepath.Base(path), err)
+	}
+	return repos, nil
+}
+
+// RestoreWork reapplies a repo's saved bundle and patches from archiveDir
+// (the directory git-repos.json was saved in) to the clone at dest.
+// Repos without a remote are cloned from their bundle if dest doesn't exist.
+func RestoreWork(repo GitRepo, archiveDir, dest string) error {
+	archived := func(rel string) string {
+		return filepath.Join(archiveDir, filepath.FromSlash(rel))
+	}
+
+	var errs []error
+	if _, err := os.Stat(filepath.Join(dest, ".git")); err != nil {
+		if repo.RemoteURL != "" || repo.Bundle == "" {
+			return ErrNotCloned
+		}
+		if err := cloneFromBundle(archived(repo.Bundle), dest, repo.Branch); err != nil {
+			return err
+		}
+	} else if repo.Bundle != "" {
+		if err := fetchBundle(dest, archived(repo.Bundle)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(repo.Stashes) > 0 {
+		if err := restoreStashes(dest, archived(repo.Bundle), repo.Stashes); err != nil {
+			errs = append(errs, fmt.Errorf("stash: %w", err))
+		}
+	}
+
+	errs = append(errs, applyPatches(repo, archiveDir, dest)...)
+	return errors.Join(errs...)
+}
+
+// restoreStashes adds the saved stash entries that the repo's stash doesn't
+// already have, keeping their order
+func restoreStashes(repoPath, bundlePath string, stashes []Stash) error {
+	// Fetching refs/stash unpacks the whole bundle, older entries included
+	if err := runGit(repoPath, "fetch", "-q", bundlePath, "refs/stash"); err != nil {
+		return err
+	}
+
+	existing := map[string]bool{}
+	if output, err := exec.Command("git", "-C", repoPath, "stash", "list", "--format=%H").Output(); err == nil {
+		for _, commit := range strings.Fields(string(output)) {
+			existing[commit] = true
+		}
+	}
+
+	var errs []error
+	for i := len(stashes) - 1; i >= 0; i-- {
+		stash := stashes[i]
+		if existing[stash.Commit] {
+			continue
+		}
+		if err := runGit(repoPath, "stash", "store", "-m", stash.Message, stash.Commit); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", stash.Message, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// applyPatches applies the repo's saved staged and unstaged changes
+func applyPatches(repo GitRepo, archiveDir, dest string) []error {
+	var errs []error
+	if repo.StagedPatch != "" {
+		if err := applyPatch(dest, filepath.Join(archiveDir, filepath.FromSlash(repo.StagedPatch)), "--index"); err != nil {
+			errs = append(errs, fmt.Errorf("staged changes: %w", err))
+		}
+	}
+	if repo.Patch != "" {
+		if err := applyPatch(dest, filepath.Join(archiveDir, filepath.FromSlash(repo.Patch))); err != nil {
+			errs = append(errs, fmt.Errorf("working tree changes: %w", err))
+		}
+	}
+	return errs
+}
+
+func cloneFromBundle(bundlePath, dest, branch string) error {
+	if err := gitClone(bundlePath, dest); err != nil {
+		return fmt.Errorf("git clone from bundle failed: %w", err)
+	}
+	// The bundle file is temporary; don't leave it configured as origin
+	_ = runGit(dest, "remote", "remove", "origin")
+
+	if branch != "" && branch != "HEAD" {
+		_ = runGit(dest, "checkout", "-q", branch)
+	}
+	return nil
+}
+
+// fetchBundle brings the bundle's branches and tags into the repo. The checked
+// out branch is fast-forwarded; other branches are updated in place.
+func fetchBundle(repoPath, bundlePath string) error {
+	output, err := exec.Command("git", "-C", repoPath, "bundle", "list-heads", bundlePath).Output()
+	if err != nil {
+		return fmt.Errorf("unreadable bundle: %w", err)
+	}
+
+	current := ""
+	if out, err := exec.Command("git", "-C", repoPath, "symbolic-ref", "-q", "HEAD").Output(); err == nil {
+		current = strings.TrimSpace(string(out))
+	}
+
+	var errs []error
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		ref := fields[1]
+		if !strings.HasPrefix(ref, "refs/heads/") && !strings.HasPrefix(ref, "refs/tags/") {
+			continue
+		}
+
+		if ref == current {
+			err = runGit(repoPath, "fetch", "-q", bundlePath, ref)
+			if err == nil {
+				err = runGit(repoPath, "merge", "-q", "--ff-only", "FETCH_HEAD")
+			}
+		} else {
+			err = runGit(repoPath, "fetch", "-q", bundlePath, ref+":"+ref)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", strings.TrimPrefix(ref, "refs/heads/"), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// applyPatch applies a saved diff, skipping it if it is already applied
+func applyPatch(repoPath, patchPath string, extraArgs ...string) error {
+	reverseCheck := append([]string{"apply", "--check", "-R"}, extraArgs...)
+	if runGit(repoPath, append(reverseCheck, patchPath)...) == nil {
+		return nil
+	}
+
+	args := append([]string{"apply", "--whitespace=nowarn"}, extraArgs...)
+	return runGit(repoPath, append(args, patchPath)...)
+}
+
+func runGit(repoPath string, args ...string) error {
+	output, err := exec.Command("git", append([]string{"-C", repoPath}, args...)...).CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(output))
+		if msg == "" {
+			return err
+		}
+		return errors.New(msg)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package gittracker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// scanCacheVersion is bumped whenever GitRepo gains fields the cache would lack
+const scanCacheVersion = 1
+
+// fingerprintPaths are the files and directories inside .git whose mtimes
+// change when commits, packed refs, remotes or the index change. Loose refs
+// are walked separately, since updating refs/heads/feature/x only touches
+// that file. Dropping a stash entry other than the newest only rewrites the
+// stash reflog, so it is listed too.
+var fingerprintPaths = []string{
+	"HEAD",
+	"index",
+	"config",
+	"packed-refs",
+	"FETCH_HEAD",
+	"logs/HEAD",
+	"logs/refs/stash",
+	"worktrees",
+}
+
+// ScanCache remembers repo info between scans, keyed by repo path and a
+// fingerprint of its .git directory
+type ScanCache struct {
+	Version int                        `json:"version"`
+	Entries map[string]*scanCacheEntry `json:"entries"`
+	path    string
+	mu      sync.Mutex
+}
+
+type scanCacheEntry struct {
+	Fingerprint string  `json:"fingerprint"`
+	Repo        GitRepo `json:"repo"`
+}
+
+// LegacyScanCacheName is the file older versions kept the scan cache in,
+// directly in the home directory
+const LegacyScanCacheName = ".stash-git-cache.json"
+
+// DefaultScanCachePath returns the default location of the scan cache. It
+// lives in the user cache directory so dotfile backups don't pick it up.
+func DefaultScanCachePath() string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		homeDir, _ := os.UserHomeDir()
+		cacheDir = filepath.Join(homeDir, ".cache")
+	}
+	return filepath.Join(cacheDir, "stash", "git-scan-cache.json")
+}
+
+// LoadScanCache reads a scan cache, starting empty if it is missing,
+// unreadable or from an older version
+func LoadScanCache(path string) *ScanCache {
+	cache := &ScanCache{
+		Version: scanCacheVersion,
+		Entries: make(map[string]*scanCacheEntry),
+		path:    path,
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+
+	var loaded ScanCache
+	if err := json.Unmarshal(data, &loaded); err != nil || loaded.Version != scanCacheVersion || loaded.Entries == nil {
+		return cache
+	}
+	cache.Entries = loaded.Entries
+	return cache
+}
+
+// Save writes the cache, dropping repos that no longer exist
+func (c *ScanCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for path := range c.Entries {
+		if _, err := os.Stat(filepath.Join(path, ".git")); err != nil {
+			delete(c.Entries, path)
+		}
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal git cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return fmt.Errorf("failed to create git cache directory: %w", err)
+	}
+	if err := os.WriteFile(c.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write git cache: %w", err)
+	}
+	return nil
+}
+
+func (c *ScanCache) get(repoPath, fingerprint string) (GitRepo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.Entries[repoPath]
+	if !ok || entry.Fingerprint != fingerprint {
+		return GitRepo{}, false
+	}
+	repo := entry.Repo
+	// Copy slices so callers can't modify the cached entry
+	repo.Remotes = append([]string{}, repo.Remotes...)
+	repo.LocalBranches = append([]string(nil), repo.LocalBranches...)
+	repo.UnpushedTags = append([]string(nil), repo.UnpushedTags...)
+	repo.Worktrees = append([]string(nil), repo.Worktrees...)
+	return repo, true
+}
+
+func (c *ScanCache) put(repoPath, fingerprint string, repo GitRepo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Entries[repoPath] = &scanCacheEntry{Fingerprint: fingerprint, Repo: repo}
+}
+
+// repoFingerprint summarizes the mtimes of HEAD, the index and every loose
+// ref. It is empty when .git isn't a directory (worktrees, submodules),
+// which disables caching for that repo.
+func repoFingerprint(repoPath string) string {
+	gitDir := filepath.Join(repoPath, ".git")
+	if info, err := os.Stat(gitDir); err != nil || !info.IsDir() {
+		return ""
+	}
+
+	parts := make([]string, 0, len(fingerprintPaths))
+	for _, path := range fingerprintPaths {
+		stamp := "-"
+		if info, err := os.Stat(filepath.Join(gitDir, path)); err == nil {
+			stamp = fmt.Sprintf("%d", info.ModTime().UnixNano())
+		}
+		parts = append(parts, path+"="+stamp)
+	}
+
+	// Branches, tags, the stash and remote-tracking refs, at any depth;
+	// directories are included so deleted refs change the fingerprint too
+	refsDir := filepath.Join(gitDir, "refs")
+	filepath.WalkDir(refsDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(gitDir, path)
+		parts = append(parts, fmt.Sprintf("%s=%d", filepath.ToSlash(rel), info.ModTime().UnixNano()))
+		return nil
+	})
+	return strings.Join(parts, ";")
+}
@@ -0,0 +1,242 @@
+package gittracker
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DefaultCloneWorkers is how many repositories are cloned at once by default
+const DefaultCloneWorkers = 4
+
+// CloneStatus describes what happened to a repository on restore
+type CloneStatus string
+
+const (
+	CloneCloned  CloneStatus = "cloned"
+	CloneExists  CloneStatus = "exists"
+	CloneSkipped CloneStatus = "skipped"
+	CloneFailed  CloneStatus = "failed"
+)
+
+// CloneResult is the outcome of restoring one repository
+type CloneResult struct {
+	Repo   GitRepo
+	Dest   string
+	Status CloneStatus
+	// Err is why a clone failed or was skipped, or what went wrong reapplying
+	// saved work to a repo that was cloned or already present
+	Err error
+}
+
+// CloneRepos clones each repository to the path dest returns, checks out its
+// recorded branch and reapplies any saved bundle and patches. Up to workers
+// clones run at once; repos nested inside another repo wait for their parent.
+// Existing destinations are never re-cloned but still get saved work.
+// onDone, if set, is called as each repo finishes. Results keep input order.
+func CloneRepos(repos []GitRepo, archiveDir string, dest func(string) string, workers int, onDone func(CloneResult)) []CloneResult {
+	if workers < 1 {
+		workers = DefaultCloneWorkers
+	}
+
+	results := make([]CloneResult, len(repos))
+	var mu sync.Mutex
+
+	for _, level := range nestingLevels(repos) {
+		jobs := make(chan int)
+		var wg sync.WaitGroup
+
+		for w := 0; w < workers && w < len(level); w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					result := CloneRepo(repos[i], archiveDir, dest(repos[i].Path))
+					results[i] = result
+					if onDone != nil {
+						mu.Lock()
+						onDone(result)
+						mu.Unlock()
+					}
+				}
+			}()
+		}
+
+		for _, i := range level {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+	}
+
+	return results
+}
+
+// nestingLevels groups repo indexes by how many other repos contain them, so
+// a parent is always cloned before the repos inside it
+func nestingLevels(repos []GitRepo) [][]int {
+	var levels [][]int
+	for i, repo := range repos {
+		depth := 0
+		for j, other := range repos {
+			if i != j && strings.HasPrefix(repo.Path, other.Path+string(filepath.Separator)) {
+				depth++
+			}
+		}
+		for len(levels) <= depth {
+			levels = append(levels, nil)
+		}
+		levels[depth] = append(levels[depth], i)
+	}
+	return levels
+}
+
+// CloneRepo restores a single repository at dest
+func CloneRepo(repo GitRepo, archiveDir, dest string) CloneResult {
+	result := CloneResult{Repo: repo, Dest: dest}
+
+	if info, err := os.Lstat(dest); err == nil {
+		if !info.IsDir() {
+			result.Status = CloneFailed
+			result.Err = fmt.Errorf("%s exists and is not a directory", dest)
+			return result
+		}
+		if isWorkDir(dest) {
+			result.Status = CloneExists
+			if repo.HasWork() {
+				result.Err = RestoreWork(repo, archiveDir, dest)
+			}
+			return result
+		}
+		// A directory that isn't a repository, e.g. one holding restored
+		// files, is cloned into
+	}
+
+	if repo.RemoteURL == "" {
+		if repo.Bundle == "" {
+			result.Status = CloneSkipped
+			result.Err = errors.New("no remote URL")
+			return result
+		}
+		// Local-only repos are recreated from their bundle
+		result.Err = RestoreWork(repo, archiveDir, dest)
+		result.Status = CloneCloned
+		if !isWorkDir(dest) {
+			result.Status = CloneFailed
+		}
+		return result
+	}
+
+	if err := cloneRemote(repo.RemoteURL, dest); err != nil {
+		result.Status = CloneFailed
+		result.Err = err
+		return result
+	}
+	result.Status = CloneCloned
+
+	var errs []error
+	if repo.Bundle != "" {
+		if err := fetchBundle(dest, filepath.Join(archiveDir, filepath.FromSlash(repo.Bundle))); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := checkoutBranch(dest, repo.Branch); err != nil {
+		errs = append(errs, fmt.Errorf("checkout %s: %w", repo.Branch, err))
+	}
+	errs = append(errs, applyPatches(repo, archiveDir, dest)...)
+
+	result.Err = errors.Join(errs...)
+	return result
+}
+
+// cloneRemote clones url into dest without ever prompting for credentials,
+// since several clones may be running at once
+func cloneRemote(url, dest string) error {
+	if err := gitClone(url, dest); err != nil {
+		return fmt.Errorf("git clone failed: %w", err)
+	}
+	return nil
+}
+
+// gitClone clones source into dest. If dest is an existing directory, the
+// clone is made next to it and only its .git is moved in, then the work tree
+// is checked out around the files already there, which are kept as they are.
+func gitClone(source, dest string) error {
+	parent := filepath.Dir(dest)
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return err
+	}
+	if _, err := os.Stat(dest); err != nil {
+		return runClone(source, dest)
+	}
+
+	tmp, err := os.MkdirTemp(parent, ".stash-clone-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := runClone(source, tmp, "--no-checkout"); err != nil {
+		return err
+	}
+	if err := os.Rename(filepath.Join(tmp, ".git"), filepath.Join(dest, ".git")); err != nil {
+		return err
+	}
+	// An empty remote has nothing to check out
+	if err := runGit(dest, "reset", "-q"); err != nil {
+		return nil
+	}
+	// checkout-index fails for files that exist, which are left alone
+	_ = runGit(dest, "checkout-index", "-a", "-q")
+	return nil
+}
+
+// runClone runs git clone without prompting, so parallel clones never wait
+// on the terminal: HTTPS credential prompts are disabled and SSH runs in
+// batch mode, failing on unknown host keys and locked keys instead of asking
+func runClone(source, dest string, args ...string) error {
+	args = append(append([]string{"clone", "-q"}, args...), source, dest)
+	cmd := exec.Command("git", args...)
+	cmd.Env = cloneEnv(os.Environ())
+	if output, err := cmd.CombinedOutput(); err != nil {
+		msg := strings.TrimSpace(string(output))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return errors.New(msg)
+	}
+	return nil
+}
+
+// cloneEnv returns env with git and SSH prompts turned off. An SSH command
+// the user already set is kept.
+func cloneEnv(env []string) []string {
+	env = append(env, "GIT_TERMINAL_PROMPT=0")
+	for _, v := range env {
+		if strings.HasPrefix(v, "GIT_SSH_COMMAND=") || strings.HasPrefix(v, "GIT_SSH=") {
+			return env
+		}
+	}
+	return append(env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes")
+}
+
+// checkoutBranch switches to branch unless it is already checked out
+func checkoutBranch(repoPath, branch string) error {
+	if branch == "" || branch == "HEAD" {
+		return nil
+	}
+	output, err := exec.Command("git", "-C", repoPath, "rev-parse", "--abbrev-ref", "HEAD").Output()
+	if err == nil && strings.TrimSpace(string(output)) == branch {
+		return nil
+	}
+	return runGit(repoPath, "checkout", "-q", branch)
+}
+
+func isWorkDir(path string) bool {
+	_, err := os.Stat(filepath.Join(path, ".git"))
+	return err == nil
+}
@@ -4,11 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
-
-	"github.com/harshpatel5940/stash/internal/security"
+	"time"
 )
 
 type GitRepo struct {
@@ -17,38 +15,61 @@ type GitRepo struct {
 	Branch        string   `json:"branch"`
 	Dirty         bool     `json:"dirty"`
 	Remotes       []string `json:"remotes"`
-	Ahead         int      `json:"ahead"`          // Commits ahead of remote
-	Behind        int      `json:"behind"`         // Commits behind remote
-	HasUpstream   bool     `json:"has_upstream"`   // Has tracking branch configured
-	UnpushedCount int      `json:"unpushed_count"` // Number of unpushed commits (alias for Ahead)
+	Ahead         int      `json:"ahead"`                    // Commits ahead of remote
+	Behind        int      `json:"behind"`                   // Commits behind remote
+	HasUpstream   bool     `json:"has_upstream"`             // Has tracking branch configured
+	UnpushedCount int      `json:"unpushed_count"`           // Number of unpushed commits (alias for Ahead)
+	StashCount    int      `json:"stash_count"`              // Entries in git stash
+	LocalBranches []string `json:"local_branches,omitempty"` // Branches other than HEAD without an upstream
+	UnpushedTags  []string `json:"unpushed_tags,omitempty"`  // Tags on commits no remote branch contains
+	Worktrees     []string `json:"worktrees,omitempty"`      // Linked worktrees (not the main one)
+	Untracked     int      `json:"untracked"`                // Untracked files (also counted in Dirty)
+	Bundle        string   `json:"bundle,omitempty"`         // Bundle of unpushed refs (all refs when local-only)
+	Patch         string   `json:"patch,omitempty"`          // Unstaged working tree changes
+	StagedPatch   string   `json:"staged_patch,omitempty"`   // Staged changes
+	Stashes       []Stash  `json:"stashes,omitempty"`        // Stash entries saved in Bundle, newest first
+}
+
+// Stash is one `git stash` entry
+type Stash struct {
+	Commit  string `json:"commit"`
+	Message string `json:"message"`
 }
 
 type GitTracker struct {
-	outputDir string
-	repos     []GitRepo
-	seenPaths map[string]bool
-	maxDepth  int
-	skipDirs  map[string]bool
+	outputDir  string
+	repos      []GitRepo
+	seenPaths  map[string]bool
+	maxDepth   int
+	skipDirs   map[string]bool
+	workers    int
+	cmdTimeout time.Duration
+	cache      *ScanCache
+	progress   func(done, total int)
 }
 
 func NewGitTracker(outputDir string) *GitTracker {
 	return &GitTracker{
-		outputDir: outputDir,
-		repos:     []GitRepo{},
-		seenPaths: make(map[string]bool),
-		maxDepth:  5,
-		skipDirs:  defaultSkipDirs(),
+		outputDir:  outputDir,
+		repos:      []GitRepo{},
+		seenPaths:  make(map[string]bool),
+		maxDepth:   5,
+		skipDirs:   defaultSkipDirs(),
+		workers:    DefaultScanWorkers,
+		cmdTimeout: DefaultCommandTimeout,
 	}
 }
 
 // NewGitTrackerWithConfig creates a GitTracker with custom config
 func NewGitTrackerWithConfig(outputDir string, maxDepth int, skipDirs []string) *GitTracker {
 	gt := &GitTracker{
-		outputDir: outputDir,
-		repos:     []GitRepo{},
-		seenPaths: make(map[string]bool),
-		maxDepth:  maxDepth,
-		skipDirs:  make(map[string]bool),
+		outputDir:  outputDir,
+		repos:      []GitRepo{},
+		seenPaths:  make(map[string]bool),
+		maxDepth:   maxDepth,
+		skipDirs:   make(map[string]bool),
+		workers:    DefaultScanWorkers,
+		cmdTimeout: DefaultCommandTimeout,
 	}
 	for _, dir := range skipDirs {
 		gt.skipDirs[dir] = true
@@ -71,145 +92,6 @@ func defaultSkipDirs() map[string]bool {
 	}
 }
 
-func (gt *GitTracker) ScanDirectories(searchDirs []string) error {
-	for _, dir := range searchDirs {
-		if err := gt.scanDir(dir, 0, gt.maxDepth); err != nil {
-			continue
-		}
-	}
-	return nil
-}
-
-func (gt *GitTracker) scanDir(dir string, depth, maxDepth int) error {
-	if depth > maxDepth {
-		return nil
-	}
-
-	if strings.HasPrefix(dir, "~") {
-		homeDir, _ := os.UserHomeDir()
-		dir = filepath.Join(homeDir, dir[1:])
-	}
-
-	absPath, err := filepath.Abs(dir)
-	if err != nil {
-		return err
-	}
-
-	if gt.seenPaths[absPath] {
-		return nil
-	}
-	gt.seenPaths[absPath] = true
-
-	entries, err := os.ReadDir(security.CleanPath(dir))
-	if err != nil {
-		return err
-	}
-
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
-		}
-
-		if gt.skipDirs[entry.Name()] {
-			continue
-		}
-
-		fullPath := security.CleanPath(filepath.Join(dir, entry.Name()))
-
-		gitPath := filepath.Join(fullPath, ".git")
-		if _, err := os.Stat(gitPath); err == nil {
-
-			repo, err := gt.extractRepoInfo(fullPath)
-			if err == nil {
-				gt.repos = append(gt.repos, repo)
-			}
-
-			continue
-		}
-
-		gt.scanDir(fullPath, depth+1, maxDepth)
-	}
-
-	return nil
-}
-
-func (gt *GitTracker) extractRepoInfo(repoPath string) (GitRepo, error) {
-	repo := GitRepo{
-		Path:    repoPath,
-		Remotes: []string{},
-	}
-
-	cmd := exec.Command("git", "-C", repoPath, "remote", "get-url", "origin")
-	output, err := cmd.Output()
-	if err == nil {
-		repo.RemoteURL = strings.TrimSpace(string(output))
-	}
-
-	cmd = exec.Command("git", "-C", repoPath, "remote", "-v")
-	output, err = cmd.Output()
-	if err == nil {
-		lines := strings.Split(string(output), "\n")
-		seen := make(map[string]bool)
-		for _, line := range lines {
-			if line == "" {
-				continue
-			}
-			parts := strings.Fields(line)
-			if len(parts) >= 2 {
-				remote := parts[0] + " " + parts[1]
-				if !seen[remote] {
-					repo.Remotes = append(repo.Remotes, remote)
-					seen[remote] = true
-				}
-			}
-		}
-	}
-
-	cmd = exec.Command("git", "-C", repoPath, "rev-parse", "--abbrev-ref", "HEAD")
-	output, err = cmd.Output()
-	if err == nil {
-		repo.Branch = strings.TrimSpace(string(output))
-	}
-
-	cmd = exec.Command("git", "-C", repoPath, "status", "--porcelain")
-	output, err = cmd.Output()
-	if err == nil {
-		repo.Dirty = len(strings.TrimSpace(string(output))) > 0
-	}
-
-	// Check for upstream tracking and ahead/behind status
-	repo.Ahead, repo.Behind, repo.HasUpstream = gt.getAheadBehind(repoPath)
-	repo.UnpushedCount = repo.Ahead // Alias for convenience
-
-	return repo, nil
-}
-
-// getAheadBehind returns the number of commits ahead and behind the upstream
-func (gt *GitTracker) getAheadBehind(repoPath string) (ahead, behind int, hasUpstream bool) {
-	// Check if there's an upstream branch configured
-	cmd := exec.Command("git", "-C", repoPath, "rev-parse", "--abbrev-ref", "@{upstream}")
-	if err := cmd.Run(); err != nil {
-		// No upstream configured
-		return 0, 0, false
-	}
-
-	// Get ahead/behind counts
-	cmd = exec.Command("git", "-C", repoPath, "rev-list", "--left-right", "--count", "@{upstream}...HEAD")
-	output, err := cmd.Output()
-	if err != nil {
-		return 0, 0, true // Upstream exists but couldn't get counts
-	}
-
-	// Parse output: "behind\tahead"
-	parts := strings.Fields(strings.TrimSpace(string(output)))
-	if len(parts) >= 2 {
-		fmt.Sscanf(parts[0], "%d", &behind)
-		fmt.Sscanf(parts[1], "%d", &ahead)
-	}
-
-	return ahead, behind, true
-}
-
 func (gt *GitTracker) Save() error {
 	if len(gt.repos) == 0 {
 		return fmt.Errorf("no repositories found")
@@ -316,9 +198,10 @@ func (gt *GitTracker) GetReposNeedingAttention() []GitRepo {
 	return needsAttention
 }
 
-// NeedsAttention returns true if the repo has uncommitted or unpushed changes
+// NeedsAttention returns true if the repo has uncommitted, unpushed or otherwise local-only work
 func (r *GitRepo) NeedsAttention() bool {
-	if r.Dirty || r.UnpushedCount > 0 {
+	if r.Dirty || r.UnpushedCount > 0 || r.StashCount > 0 ||
+		len(r.LocalBranches) > 0 || len(r.UnpushedTags) > 0 || len(r.Worktrees) > 0 {
 		return true
 	}
 	// A configured remote without upstream tracking often means local work
@@ -326,6 +209,35 @@ func (r *GitRepo) NeedsAttention() bool {
 	return !r.HasUpstream && len(r.Remotes) > 0
 }
 
+// LocalOnlyWork describes data in the repo that exists only on this machine
+func (r *GitRepo) LocalOnlyWork() []string {
+	var parts []string
+	if len(r.Remotes) == 0 {
+		parts = append(parts, "no remote")
+	}
+	if r.Dirty {
+		parts = append(parts, "uncommitted changes")
+	}
+	if r.UnpushedCount > 0 {
+		parts = append(parts, fmt.Sprintf("%d unpushed commit(s)", r.UnpushedCount))
+	} else if !r.HasUpstream && len(r.Remotes) > 0 {
+		parts = append(parts, fmt.Sprintf("branch %s has no upstream", r.Branch))
+	}
+	if r.StashCount > 0 {
+		parts = append(parts, fmt.Sprintf("%d stash(es)", r.StashCount))
+	}
+	if len(r.LocalBranches) > 0 {
+		parts = append(parts, fmt.Sprintf("local branches: %s", strings.Join(r.LocalBranches, ", ")))
+	}
+	if len(r.UnpushedTags) > 0 {
+		parts = append(parts, fmt.Sprintf("unpushed tags: %s", strings.Join(r.UnpushedTags, ", ")))
+	}
+	if len(r.Worktrees) > 0 {
+		parts = append(parts, fmt.Sprintf("%d linked worktree(s)", len(r.Worktrees)))
+	}
+	return parts
+}
+
 // GetStatusSummary returns a human-readable status summary
 func (r *GitRepo) GetStatusSummary() string {
 	var parts []string
@@ -341,6 +253,18 @@ func (r *GitRepo) GetStatusSummary() string {
 	if r.Behind > 0 {
 		parts = append(parts, fmt.Sprintf("%d behind", r.Behind))
 	}
+	if r.StashCount > 0 {
+		parts = append(parts, fmt.Sprintf("%d stashed", r.StashCount))
+	}
+	if len(r.LocalBranches) > 0 {
+		parts = append(parts, fmt.Sprintf("%d local branch(es)", len(r.LocalBranches)))
+	}
+	if len(r.UnpushedTags) > 0 {
+		parts = append(parts, fmt.Sprintf("%d unpushed tag(s)", len(r.UnpushedTags)))
+	}
+	if len(r.Worktrees) > 0 {
+		parts = append(parts, fmt.Sprintf("%d worktree(s)", len(r.Worktrees)))
+	}
 	if len(parts) == 0 {
 		return "clean"
 	}
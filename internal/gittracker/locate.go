@@ -0,0 +1,131 @@
+package gittracker
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RepoLocation identifies a file by the git repository it lives in
+type RepoLocation struct {
+	Root      string // repository working tree root
+	RemoteURL string // origin URL
+	RelPath   string // file path relative to Root
+}
+
+// RepoLocator finds the git repository owning a file, caching lookups per directory
+type RepoLocator struct {
+	mu    sync.Mutex
+	roots map[string]string // dir -> repo root ("" when not in a repo)
+	urls  map[string]string // repo root -> origin URL
+}
+
+// NewRepoLocator creates an empty locator
+func NewRepoLocator() *RepoLocator {
+	return &RepoLocator{
+		roots: make(map[string]string),
+		urls:  make(map[string]string),
+	}
+}
+
+// Locate returns the repository a file belongs to. Files outside a repository,
+// or in one without an origin remote, are reported as not found.
+func (l *RepoLocator) Locate(path string) (RepoLocation, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	root := l.findRoot(filepath.Dir(path))
+	if root == "" {
+		return RepoLocation{}, false
+	}
+
+	url, ok := l.urls[root]
+	if !ok {
+		output, err := exec.Command("git", "-C", root, "remote", "get-url", "origin").Output()
+		if err == nil {
+			url = strings.TrimSpace(string(output))
+		}
+		l.urls[root] = url
+	}
+	if url == "" {
+		return RepoLocation{}, false
+	}
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return RepoLocation{}, false
+	}
+
+	return RepoLocation{Root: root, RemoteURL: url, RelPath: filepath.ToSlash(rel)}, true
+}
+
+func (l *RepoLocator) findRoot(dir string) string {
+	if root, ok := l.roots[dir]; ok {
+		return root
+	}
+
+	root := ""
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		root = dir
+	} else if parent := filepath.Dir(dir); parent != dir {
+		root = l.findRoot(parent)
+	}
+
+	l.roots[dir] = root
+	return root
+}
+
+// NormalizeRemoteURL reduces a remote URL to host/owner/name so SSH and HTTPS
+// clones of the same repository compare equal
+func NormalizeRemoteURL(url string) string {
+	url = strings.TrimSpace(url)
+	url = strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git")
+
+	if idx := strings.Index(url, "://"); idx != -1 {
+		url = url[idx+3:]
+		// Drop credentials and port
+		if at := strings.LastIndex(url, "@"); at != -1 && at < strings.Index(url+"/", "/") {
+			url = url[at+1:]
+		}
+		if slash := strings.Index(url, "/"); slash != -1 {
+			host := url[:slash]
+			if colon := strings.Index(host, ":"); colon != -1 {
+				host = host[:colon]
+			}
+			url = host + url[slash:]
+		}
+	} else if at := strings.Index(url, "@"); at != -1 && strings.Contains(url[at:], ":") {
+		// scp-like syntax: git@github.com:owner/name
+		url = strings.Replace(url[at+1:], ":", "/", 1)
+	}
+
+	return strings.ToLower(url)
+}
+
+// IndexByRemote maps normalized remote URLs to local clone paths. When a
+// repository is cloned more than once, the shortest path wins.
+func IndexByRemote(repos []GitRepo) map[string]string {
+	sorted := make([]GitRepo, len(repos))
+	copy(sorted, repos)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if len(sorted[i].Path) != len(sorted[j].Path) {
+			return len(sorted[i].Path) < len(sorted[j].Path)
+		}
+		return sorted[i].Path < sorted[j].Path
+	})
+
+	index := make(map[string]string)
+	for _, repo := range sorted {
+		if repo.RemoteURL == "" {
+			continue
+		}
+		key := NormalizeRemoteURL(repo.RemoteURL)
+		if _, exists := index[key]; !exists {
+			index[key] = repo.Path
+		}
+	}
+	return index
+}
@@ -0,0 +1,377 @@
+package gittracker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/harshpatel5940/stash/internal/security"
+)
+
+const (
+	// DefaultScanWorkers is how many repositories are inspected at once
+	DefaultScanWorkers = 8
+	// DefaultCommandTimeout bounds each git command run while scanning
+	DefaultCommandTimeout = 30 * time.Second
+)
+
+// SetWorkers sets how many repositories are inspected concurrently
+func (gt *GitTracker) SetWorkers(workers int) {
+	if workers > 0 {
+		gt.workers = workers
+	}
+}
+
+// SetCommandTimeout sets how long a single git command may run before it is
+// killed and the repo is recorded with what was gathered so far
+func (gt *GitTracker) SetCommandTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		gt.cmdTimeout = timeout
+	}
+}
+
+// SetCache makes scans reuse results for repos that haven't changed
+func (gt *GitTracker) SetCache(cache *ScanCache) {
+	gt.cache = cache
+}
+
+// SetProgress registers a callback run after each repo is inspected
+func (gt *GitTracker) SetProgress(progress func(done, total int)) {
+	gt.progress = progress
+}
+
+func (gt *GitTracker) ScanDirectories(searchDirs []string) error {
+	return gt.ScanDirectoriesContext(context.Background(), searchDirs)
+}
+
+// ScanDirectoriesContext finds repositories under searchDirs and inspects them
+// in parallel. Cancelling ctx stops the scan and returns ctx.Err(); repos
+// inspected before that are kept.
+func (gt *GitTracker) ScanDirectoriesContext(ctx context.Context, searchDirs []string) error {
+	var paths []string
+	for _, dir := range searchDirs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := gt.scanDir(ctx, dir, 0, gt.maxDepth, &paths); err != nil {
+			continue
+		}
+	}
+
+	gt.repos = append(gt.repos, gt.inspectRepos(ctx, paths)...)
+
+	if gt.cache != nil {
+		// The cache is only an optimization; a failed write just means a slower next scan
+		_ = gt.cache.Save()
+	}
+	return ctx.Err()
+}
+
+// scanDir collects repository paths under dir in walk order
+func (gt *GitTracker) scanDir(ctx context.Context, dir string, depth, maxDepth int, paths *[]string) error {
+	if depth > maxDepth || ctx.Err() != nil {
+		return nil
+	}
+
+	if strings.HasPrefix(dir, "~") {
+		homeDir, _ := os.UserHomeDir()
+		dir = filepath.Join(homeDir, dir[1:])
+	}
+
+	absPath, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	if gt.seenPaths[absPath] {
+		return nil
+	}
+	gt.seenPaths[absPath] = true
+
+	entries, err := os.ReadDir(security.CleanPath(dir))
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		if gt.skipDirs[entry.Name()] {
+			continue
+		}
+
+		fullPath := security.CleanPath(filepath.Join(dir, entry.Name()))
+
+		gitPath := filepath.Join(fullPath, ".git")
+		if _, err := os.Stat(gitPath); err == nil {
+			*paths = append(*paths, fullPath)
+			continue
+		}
+
+		gt.scanDir(ctx, fullPath, depth+1, maxDepth, paths)
+	}
+
+	return nil
+}
+
+// inspectRepos gathers repo info with a bounded worker pool, keeping the
+// order the repos were found in
+func (gt *GitTracker) inspectRepos(ctx context.Context, paths []string) []GitRepo {
+	workers := gt.workers
+	if workers < 1 {
+		workers = DefaultScanWorkers
+	}
+
+	results := make([]GitRepo, len(paths))
+	inspected := make([]bool, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done := 0
+
+	for w := 0; w < workers && w < len(paths); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				repo, err := gt.cachedRepoInfo(ctx, paths[i])
+				if err == nil && ctx.Err() == nil {
+					results[i], inspected[i] = repo, true
+				}
+				if gt.progress != nil {
+					mu.Lock()
+					done++
+					gt.progress(done, len(paths))
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := range paths {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	var repos []GitRepo
+	for i, repo := range results {
+		if inspected[i] {
+			repos = append(repos, repo)
+		}
+	}
+	return repos
+}
+
+// cachedRepoInfo returns repo info, reusing the cached refs-derived fields
+// when HEAD, the index and refs are unchanged. Working tree status is always
+// re-read since editing files doesn't touch anything the fingerprint covers.
+func (gt *GitTracker) cachedRepoInfo(ctx context.Context, repoPath string) (GitRepo, error) {
+	if gt.cache == nil {
+		return gt.extractRepoInfo(ctx, repoPath)
+	}
+
+	fingerprint := repoFingerprint(repoPath)
+	if fingerprint != "" {
+		if repo, ok := gt.cache.get(repoPath, fingerprint); ok {
+			gt.readStatus(ctx, &repo)
+			return repo, nil
+		}
+	}
+
+	repo, err := gt.extractRepoInfo(ctx, repoPath)
+	if err == nil && fingerprint != "" && ctx.Err() == nil {
+		gt.cache.put(repoPath, fingerprint, repo)
+	}
+	return repo, err
+}
+
+func (gt *GitTracker) extractRepoInfo(ctx context.Context, repoPath string) (GitRepo, error) {
+	repo := GitRepo{
+		Path:    repoPath,
+		Remotes: []string{},
+	}
+
+	output, err := gt.git(ctx, repoPath, "remote", "get-url", "origin")
+	if err == nil {
+		repo.RemoteURL = strings.TrimSpace(string(output))
+	}
+
+	output, err = gt.git(ctx, repoPath, "remote", "-v")
+	if err == nil {
+		lines := strings.Split(string(output), "\n")
+		seen := make(map[string]bool)
+		for _, line := range lines {
+			if line == "" {
+				continue
+			}
+			parts := strings.Fields(line)
+			if len(parts) >= 2 {
+				remote := parts[0] + " " + parts[1]
+				if !seen[remote] {
+					repo.Remotes = append(repo.Remotes, remote)
+					seen[remote] = true
+				}
+			}
+		}
+	}
+
+	output, err = gt.git(ctx, repoPath, "rev-parse", "--abbrev-ref", "HEAD")
+	if err == nil {
+		repo.Branch = strings.TrimSpace(string(output))
+	}
+
+	gt.readStatus(ctx, &repo)
+
+	// Check for upstream tracking and ahead/behind status
+	repo.Ahead, repo.Behind, repo.HasUpstream = gt.getAheadBehind(ctx, repoPath)
+	repo.UnpushedCount = repo.Ahead // Alias for convenience
+
+	repo.StashCount = len(gt.gitLines(ctx, repoPath, "stash", "list"))
+	repo.Worktrees = gt.linkedWorktrees(ctx, repoPath)
+
+	// Branches and tags can only be "unpushed" if there is somewhere to push
+	if len(repo.Remotes) > 0 {
+		repo.LocalBranches = gt.branchesWithoutUpstream(ctx, repoPath, repo.Branch)
+		repo.UnpushedTags = gt.unpushedTags(ctx, repoPath)
+	}
+
+	return repo, nil
+}
+
+// readStatus sets Dirty and Untracked from `git status`
+func (gt *GitTracker) readStatus(ctx context.Context, repo *GitRepo) {
+	repo.Dirty, repo.Untracked = false, 0
+
+	// Don't let status refresh the index: that would change its mtime and
+	// invalidate the cache fingerprint on every scan
+	output, err := gt.gitEnv(ctx, repo.Path, []string{"GIT_OPTIONAL_LOCKS=0"}, "status", "--porcelain")
+	if err != nil {
+		return
+	}
+	repo.Dirty = len(strings.TrimSpace(string(output))) > 0
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.HasPrefix(line, "??") {
+			repo.Untracked++
+		}
+	}
+}
+
+// git runs a git command in repoPath, killing it after the command timeout
+func (gt *GitTracker) git(ctx context.Context, repoPath string, args ...string) ([]byte, error) {
+	return gt.gitEnv(ctx, repoPath, nil, args...)
+}
+
+func (gt *GitTracker) gitEnv(ctx context.Context, repoPath string, env []string, args ...string) ([]byte, error) {
+	timeout := gt.cmdTimeout
+	if timeout <= 0 {
+		timeout = DefaultCommandTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", repoPath}, args...)...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	return cmd.Output()
+}
+
+// gitLines runs a git command and returns its non-empty output lines
+func (gt *GitTracker) gitLines(ctx context.Context, repoPath string, args ...string) []string {
+	output, err := gt.git(ctx, repoPath, args...)
+	if err != nil {
+		return nil
+	}
+	var lines []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// branchesWithoutUpstream lists local branches, other than current, that
+// don't track a remote branch
+func (gt *GitTracker) branchesWithoutUpstream(ctx context.Context, repoPath, current string) []string {
+	var branches []string
+	for _, line := range gt.gitLines(ctx, repoPath, "for-each-ref", "--format=%(refname:short) %(upstream)", "refs/heads") {
+		fields := strings.Fields(line)
+		if len(fields) == 1 && fields[0] != current {
+			branches = append(branches, fields[0])
+		}
+	}
+	return branches
+}
+
+// unpushedTags lists tags pointing at commits that no remote-tracking branch contains
+func (gt *GitTracker) unpushedTags(ctx context.Context, repoPath string) []string {
+	local := make(map[string]bool)
+	for _, commit := range gt.gitLines(ctx, repoPath, "rev-list", "--tags", "--not", "--remotes") {
+		local[commit] = true
+	}
+	if len(local) == 0 {
+		return nil
+	}
+
+	var tags []string
+	// %(*objectname) is the commit behind an annotated tag; empty for lightweight tags
+	for _, line := range gt.gitLines(ctx, repoPath, "for-each-ref", "--format=%(refname:short) %(objectname) %(*objectname)", "refs/tags") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		if local[fields[len(fields)-1]] {
+			tags = append(tags, fields[0])
+		}
+	}
+	return tags
+}
+
+// linkedWorktrees lists worktrees added with `git worktree add`
+func (gt *GitTracker) linkedWorktrees(ctx context.Context, repoPath string) []string {
+	var worktrees []string
+	for i, line := range gt.gitLines(ctx, repoPath, "worktree", "list", "--porcelain") {
+		if path, ok := strings.CutPrefix(line, "worktree "); ok && i > 0 {
+			worktrees = append(worktrees, path)
+		}
+	}
+	return worktrees
+}
+
+// getAheadBehind returns the number of commits ahead and behind the upstream
+func (gt *GitTracker) getAheadBehind(ctx context.Context, repoPath string) (ahead, behind int, hasUpstream bool) {
+	// Check if there's an upstream branch configured
+	if _, err := gt.git(ctx, repoPath, "rev-parse", "--abbrev-ref", "@{upstream}"); err != nil {
+		// No upstream configured
+		return 0, 0, false
+	}
+
+	// Get ahead/behind counts
+	output, err := gt.git(ctx, repoPath, "rev-list", "--left-right", "--count", "@{upstream}...HEAD")
+	if err != nil {
+		return 0, 0, true // Upstream exists but couldn't get counts
+	}
+
+	// Parse output: "behind\tahead"
+	parts := strings.Fields(strings.TrimSpace(string(output)))
+	if len(parts) >= 2 {
+		fmt.Sscanf(parts[0], "%d", &behind)
+		fmt.Sscanf(parts[1], "%d", &ahead)
+	}
+
+	return ahead, behind, true
+}
@@ -0,0 +1,208 @@
+// Package gpg backs up GPG keys with gpg itself: public and secret keys are
+// exported armored and owner trust as gpg writes it, so a backup holds
+// portable key material instead of a copy of ~/.gnupg with agent sockets,
+// lock files and keybox state tied to one gpg version.
+package gpg
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Files written by Export
+const (
+	PublicKeysFile = "public-keys.asc"
+	SecretKeysFile = "secret-keys.asc"
+	OwnerTrustFile = "ownertrust.txt"
+)
+
+// configFiles are copied alongside the exported keys
+var configFiles = []string{"gpg.conf", "gpg-agent.conf", "dirmngr.conf", "scdaemon.conf", "sshcontrol"}
+
+// HomeDir returns the GPG home directory: $GNUPGHOME, else ~/.gnupg
+func HomeDir() string {
+	if dir := os.Getenv("GNUPGHOME"); dir != "" {
+		return dir
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".gnupg")
+}
+
+// Available reports whether gpg is installed
+func Available() bool {
+	_, err := exec.LookPath("gpg")
+	return err == nil
+}
+
+// IsRuntimeFile reports whether a file in the GPG home is agent or lock
+// state that shouldn't be copied: sockets, lock files and the random seed
+func IsRuntimeFile(name string) bool {
+	return strings.HasPrefix(name, "S.") ||
+		strings.HasPrefix(name, ".#lk") ||
+		strings.HasSuffix(name, ".lock") ||
+		name == "random_seed"
+}
+
+// GPGManager exports and imports the keys of one GPG home directory
+type GPGManager struct {
+	homeDir string
+}
+
+func NewGPGManager(homeDir string) *GPGManager {
+	return &GPGManager{homeDir: homeDir}
+}
+
+// Export writes the public keys, secret keys, owner trust and config files
+// to destDir and returns how many keys there are. If interactive, gpg may
+// ask for passphrases to export secret keys; otherwise it runs in batch mode
+// and the export fails for keys that need one.
+func (gm *GPGManager) Export(destDir string, interactive bool) (int, error) {
+	count, err := gm.countKeys()
+	if err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		return 0, fmt.Errorf("no GPG keys in %s", gm.homeDir)
+	}
+
+	if err := os.MkdirAll(destDir, 0700); err != nil {
+		return 0, err
+	}
+
+	public, err := gm.run(nil, "--batch", "--armor", "--export")
+	if err != nil {
+		return 0, fmt.Errorf("gpg --export failed: %w", err)
+	}
+	var secret []byte
+	if interactive {
+		// Not in batch mode, so pinentry can ask for passphrases
+		secret, err = gm.run(os.Stdin, "--armor", "--export-secret-keys")
+	} else {
+		secret, err = gm.run(nil, "--batch", "--pinentry-mode", "error", "--armor", "--export-secret-keys")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("gpg --export-secret-keys failed: %w", err)
+	}
+	trust, err := gm.run(nil, "--batch", "--export-ownertrust")
+	if err != nil {
+		return 0, fmt.Errorf("gpg --export-ownertrust failed: %w", err)
+	}
+
+	files := map[string][]byte{
+		PublicKeysFile: public,
+		SecretKeysFile: secret,
+		OwnerTrustFile: trust,
+	}
+	for name, data := range files {
+		if len(data) == 0 {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(destDir, name), data, 0600); err != nil {
+			return 0, fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	for _, name := range configFiles {
+		if data, err := os.ReadFile(filepath.Join(gm.homeDir, name)); err == nil {
+			_ = os.WriteFile(filepath.Join(destDir, name), data, 0600)
+		}
+	}
+
+	return count, nil
+}
+
+// Import creates the GPG home if needed and imports the keys and owner
+// trust Export wrote to srcDir. It returns how many keys the keyring has
+// afterwards.
+func (gm *GPGManager) Import(srcDir string) (int, error) {
+	// gpg refuses a home directory others can read
+	if err := os.MkdirAll(gm.homeDir, 0700); err != nil {
+		return 0, err
+	}
+
+	for _, name := range []string{PublicKeysFile, SecretKeysFile} {
+		path := filepath.Join(srcDir, name)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if _, err := gm.run(nil, "--batch", "--import", path); err != nil {
+			return 0, fmt.Errorf("failed to import %s: %w", name, err)
+		}
+	}
+
+	if path := filepath.Join(srcDir, OwnerTrustFile); fileExists(path) {
+		if _, err := gm.run(nil, "--batch", "--import-ownertrust", path); err != nil {
+			return 0, fmt.Errorf("failed to import owner trust: %w", err)
+		}
+	}
+
+	return gm.countKeys()
+}
+
+// RestoreConfig copies the config files Export wrote to srcDir into the GPG
+// home. Config files already there are kept and returned as skipped.
+func (gm *GPGManager) RestoreConfig(srcDir string) (skipped []string, err error) {
+	if err := os.MkdirAll(gm.homeDir, 0700); err != nil {
+		return nil, err
+	}
+
+	for _, name := range configFiles {
+		data, err := os.ReadFile(filepath.Join(srcDir, name))
+		if err != nil {
+			continue
+		}
+		dest := filepath.Join(gm.homeDir, name)
+		if fileExists(dest) {
+			skipped = append(skipped, name)
+			continue
+		}
+		if err := os.WriteFile(dest, data, 0600); err != nil {
+			return skipped, fmt.Errorf("failed to restore %s: %w", name, err)
+		}
+	}
+	return skipped, nil
+}
+
+// countKeys returns the number of public keys in the keyring
+func (gm *GPGManager) countKeys() (int, error) {
+	output, err := gm.run(nil, "--batch", "--with-colons", "--list-keys")
+	if err != nil {
+		return 0, fmt.Errorf("gpg --list-keys failed: %w", err)
+	}
+	count := 0
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.HasPrefix(line, "pub:") {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// run runs gpg on the home directory and returns its output, with the last
+// line gpg printed to stderr in the error
+func (gm *GPGManager) run(stdin *os.File, args ...string) ([]byte, error) {
+	cmd := exec.Command("gpg", append([]string{"--homedir", gm.homeDir}, args...)...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+		if last := lines[len(lines)-1]; last != "" {
+			return nil, fmt.Errorf("%v: %s", err, last)
+		}
+		return nil, err
+	}
+	return output, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -14,6 +14,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
 	"sync"
@@ -28,6 +29,8 @@ type FileInfo struct {
 	ModTime      time.Time   `json:"mod_time"`
 	Checksum     string      `json:"checksum"`
 	IsDir        bool        `json:"is_dir"`
+	GitRemote    string      `json:"git_remote,omitempty"`   // origin URL of the repo the file lives in
+	GitRelPath   string      `json:"git_rel_path,omitempty"` // path relative to that repo's root
 }
 
 type CategoryTiming struct {
@@ -42,6 +45,9 @@ type Metadata struct {
 	Timestamp        time.Time                  `json:"timestamp"`
 	Hostname         string                     `json:"hostname"`
 	Username         string                     `json:"username"`
+	HomeDir          string                     `json:"home_dir,omitempty"` // home directory on the source machine
+	Platform         string                     `json:"platform,omitempty"` // GOOS of the source machine
+	Arch             string                     `json:"arch,omitempty"`     // GOARCH of the source machine
 	Note             string                     `json:"note,omitempty"`
 	Files            []FileInfo                 `json:"files"`
 	PackageCounts    map[string]int             `json:"package_counts"`
@@ -59,12 +65,16 @@ type Metadata struct {
 func New() *Metadata {
 	hostname, _ := os.Hostname()
 	username := os.Getenv("USER")
+	homeDir, _ := os.UserHomeDir()
 
 	return &Metadata{
 		Version:       "1.1.0",
 		Timestamp:     time.Now(),
 		Hostname:      hostname,
 		Username:      username,
+		HomeDir:       homeDir,
+		Platform:      runtime.GOOS,
+		Arch:          runtime.GOARCH,
 		Files:         []FileInfo{},
 		PackageCounts: make(map[string]int),
 		Categories:    make(map[string]*CategoryTiming),
@@ -73,18 +83,26 @@ func New() *Metadata {
 }
 
 func (m *Metadata) AddFile(originalPath, backupPath string) error {
+	return m.AddRepoFile(originalPath, backupPath, "", "")
+}
+
+// AddRepoFile records a file along with the git repository it belongs to, so
+// restore can find the repository again wherever it is cloned
+func (m *Metadata) AddRepoFile(originalPath, backupPath, remoteURL, repoRelPath string) error {
 	info, err := os.Stat(originalPath)
 	if err != nil {
 		return err
 	}
 
 	fileInfo := FileInfo{
-		OriginalPath: originalPath,
+		OriginalPath: ContractHome(originalPath, m.HomeDir),
 		BackupPath:   backupPath,
 		Size:         info.Size(),
 		Mode:         info.Mode(),
 		ModTime:      info.ModTime(),
 		IsDir:        info.IsDir(),
+		GitRemote:    remoteURL,
+		GitRelPath:   repoRelPath,
 	}
 
 	if !info.IsDir() {
@@ -106,6 +124,8 @@ func (m *Metadata) AddFile(originalPath, backupPath string) error {
 }
 
 func (m *Metadata) AddFileInfo(fileInfo FileInfo) {
+	fileInfo.OriginalPath = ContractHome(fileInfo.OriginalPath, m.HomeDir)
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -142,6 +162,72 @@ func Load(path string) (*Metadata, error) {
 	return &meta, nil
 }
 
+// SourceHome returns the home directory of the machine the backup was taken
+// on. Older backups did not record it, so it is inferred from the username
+// and the stored absolute paths.
+func (m *Metadata) SourceHome() string {
+	if m.HomeDir != "" {
+		return m.HomeDir
+	}
+	if m.Username == "" {
+		return ""
+	}
+
+	for _, root := range []string{"/Users", "/home"} {
+		candidate := filepath.Join(root, m.Username)
+		for _, f := range m.Files {
+			if f.OriginalPath == candidate || strings.HasPrefix(f.OriginalPath, candidate+string(filepath.Separator)) {
+				return candidate
+			}
+		}
+	}
+	return ""
+}
+
+// SourcePlatform returns the GOOS of the machine the backup was made on.
+// Backups from before it was recorded are inferred from the home directory,
+// falling back to macOS, the only platform stash supported then.
+func (m *Metadata) SourcePlatform() string {
+	if m.Platform != "" {
+		return m.Platform
+	}
+	if strings.HasPrefix(m.SourceHome(), "/home/") {
+		return "linux"
+	}
+	return "darwin"
+}
+
+// ContractHome rewrites a path under homeDir to the portable ~/ form
+func ContractHome(path, homeDir string) string {
+	if homeDir == "" || !filepath.IsAbs(path) {
+		return path
+	}
+	rel, err := filepath.Rel(homeDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	if rel == "." {
+		return "~"
+	}
+	return "~/" + filepath.ToSlash(rel)
+}
+
+// ExpandHome rewrites a leading ~ to homeDir
+func ExpandHome(path, homeDir string) string {
+	if path == "~" {
+		return homeDir
+	}
+	if strings.HasPrefix(path, "~/") {
+		return filepath.Join(homeDir, path[2:])
+	}
+	return path
+}
+
+// Checksum returns the hex-encoded SHA256 of a file's contents
+func Checksum(path string) (string, error) {
+	return calculateChecksum(path)
+}
+
 func calculateChecksum(path string) (string, error) {
 	file, err := os.Open(path)
 	if err != nil {
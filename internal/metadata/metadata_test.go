@@ -368,3 +368,84 @@ func TestConcurrentAccess(t *testing.T) {
 	<-done
 	<-done
 }
+
+func TestAddFileStoresHomeRelativePath(t *testing.T) {
+	home := t.TempDir()
+	path := filepath.Join(home, "projects", "app", ".env")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("A=1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	meta := New()
+	meta.HomeDir = home
+	if err := meta.AddFile(path, "env-files/projects-app-.env"); err != nil {
+		t.Fatalf("AddFile failed: %v", err)
+	}
+
+	if got := meta.Files[0].OriginalPath; got != "~/projects/app/.env" {
+		t.Errorf("Expected home-relative path, got %s", got)
+	}
+}
+
+func TestContractAndExpandHome(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/Users/alice/.zshrc", "~/.zshrc"},
+		{"/Users/alice", "~"},
+		{"/Users/alicia/.zshrc", "/Users/alicia/.zshrc"},
+		{"/etc/hosts", "/etc/hosts"},
+		{"~/.ssh", "~/.ssh"},
+	}
+
+	for _, tt := range tests {
+		if got := ContractHome(tt.path, "/Users/alice"); got != tt.expected {
+			t.Errorf("ContractHome(%q) = %q, want %q", tt.path, got, tt.expected)
+		}
+	}
+
+	if got := ExpandHome("~/.ssh/config", "/home/bob"); got != "/home/bob/.ssh/config" {
+		t.Errorf("ExpandHome returned %q", got)
+	}
+}
+
+func TestSourceHome(t *testing.T) {
+	meta := &Metadata{HomeDir: "/home/bob"}
+	if got := meta.SourceHome(); got != "/home/bob" {
+		t.Errorf("Expected recorded home, got %q", got)
+	}
+
+	legacy := &Metadata{
+		Username: "alice",
+		Files:    []FileInfo{{OriginalPath: "~/.zshrc"}, {OriginalPath: "/Users/alice/projects/.env"}},
+	}
+	if got := legacy.SourceHome(); got != "/Users/alice" {
+		t.Errorf("Expected inferred home /Users/alice, got %q", got)
+	}
+
+	unknown := &Metadata{Username: "alice", Files: []FileInfo{{OriginalPath: "~/.zshrc"}}}
+	if got := unknown.SourceHome(); got != "" {
+		t.Errorf("Expected no home, got %q", got)
+	}
+}
+
+func TestSourcePlatform(t *testing.T) {
+	tests := []struct {
+		meta *Metadata
+		want string
+	}{
+		{&Metadata{Platform: "linux", HomeDir: "/Users/alice"}, "linux"},
+		{&Metadata{HomeDir: "/home/bob"}, "linux"},
+		{&Metadata{HomeDir: "/Users/alice"}, "darwin"},
+		{&Metadata{}, "darwin"},
+	}
+	for _, tt := range tests {
+		if got := tt.meta.SourcePlatform(); got != tt.want {
+			t.Errorf("SourcePlatform() for %+v = %q, want %q", tt.meta, got, tt.want)
+		}
+	}
+}
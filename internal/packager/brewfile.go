@@ -1,99 +1,279 @@
 package packager
 
 import (
-	"bufio"
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
 )
 
+// brewfileTypes are the Brewfile entries that install something. Anything
+// else, such as cask_args or an if block, is kept as written.
+var brewfileTypes = map[string]bool{
+	"tap":       true,
+	"brew":      true,
+	"cask":      true,
+	"mas":       true,
+	"whalebrew": true,
+	"vscode":    true,
+	"go":        true,
+	"cargo":     true,
+	"flatpak":   true,
+}
+
+// brewfileEntryPattern matches `type "name"` with an optional `, options` tail
+var brewfileEntryPattern = regexp.MustCompile(`^\s*([a-z_]+)\s+(?:"([^"]*)"|'([^']*)')\s*(?:,\s*(.*))?$`)
+
 // BrewfileItem represents a single item in a Brewfile
 type BrewfileItem struct {
-	Type    string // "tap", "brew", "cask", "mas"
+	Type    string // "tap", "brew", "cask", "mas", "whalebrew", "vscode", ...
 	Name    string // package name
-	RawLine string // original line from Brewfile
+	Options []BrewfileOption
+	RawLine string // the entry as written, which may span several lines
+}
+
+// BrewfileOption is a keyword argument of an entry, such as
+// `restart_service: :changed`. Value is the Ruby source text.
+type BrewfileOption struct {
+	Key   string
+	Value string
+}
+
+// Option returns the source text of the named option
+func (item BrewfileItem) Option(key string) (string, bool) {
+	for _, option := range item.Options {
+		if option.Key == key {
+			return option.Value, true
+		}
+	}
+	return "", false
+}
+
+// Tap returns the tap a formula or cask comes from, or the tap itself for
+// tap entries. Other entry types have no tap.
+func (item BrewfileItem) Tap() string {
+	switch item.Type {
+	case "tap":
+		return item.Name
+	case "brew", "cask":
+		if parts := strings.Split(item.Name, "/"); len(parts) == 3 {
+			return parts[0] + "/" + parts[1]
+		}
+		if item.Type == "cask" {
+			return "homebrew/cask"
+		}
+		return "homebrew/core"
+	}
+	return ""
+}
+
+// Brewfile is a parsed Brewfile. Writing it back gives the original text;
+// comments and Ruby that isn't an entry are kept as they are.
+type Brewfile struct {
+	Items []BrewfileItem
+	// chunks is the file split into entries and other lines, in order
+	chunks []brewfileChunk
+}
+
+type brewfileChunk struct {
+	text string
+	item int // index into Items, or -1
+}
+
+// LoadBrewfile reads and parses a Brewfile
+func LoadBrewfile(path string) (*Brewfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Brewfile: %w", err)
+	}
+	return ParseBrewfileContent(string(data)), nil
+}
+
+// ParseBrewfileContent parses the text of a Brewfile
+func ParseBrewfileContent(content string) *Brewfile {
+	b := &Brewfile{}
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		text := lines[i]
+		// An entry continues while brackets are open or the line ends in a comma
+		for i+1 < len(lines) && continuesOnNextLine(text) {
+			i++
+			text += "\n" + lines[i]
+		}
+
+		item := parseBrewfileLine(text)
+		if item == nil {
+			b.chunks = append(b.chunks, brewfileChunk{text: text, item: -1})
+			continue
+		}
+		b.chunks = append(b.chunks, brewfileChunk{text: text, item: len(b.Items)})
+		b.Items = append(b.Items, *item)
+	}
+	return b
 }
 
 // ParseBrewfile parses a Brewfile and returns individual items
 func ParseBrewfile(brewfilePath string) ([]BrewfileItem, error) {
-	file, err := os.Open(brewfilePath)
+	b, err := LoadBrewfile(brewfilePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open Brewfile: %w", err)
+		return nil, err
 	}
-	defer file.Close()
+	return b.Items, nil
+}
 
-	var items []BrewfileItem
-	scanner := bufio.NewScanner(file)
+// String returns the Brewfile's text
+func (b *Brewfile) String() string {
+	texts := make([]string, len(b.chunks))
+	for i, chunk := range b.chunks {
+		texts[i] = chunk.text
+	}
+	return strings.Join(texts, "\n")
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		trimmed := strings.TrimSpace(line)
+// WriteFile writes the Brewfile to path
+func (b *Brewfile) WriteFile(path string) error {
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write Brewfile: %w", err)
+	}
+	return nil
+}
 
-		// Skip empty lines and comments
-		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+// Filter returns a copy holding only the entries keep returns true for.
+// Comments and other lines stay.
+func (b *Brewfile) Filter(keep func(BrewfileItem) bool) *Brewfile {
+	filtered := &Brewfile{}
+	for _, chunk := range b.chunks {
+		if chunk.item < 0 {
+			filtered.chunks = append(filtered.chunks, chunk)
 			continue
 		}
-
-		// Parse the line
-		item := parseBrewfileLine(trimmed)
-		if item != nil {
-			items = append(items, *item)
+		if item := b.Items[chunk.item]; keep(item) {
+			filtered.chunks = append(filtered.chunks, brewfileChunk{text: chunk.text, item: len(filtered.Items)})
+			filtered.Items = append(filtered.Items, item)
 		}
 	}
+	return filtered
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("failed to read Brewfile: %w", err)
+// Settings returns the top-level lines that change how entries install,
+// such as cask_args, so a single entry can be installed the same way
+func (b *Brewfile) Settings() []string {
+	var settings []string
+	for _, chunk := range b.chunks {
+		if chunk.item < 0 && strings.HasPrefix(chunk.text, "cask_args") {
+			settings = append(settings, chunk.text)
+		}
 	}
-
-	return items, nil
+	return settings
 }
 
-// parseBrewfileLine parses a single Brewfile line
+// parseBrewfileLine parses a single Brewfile entry
 func parseBrewfileLine(line string) *BrewfileItem {
-	// Handle different Brewfile formats
-	parts := strings.Fields(line)
-	if len(parts) < 2 {
+	code := strings.TrimSpace(stripRubyComment(line))
+	match := brewfileEntryPattern.FindStringSubmatch(strings.ReplaceAll(code, "\n", " "))
+	if match == nil || !brewfileTypes[match[1]] {
 		return nil
 	}
 
-	itemType := parts[0]
+	item := &BrewfileItem{
+		Type:    match[1],
+		Name:    match[2] + match[3],
+		RawLine: line,
+	}
+	for _, arg := range splitRubyArgs(match[4]) {
+		key, value, ok := strings.Cut(arg, ":")
+		// `key: value`; a leading colon would be a symbol, not a key
+		if !ok || key == "" || strings.ContainsAny(key, ` "'`) {
+			continue
+		}
+		item.Options = append(item.Options, BrewfileOption{Key: key, Value: strings.TrimSpace(value)})
+	}
+	return item
+}
 
-	// Extract name (remove quotes if present)
-	name := parts[1]
-	name = strings.Trim(name, `"'`)
+// continuesOnNextLine reports whether a Brewfile entry carries on past text
+func continuesOnNextLine(text string) bool {
+	code := strings.TrimSpace(stripRubyComment(text))
+	return rubyDepth(code) > 0 || strings.HasSuffix(code, ",")
+}
 
-	// Handle comma at end
-	name = strings.TrimSuffix(name, ",")
+// stripRubyComment drops a # comment that isn't inside a string
+func stripRubyComment(text string) string {
+	var quote rune
+	for i, r := range text {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return text[:i]
+		}
+	}
+	return text
+}
 
-	// Only process known types
-	if itemType == "tap" || itemType == "brew" || itemType == "cask" || itemType == "mas" {
-		return &BrewfileItem{
-			Type:    itemType,
-			Name:    name,
-			RawLine: line,
+// rubyDepth counts brackets left open in code, ignoring strings
+func rubyDepth(code string) int {
+	depth := 0
+	var quote rune
+	for _, r := range code {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '[' || r == '{' || r == '(':
+			depth++
+		case r == ']' || r == '}' || r == ')':
+			depth--
 		}
 	}
+	return depth
+}
 
-	return nil
+// splitRubyArgs splits an argument list on the commas between arguments
+func splitRubyArgs(args string) []string {
+	var parts []string
+	start := 0
+	depth := 0
+	var quote rune
+	for i, r := range args {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '[' || r == '{' || r == '(':
+			depth++
+		case r == ']' || r == '}' || r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(args[start:i]))
+			start = i + 1
+		}
+	}
+	if rest := strings.TrimSpace(args[start:]); rest != "" {
+		parts = append(parts, rest)
+	}
+	return parts
 }
 
 // CreateFilteredBrewfile creates a new Brewfile with only selected items
 func CreateFilteredBrewfile(items []BrewfileItem, outputPath string) error {
-	file, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("failed to create Brewfile: %w", err)
-	}
-	defer file.Close()
-
-	writer := bufio.NewWriter(file)
-	defer writer.Flush()
-
+	var content strings.Builder
 	for _, item := range items {
-		if _, err := writer.WriteString(item.RawLine + "\n"); err != nil {
-			return fmt.Errorf("failed to write to Brewfile: %w", err)
-		}
+		content.WriteString(item.RawLine + "\n")
+	}
+	if err := os.WriteFile(outputPath, []byte(content.String()), 0644); err != nil {
+		return fmt.Errorf("failed to create Brewfile: %w", err)
 	}
-
 	return nil
 }
 
@@ -109,9 +289,17 @@ func FormatBrewfileItem(item BrewfileItem) string {
 		icon = "📦"
 	case "mas":
 		icon = "🏪"
+	case "whalebrew":
+		icon = "🐳"
+	case "vscode":
+		icon = "🧩"
 	default:
 		icon = "  "
 	}
 
-	return fmt.Sprintf("%s %s", icon, item.Name)
+	label := fmt.Sprintf("%s %s", icon, item.Name)
+	if _, ok := item.Option("restart_service"); ok {
+		label += " (service)"
+	}
+	return label
 }
@@ -0,0 +1,125 @@
+package packager
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testBrewfile = `# Generated by stash
+cask_args appdir: "~/Applications"
+
+tap "homebrew/bundle"
+tap "hashicorp/tap"
+brew "wget"
+brew "postgresql@16", restart_service: :changed, link: true # keep running
+brew "hashicorp/tap/terraform"
+brew "vim", args: ["with-override-system-vi", "HEAD"]
+brew "emacs-plus",
+  args: [
+    "with-native-comp",
+  ]
+cask "firefox", greedy: true
+cask "homebrew/cask-fonts/font-fira-code"
+mas "Xcode", id: 497799835
+whalebrew "whalebrew/wget"
+vscode "golang.go"
+if OS.mac?
+  brew "gnu-sed"
+end
+`
+
+func TestParseBrewfileContent_RoundTrip(t *testing.T) {
+	b := ParseBrewfileContent(testBrewfile)
+	if got := b.String(); got != testBrewfile {
+		t.Errorf("Round trip changed the Brewfile:\n%s", got)
+	}
+
+	var names []string
+	for _, item := range b.Items {
+		names = append(names, item.Type+" "+item.Name)
+	}
+	want := []string{
+		"tap homebrew/bundle",
+		"tap hashicorp/tap",
+		"brew wget",
+		"brew postgresql@16",
+		"brew hashicorp/tap/terraform",
+		"brew vim",
+		"brew emacs-plus",
+		"cask firefox",
+		"cask homebrew/cask-fonts/font-fira-code",
+		"mas Xcode",
+		"whalebrew whalebrew/wget",
+		"vscode golang.go",
+		"brew gnu-sed",
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("Unexpected items: %q", names)
+	}
+}
+
+func TestParseBrewfileContent_Options(t *testing.T) {
+	b := ParseBrewfileContent(testBrewfile)
+	items := make(map[string]BrewfileItem)
+	for _, item := range b.Items {
+		items[item.Name] = item
+	}
+
+	postgres := items["postgresql@16"]
+	if !reflect.DeepEqual(postgres.Options, []BrewfileOption{{"restart_service", ":changed"}, {"link", "true"}}) {
+		t.Errorf("Unexpected postgresql options: %+v", postgres.Options)
+	}
+	if value, _ := items["vim"].Option("args"); value != `["with-override-system-vi", "HEAD"]` {
+		t.Errorf("Unexpected vim args: %q", value)
+	}
+	if value, ok := items["emacs-plus"].Option("args"); !ok || items["emacs-plus"].RawLine != "brew \"emacs-plus\",\n  args: [\n    \"with-native-comp\",\n  ]" {
+		t.Errorf("Expected the multi-line entry to be kept whole, got %q (args %q)", items["emacs-plus"].RawLine, value)
+	}
+	if value, _ := items["Xcode"].Option("id"); value != "497799835" {
+		t.Errorf("Unexpected mas id: %q", value)
+	}
+}
+
+func TestBrewfileFilter(t *testing.T) {
+	b := ParseBrewfileContent(testBrewfile)
+	filtered := b.Filter(func(item BrewfileItem) bool {
+		return item.Name == "postgresql@16" || item.Name == "firefox"
+	})
+
+	want := `# Generated by stash
+cask_args appdir: "~/Applications"
+
+brew "postgresql@16", restart_service: :changed, link: true # keep running
+cask "firefox", greedy: true
+if OS.mac?
+end
+`
+	if got := filtered.String(); got != want {
+		t.Errorf("Unexpected filtered Brewfile:\n%s", got)
+	}
+	if len(filtered.Items) != 2 || filtered.Items[1].Name != "firefox" {
+		t.Errorf("Unexpected filtered items: %+v", filtered.Items)
+	}
+	if settings := filtered.Settings(); !reflect.DeepEqual(settings, []string{`cask_args appdir: "~/Applications"`}) {
+		t.Errorf("Unexpected settings: %q", settings)
+	}
+}
+
+func TestBrewfileItemTap(t *testing.T) {
+	tests := []struct {
+		item BrewfileItem
+		want string
+	}{
+		{BrewfileItem{Type: "brew", Name: "wget"}, "homebrew/core"},
+		{BrewfileItem{Type: "brew", Name: "hashicorp/tap/terraform"}, "hashicorp/tap"},
+		{BrewfileItem{Type: "cask", Name: "firefox"}, "homebrew/cask"},
+		{BrewfileItem{Type: "cask", Name: "homebrew/cask-fonts/font-fira-code"}, "homebrew/cask-fonts"},
+		{BrewfileItem{Type: "tap", Name: "hashicorp/tap"}, "hashicorp/tap"},
+		{BrewfileItem{Type: "mas", Name: "Xcode"}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.item.Tap(); got != tt.want {
+			t.Errorf("Tap() of %s %q = %q, want %q", tt.item.Type, tt.item.Name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package packager
+
+import (
+	_ "embed"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SuggestedCasksFile is a Brewfile of casks that install the apps in
+// non-brew-apps.txt. Restore can install it like the main Brewfile.
+const SuggestedCasksFile = "Brewfile.suggested"
+
+//go:embed cask-catalog.txt
+var caskCatalogData string
+
+// caskCatalog maps lowercased app names, without .app, to cask tokens
+var caskCatalog = parseCaskCatalog(caskCatalogData)
+
+// CaskSuggestion is a cask that probably installs a manually installed app
+type CaskSuggestion struct {
+	App  string
+	Cask string
+}
+
+func parseCaskCatalog(data string) map[string]string {
+	catalog := make(map[string]string)
+	for _, line := range strings.Split(data, "\n") {
+		app, cask, ok := strings.Cut(line, "\t")
+		if !ok || strings.HasPrefix(line, "#") {
+			continue
+		}
+		catalog[strings.ToLower(app)] = strings.TrimSpace(cask)
+	}
+	return catalog
+}
+
+// guessCaskToken turns an app name into the token Homebrew would likely
+// give its cask: "Google Chrome.app" becomes "google-chrome"
+func guessCaskToken(app string) string {
+	name := strings.ToLower(strings.TrimSuffix(app, ".app"))
+	var token strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			token.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '_':
+			if !strings.HasSuffix(token.String(), "-") {
+				token.WriteRune('-')
+			}
+		}
+	}
+	return strings.Trim(token.String(), "-")
+}
+
+// SuggestCasks matches apps against the embedded catalog. Apps it doesn't
+// know get a guessed token, kept only if search finds a cask by that name;
+// search may be nil to use the catalog alone.
+func SuggestCasks(apps []string, search func(tokens []string) map[string]bool) []CaskSuggestion {
+	var suggestions []CaskSuggestion
+	guesses := make(map[string]string)
+	for _, app := range apps {
+		if cask, ok := caskCatalog[strings.ToLower(strings.TrimSuffix(app, ".app"))]; ok {
+			suggestions = append(suggestions, CaskSuggestion{App: app, Cask: cask})
+		} else if token := guessCaskToken(app); token != "" {
+			guesses[app] = token
+		}
+	}
+
+	if len(guesses) > 0 && search != nil {
+		var tokens []string
+		for _, token := range guesses {
+			tokens = append(tokens, token)
+		}
+		sort.Strings(tokens)
+		found := search(tokens)
+		for _, app := range apps {
+			if token, ok := guesses[app]; ok && found[token] {
+				suggestions = append(suggestions, CaskSuggestion{App: app, Cask: token})
+			}
+		}
+	}
+
+	sort.Slice(suggestions, func(a, b int) bool {
+		return suggestions[a].Cask < suggestions[b].Cask
+	})
+	return suggestions
+}
+
+// searchBrewCasks asks brew which of tokens are casks, with one search for
+// an exact match on any of them
+func searchBrewCasks(tokens []string) map[string]bool {
+	found := make(map[string]bool)
+	if !commandExists("brew") {
+		return found
+	}
+
+	quoted := make([]string, len(tokens))
+	for i, token := range tokens {
+		quoted[i] = regexp.QuoteMeta(token)
+	}
+	output, err := exec.Command("brew", "search", "--casks", "/^("+strings.Join(quoted, "|")+")$/").Output()
+	if err != nil {
+		return found
+	}
+
+	wanted := make(map[string]bool)
+	for _, token := range tokens {
+		wanted[token] = true
+	}
+	for _, line := range nonEmptyLines(output) {
+		// Skip "==> Casks" headings; installed casks are followed by a check mark
+		for _, field := range strings.Fields(line) {
+			if wanted[field] {
+				found[field] = true
+			}
+		}
+	}
+	return found
+}
+
+// writeCaskSuggestions writes the suggestions as a Brewfile, each cask
+// commented with the app it was matched to
+func (p *Packager) writeCaskSuggestions(suggestions []CaskSuggestion) error {
+	var lines []string
+	for _, suggestion := range suggestions {
+		lines = append(lines, fmt.Sprintf("cask %q # %s", suggestion.Cask, suggestion.App))
+	}
+	header := "# Casks that install apps found outside Homebrew (see non-brew-apps.txt)\n# Check them before installing with: brew bundle --file=" + SuggestedCasksFile
+	return p.writeList(SuggestedCasksFile, header, lines)
+}
+
+// masAppNames returns the names of the App Store apps collected alongside,
+// which mas reinstalls, so they don't need a cask
+func (p *Packager) masAppNames() map[string]bool {
+	names := make(map[string]bool)
+	lines, err := masNames(filepath.Join(p.outputDir, "mas-apps.txt"))
+	if err != nil {
+		return names
+	}
+	for _, line := range lines {
+		if _, name, ok := strings.Cut(line, " "); ok {
+			names[strings.ToLower(name)] = true
+		}
+	}
+	return names
+}
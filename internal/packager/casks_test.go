@@ -0,0 +1,77 @@
+package packager
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGuessCaskToken(t *testing.T) {
+	tests := map[string]string{
+		"Google Chrome.app":  "google-chrome",
+		"Notion Calendar":    "notion-calendar",
+		"Postgres.app":       "postgres",
+		"Hand_Brake (1).app": "hand-brake-1",
+		"Señor.app":          "seor",
+		"Foo - Bar.app":      "foo-bar",
+	}
+	for app, want := range tests {
+		if got := guessCaskToken(app); got != want {
+			t.Errorf("guessCaskToken(%q) = %q, want %q", app, got, want)
+		}
+	}
+}
+
+func TestDetectNonBrewApps(t *testing.T) {
+	bin := t.TempDir()
+	t.Setenv("PATH", bin)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	for _, app := range []string{"Firefox.app", "Visual Studio Code.app", "zoom.us.app", "Notion Calendar.app", "Homemade Tool.app", "Xcode.app"} {
+		if err := os.MkdirAll(filepath.Join(home, "Applications", app), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	args := filepath.Join(t.TempDir(), "search-args")
+	fakeCommand(t, bin, "brew", `case "$1" in
+list) echo firefox ;;
+search) echo "$3" > `+args+`; echo "==> Casks"; echo "notion-calendar" ;;
+esac`)
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "mas-apps.txt"), []byte("497799835  Xcode  (15.2)\n"), 0644)
+	if err := NewPackager(dir).DetectNonBrewApps(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Catalog matches need no search; only guesses are looked up
+	data, _ := os.ReadFile(args)
+	if got := strings.TrimSpace(string(data)); got != "/^(homemade-tool|notion-calendar)$/" {
+		t.Errorf("Unexpected brew search pattern: %q", got)
+	}
+
+	brewfile, err := LoadBrewfile(filepath.Join(dir, SuggestedCasksFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var casks []string
+	for _, item := range brewfile.Items {
+		casks = append(casks, item.Type+" "+item.Name)
+	}
+	want := []string{"cask notion-calendar", "cask visual-studio-code", "cask zoom"}
+	if !reflect.DeepEqual(casks, want) {
+		t.Errorf("Unexpected suggestions: %q", casks)
+	}
+	if !strings.Contains(brewfile.String(), `cask "zoom" # zoom.us.app`) {
+		t.Errorf("Expected each cask to name its app:\n%s", brewfile.String())
+	}
+
+	nonBrew, _ := os.ReadFile(filepath.Join(dir, "non-brew-apps.txt"))
+	if strings.Contains(string(nonBrew), "Firefox.app") || !strings.Contains(string(nonBrew), "Homemade Tool.app") {
+		t.Errorf("Unexpected non-brew apps:\n%s", nonBrew)
+	}
+}
@@ -0,0 +1,154 @@
+package packager
+
+import (
+	"debug/buildinfo"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"github.com/harshpatel5940/stash/internal/ui"
+)
+
+// GoBinariesFile lists binaries installed with `go install`, one path@version per line
+const GoBinariesFile = "go-binaries.txt"
+
+// goBinDir returns where `go install` puts binaries: $GOBIN, else the bin
+// directory of the first $GOPATH entry, else ~/go/bin
+func goBinDir() string {
+	if dir := os.Getenv("GOBIN"); dir != "" {
+		return dir
+	}
+	if gopath := os.Getenv("GOPATH"); gopath != "" {
+		return filepath.Join(filepath.SplitList(gopath)[0], "bin")
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, "go", "bin")
+}
+
+// CollectGoBinaries reads the module path and version embedded in every Go
+// binary in the install directory. It doesn't need Go itself, so it works
+// on machines where only the binaries are left.
+func (p *Packager) CollectGoBinaries() error {
+	dir := goBinDir()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("no Go binaries in %s", dir)
+	}
+
+	var installable, local []string
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := buildinfo.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			// Not a Go binary
+			continue
+		}
+		if line, ok := goInstallTarget(info); ok {
+			installable = append(installable, line)
+		} else {
+			local = append(local, fmt.Sprintf("# %s: built from a local checkout of %s", entry.Name(), info.Path))
+		}
+	}
+
+	if len(installable)+len(local) == 0 {
+		return fmt.Errorf("no Go binaries in %s", dir)
+	}
+
+	sort.Strings(installable)
+	sort.Strings(local)
+	return p.writeList(GoBinariesFile, "", append(installable, local...))
+}
+
+// goInstallTarget returns the path@version argument that reinstalls the
+// binary, or false for binaries without a published version
+func goInstallTarget(info *debug.BuildInfo) (string, bool) {
+	version := info.Main.Version
+	if info.Path == "" || version == "" || version == "(devel)" {
+		return "", false
+	}
+	// Go 1.24 stamps checkouts with uncommitted changes as +dirty
+	if strings.HasSuffix(version, "+dirty") {
+		return "", false
+	}
+	// A replaced main module was built from somewhere else, so the version
+	// recorded for it can't be fetched
+	if info.Main.Replace != nil {
+		return "", false
+	}
+	return info.Path + "@" + version, true
+}
+
+// LoadGoBinaries reads the path@version targets from a Go binaries list,
+// skipping binaries that were noted as built locally
+func LoadGoBinaries(path string) ([]string, error) {
+	return readNonEmptyLines(path)
+}
+
+// goTarget returns the go install argument for a recorded path@version:
+// the version itself when restoring exact versions, otherwise @latest
+func goTarget(target string, lock *Lock) string {
+	if lock != nil {
+		return target
+	}
+	path, _, _ := strings.Cut(target, "@")
+	return path + "@latest"
+}
+
+func goInstallCommand(target string) *exec.Cmd {
+	return exec.Command("go", "install", target)
+}
+
+// InstallGoBinaries runs `go install path@version` for each binary in the
+// list. Without a lock (see UseLock) binaries are updated to @latest.
+func (i *Installer) InstallGoBinaries(goBinariesPath string) (int, error) {
+	if !commandExists("go") {
+		return 0, fmt.Errorf("go not found - install Go first")
+	}
+
+	all, err := LoadGoBinaries(goBinariesPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read Go binaries file: %w", err)
+	}
+	var targets []string
+	for _, target := range all {
+		if path, _, _ := strings.Cut(target, "@"); i.wanted(path) {
+			targets = append(targets, target)
+		}
+	}
+
+	if len(targets) == 0 {
+		fmt.Println("  No Go binaries found in file")
+		return 0, nil
+	}
+
+	fmt.Printf("  Installing %d Go binaries...\n", len(targets))
+
+	bar := ui.NewProgressBar(len(targets), "Go")
+
+	installed := 0
+	var failed []string
+	for _, target := range targets {
+		target = goTarget(target, i.lock)
+		if err := i.runCommand(goInstallCommand(target)); err != nil {
+			failed = append(failed, target)
+			if i.verbose {
+				fmt.Printf("    Failed to install %s: %v\n", target, err)
+			}
+		} else {
+			installed++
+		}
+		bar.Add(1)
+	}
+
+	bar.Finish()
+	if len(failed) > 0 {
+		return installed, fmt.Errorf("failed: %s", strings.Join(failed, ", "))
+	}
+	return installed, nil
+}
@@ -0,0 +1,126 @@
+package packager
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestGoInstallTarget(t *testing.T) {
+	tests := []struct {
+		name string
+		info debug.BuildInfo
+		want string
+		ok   bool
+	}{
+		{
+			name: "published",
+			info: debug.BuildInfo{Path: "golang.org/x/tools/gopls", Main: debug.Module{Path: "golang.org/x/tools/gopls", Version: "v0.15.3"}},
+			want: "golang.org/x/tools/gopls@v0.15.3",
+			ok:   true,
+		},
+		{
+			name: "package below the module root",
+			info: debug.BuildInfo{Path: "github.com/go-delve/delve/cmd/dlv", Main: debug.Module{Path: "github.com/go-delve/delve", Version: "v1.22.1"}},
+			want: "github.com/go-delve/delve/cmd/dlv@v1.22.1",
+			ok:   true,
+		},
+		{
+			name: "built from a checkout",
+			info: debug.BuildInfo{Path: "example.com/tool", Main: debug.Module{Path: "example.com/tool", Version: "(devel)"}},
+		},
+		{
+			name: "checkout with local changes",
+			info: debug.BuildInfo{Path: "example.com/tool", Main: debug.Module{Path: "example.com/tool", Version: "v1.2.1-0.20240101000000-abcdef123456+dirty"}},
+		},
+		{
+			name: "replaced main module",
+			info: debug.BuildInfo{Path: "example.com/tool", Main: debug.Module{Path: "example.com/tool", Version: "v1.0.0", Replace: &debug.Module{Path: "../tool"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := goInstallTarget(&tt.info)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("goInstallTarget() = %q, %v, want %q, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCollectGoBinaries(t *testing.T) {
+	gobin := t.TempDir()
+	t.Setenv("GOBIN", gobin)
+
+	// The test binary is a Go binary built from this checkout
+	self, err := os.ReadFile(os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(gobin, "local-tool"), self, 0755)
+	os.WriteFile(filepath.Join(gobin, "script"), []byte("#!/bin/sh\n"), 0755)
+
+	out := t.TempDir()
+	p := NewPackager(out)
+	if err := p.CollectGoBinaries(); err != nil {
+		t.Fatalf("CollectGoBinaries() failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(out, GoBinariesFile))
+	if !strings.HasPrefix(string(data), "# local-tool: built from a local checkout") {
+		t.Errorf("Expected local binary to be noted, got %q", data)
+	}
+	if count := p.countLines(filepath.Join(out, GoBinariesFile)); count != 0 {
+		t.Errorf("Expected no installable binaries, got %d", count)
+	}
+}
+
+func TestCollectGoBinaries_Empty(t *testing.T) {
+	t.Setenv("GOBIN", t.TempDir())
+
+	if err := NewPackager(t.TempDir()).CollectGoBinaries(); err == nil {
+		t.Error("Expected error when GOBIN has no Go binaries")
+	}
+}
+
+func TestInstallGoBinaries(t *testing.T) {
+	bin := t.TempDir()
+	t.Setenv("PATH", bin)
+	log := filepath.Join(t.TempDir(), "commands.log")
+
+	fakeCommand(t, bin, "go", `echo "go $*" >> `+log+`
+case "$*" in *gone*) echo "go: module example.com/gone: not found"; exit 1 ;; esac`)
+
+	list := filepath.Join(t.TempDir(), GoBinariesFile)
+	os.WriteFile(list, []byte("example.com/gone@v1.0.0\ngolang.org/x/tools/gopls@v0.15.3\n# tool: built from a local checkout of example.com/tool\n"), 0644)
+
+	// Binaries keep their recorded version when restoring exact versions
+	installer := NewInstaller(false)
+	installer.UseLock(&Lock{})
+	installed, err := installer.InstallGoBinaries(list)
+	if installed != 1 {
+		t.Errorf("Expected 1 binary installed, got %d", installed)
+	}
+	if err == nil || !strings.Contains(err.Error(), "example.com/gone@v1.0.0") {
+		t.Errorf("Expected error naming the failed binary, got %v", err)
+	}
+
+	data, _ := os.ReadFile(log)
+	want := []string{"go install example.com/gone@v1.0.0", "go install golang.org/x/tools/gopls@v0.15.3"}
+	if got := nonEmptyLines(data); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected commands:\n%s", strings.Join(got, "\n"))
+	}
+
+	os.Remove(log)
+	installer.UseLock(nil)
+	installer.InstallGoBinaries(list)
+	data, _ = os.ReadFile(log)
+	want = []string{"go install example.com/gone@latest", "go install golang.org/x/tools/gopls@latest"}
+	if got := nonEmptyLines(data); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected commands without a lock:\n%s", strings.Join(got, "\n"))
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/harshpatel5940/stash/internal/ui"
 )
@@ -17,6 +18,14 @@ import (
 // Installer provides progress-wrapped package installation
 type Installer struct {
 	verbose bool
+	// lock pins packages to the versions in the backup; nil installs the latest
+	lock *Lock
+	// only narrows installs down to these package names; nil installs everything
+	only map[string]bool
+	// workers, retries and backoff apply to install plans (see RunPlan)
+	workers int
+	retries int
+	backoff time.Duration
 }
 
 // BrewInstallError captures brew bundle failure details for restore summaries.
@@ -42,6 +51,32 @@ func NewInstaller(verbose bool) *Installer {
 	return &Installer{verbose: verbose}
 }
 
+// UseLock makes later installs pick the versions recorded in lock where the
+// package manager can install a specific version. Passing nil goes back to
+// installing the latest versions.
+func (i *Installer) UseLock(lock *Lock) {
+	i.lock = lock
+}
+
+// wanted reports whether the package called name should be installed
+func (i *Installer) wanted(name string) bool {
+	return i.only == nil || i.only[name]
+}
+
+// wantedLines keeps the list lines whose first field names a wanted package
+func (i *Installer) wantedLines(lines []string) []string {
+	if i.only == nil {
+		return lines
+	}
+	var kept []string
+	for _, line := range lines {
+		if fields := strings.Fields(line); len(fields) > 0 && i.only[fields[0]] {
+			kept = append(kept, line)
+		}
+	}
+	return kept
+}
+
 // InstallBrewPackages installs Homebrew packages from a Brewfile with progress
 func (i *Installer) InstallBrewPackages(brewfilePath string) error {
 	if !commandExists("brew") {
@@ -202,6 +237,7 @@ func (i *Installer) InstallVSCodeExtensions(extensionsPath string) (int, error)
 	if err != nil {
 		return 0, fmt.Errorf("failed to read extensions file: %w", err)
 	}
+	extensions = i.wantedLines(extensions)
 
 	if len(extensions) == 0 {
 		fmt.Println("  No extensions found in file")
@@ -247,6 +283,7 @@ func (i *Installer) InstallMASApps(masFilePath string) (int, error) {
 	if err != nil {
 		return 0, fmt.Errorf("failed to read MAS file: %w", err)
 	}
+	lines = i.wantedLines(lines)
 
 	if len(lines) == 0 {
 		fmt.Println("  No apps found in file")
@@ -289,28 +326,47 @@ func (i *Installer) InstallMASApps(masFilePath string) (int, error) {
 	return installed, nil
 }
 
-// InstallNPMPackages shows the NPM package list (manual install recommended)
-func (i *Installer) InstallNPMPackages(npmFilePath string) error {
+// InstallNPMPackages installs the global npm packages listed in npmFilePath
+// with npm install -g, at their locked versions when a lock is in use. It
+// returns how many were installed.
+func (i *Installer) InstallNPMPackages(npmFilePath string) (int, error) {
 	if !commandExists("npm") {
-		return fmt.Errorf("npm not found - install Node.js first")
+		return 0, fmt.Errorf("npm not found - install Node.js first")
 	}
 
-	// Read NPM packages list
-	lines, err := readNonEmptyLines(npmFilePath)
+	names, err := npmNames(npmFilePath)
 	if err != nil {
-		return fmt.Errorf("failed to read NPM file: %w", err)
+		return 0, fmt.Errorf("failed to read NPM file: %w", err)
 	}
 
-	if len(lines) == 0 {
+	var targets []string
+	for _, name := range names {
+		if !i.wanted(name) {
+			continue
+		}
+		targets = append(targets, npmTarget(name, i.lock))
+	}
+
+	if len(targets) == 0 {
 		fmt.Println("  No packages found in file")
-		return nil
+		return 0, nil
 	}
 
-	fmt.Printf("  Found %d NPM global packages\n", len(lines))
-	fmt.Println("  NPM global packages list saved at:", npmFilePath)
-	fmt.Println("  💡 Review and install manually with: npm install -g <package>")
+	fmt.Printf("  Installing %d NPM global packages...\n", len(targets))
+	return i.installEach(ManagerNPM, targets, npmInstallCommand)
+}
 
-	return nil
+// npmTarget returns the npm install argument for a package, pinned to its
+// locked version if there is one
+func npmTarget(name string, lock *Lock) string {
+	if version := lock.Version(ManagerNPM, name); version != "" {
+		return name + "@" + version
+	}
+	return name
+}
+
+func npmInstallCommand(pkg string) *exec.Cmd {
+	return exec.Command("npm", "install", "-g", pkg)
 }
 
 // countBrewfilePackages counts packages in a Brewfile
@@ -0,0 +1,485 @@
+package packager
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Language package managers. Their lists are written by CollectAll; pip,
+// cargo, pnpm, gem and composer as raw command output, pipx and uv as
+// ToolEnv JSON.
+const (
+	ManagerPip      = "pip"
+	ManagerPipx     = "pipx"
+	ManagerUv       = "uv"
+	ManagerCargo    = "cargo"
+	ManagerGem      = "gem"
+	ManagerPnpm     = "pnpm"
+	ManagerComposer = "composer"
+)
+
+// LanguageManager describes a language package list that can be reinstalled
+type LanguageManager struct {
+	Name  string
+	Label string
+	File  string
+	// Default is whether restore selects it without asking. pip and gem
+	// lists include every dependency, so they're opt-in.
+	Default bool
+}
+
+// LanguageManagers lists the language package managers in install order
+var LanguageManagers = []LanguageManager{
+	{Name: ManagerPipx, Label: "pipx tools", File: "pipx-tools.json", Default: true},
+	{Name: ManagerUv, Label: "uv tools", File: "uv-tools.json", Default: true},
+	{Name: ManagerPip, Label: "pip packages", File: "pip-requirements.txt"},
+	{Name: ManagerCargo, Label: "Cargo crates", File: "cargo-packages.txt", Default: true},
+	{Name: ManagerGem, Label: "Ruby gems", File: "gem-packages.txt"},
+	{Name: ManagerPnpm, Label: "pnpm globals", File: "pnpm-global.txt", Default: true},
+	{Name: ManagerComposer, Label: "Composer globals", File: "composer-global.txt", Default: true},
+}
+
+// LookupLanguageManager returns the manager with the given name
+func LookupLanguageManager(name string) (LanguageManager, bool) {
+	for _, m := range LanguageManagers {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return LanguageManager{}, false
+}
+
+// ToolEnv is an application installed into its own environment by pipx or uv
+type ToolEnv struct {
+	Name string `json:"name"`
+	// Spec is what to install: a package name with optional extras and
+	// version specifier, or a URL
+	Spec    string `json:"spec"`
+	Version string `json:"version,omitempty"`
+	Python  string `json:"python,omitempty"`
+	// Suffix is pipx's --suffix, for side-by-side versions
+	Suffix string `json:"suffix,omitempty"`
+	// Injected are extra packages installed into the environment with
+	// `pipx inject` or `uv tool install --with`
+	Injected []string `json:"injected,omitempty"`
+}
+
+// pipxList is the part of `pipx list --json` that's needed to reinstall
+type pipxList struct {
+	Venvs map[string]struct {
+		Metadata struct {
+			MainPackage      pipxPackage            `json:"main_package"`
+			InjectedPackages map[string]pipxPackage `json:"injected_packages"`
+			PythonVersion    string                 `json:"python_version"`
+		} `json:"metadata"`
+	} `json:"venvs"`
+}
+
+type pipxPackage struct {
+	Package        string `json:"package"`
+	PackageOrURL   string `json:"package_or_url"`
+	PackageVersion string `json:"package_version"`
+	Suffix         string `json:"suffix"`
+}
+
+// CollectPipx records pipx applications and the packages injected into them
+func (p *Packager) CollectPipx() error {
+	if !commandExists("pipx") {
+		return fmt.Errorf("pipx not installed")
+	}
+
+	output, err := exec.Command("pipx", "list", "--json").Output()
+	if err != nil {
+		return fmt.Errorf("pipx list failed: %v", err)
+	}
+
+	var list pipxList
+	if err := json.Unmarshal(output, &list); err != nil {
+		return fmt.Errorf("failed to parse pipx list: %w", err)
+	}
+
+	var tools []ToolEnv
+	for _, name := range sortedMapKeys(list.Venvs) {
+		meta := list.Venvs[name].Metadata
+		tool := ToolEnv{
+			Name:    name,
+			Spec:    meta.MainPackage.PackageOrURL,
+			Version: meta.MainPackage.PackageVersion,
+			Python:  strings.TrimPrefix(meta.PythonVersion, "Python "),
+			Suffix:  meta.MainPackage.Suffix,
+		}
+		if tool.Spec == "" {
+			tool.Spec = meta.MainPackage.Package
+		}
+		for _, injected := range sortedMapKeys(meta.InjectedPackages) {
+			pkg := meta.InjectedPackages[injected]
+			if pkg.PackageOrURL != "" {
+				injected = pkg.PackageOrURL
+			}
+			tool.Injected = append(tool.Injected, injected)
+		}
+		tools = append(tools, tool)
+	}
+
+	return p.writeToolEnvs(ManagerPipx, tools)
+}
+
+// uvReceipt is the part of a uv tool's uv-receipt.toml that's needed to reinstall
+type uvReceipt struct {
+	Tool struct {
+		Requirements []uvRequirement `toml:"requirements"`
+		Python       string          `toml:"python"`
+	} `toml:"tool"`
+}
+
+type uvRequirement struct {
+	Name      string   `toml:"name"`
+	Extras    []string `toml:"extras"`
+	Specifier string   `toml:"specifier"`
+	Git       string   `toml:"git"`
+	URL       string   `toml:"url"`
+	Path      string   `toml:"path"`
+}
+
+// spec turns a requirement back into the argument `uv tool install` takes
+func (r uvRequirement) spec() string {
+	switch {
+	case r.Git != "":
+		return "git+" + r.Git
+	case r.URL != "":
+		return r.URL
+	case r.Path != "":
+		return r.Path
+	}
+	spec := r.Name
+	if len(r.Extras) > 0 {
+		spec += "[" + strings.Join(r.Extras, ",") + "]"
+	}
+	return spec + r.Specifier
+}
+
+// CollectUvTools records `uv tool` environments. uv doesn't list the
+// packages added with --with, so they're read from each tool's receipt.
+func (p *Packager) CollectUvTools() error {
+	if !commandExists("uv") {
+		return fmt.Errorf("uv not installed")
+	}
+
+	dirOutput, err := exec.Command("uv", "tool", "dir").Output()
+	if err != nil {
+		return fmt.Errorf("uv tool dir failed: %v", err)
+	}
+	toolDir := strings.TrimSpace(string(dirOutput))
+
+	// "ruff v0.4.1" lines, each followed by "- entrypoint" lines
+	versions := make(map[string]string)
+	if listOutput, err := exec.Command("uv", "tool", "list").Output(); err == nil {
+		for _, line := range nonEmptyLines(listOutput) {
+			fields := strings.Fields(line)
+			if len(fields) >= 2 && fields[0] != "-" {
+				versions[fields[0]] = strings.TrimPrefix(fields[1], "v")
+			}
+		}
+	}
+
+	entries, err := os.ReadDir(toolDir)
+	if err != nil {
+		return fmt.Errorf("no uv tools installed")
+	}
+
+	var tools []ToolEnv
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(toolDir, entry.Name(), "uv-receipt.toml"))
+		if err != nil {
+			continue
+		}
+		var receipt uvReceipt
+		if err := toml.Unmarshal(data, &receipt); err != nil || len(receipt.Tool.Requirements) == 0 {
+			continue
+		}
+
+		// The first requirement is the tool, the rest came from --with
+		reqs := receipt.Tool.Requirements
+		tool := ToolEnv{
+			Name:    entry.Name(),
+			Spec:    reqs[0].spec(),
+			Version: versions[entry.Name()],
+			Python:  receipt.Tool.Python,
+		}
+		for _, req := range reqs[1:] {
+			tool.Injected = append(tool.Injected, req.spec())
+		}
+		tools = append(tools, tool)
+	}
+
+	return p.writeToolEnvs(ManagerUv, tools)
+}
+
+func (p *Packager) writeToolEnvs(manager string, tools []ToolEnv) error {
+	if len(tools) == 0 {
+		return fmt.Errorf("no %s tools installed", manager)
+	}
+	data, err := json.MarshalIndent(tools, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s tools: %w", manager, err)
+	}
+	m, _ := LookupLanguageManager(manager)
+	return os.WriteFile(filepath.Join(p.outputDir, m.File), data, 0644)
+}
+
+// LoadToolEnvs reads a pipx or uv tools list
+func LoadToolEnvs(path string) ([]ToolEnv, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var tools []ToolEnv
+	if err := json.Unmarshal(data, &tools); err != nil {
+		return nil, fmt.Errorf("failed to parse tools: %w", err)
+	}
+	return tools, nil
+}
+
+// languagePackage is one entry in a language package list
+type languagePackage struct {
+	Name    string
+	Version string
+	// Git is the repository a cargo crate was installed from
+	Git string
+}
+
+// LanguagePackages returns what installing a manager's list would install,
+// one entry per package, at the latest version. Packages that can't be
+// reinstalled from a registry, such as editable pip installs or local cargo
+// crates, are left out.
+func LanguagePackages(manager, path string) ([]string, error) {
+	return LanguageTargets(manager, path, nil)
+}
+
+// LanguageTargets is LanguagePackages, pinning every package that has a
+// version in lock. A nil lock installs the latest versions.
+func LanguageTargets(manager, path string, lock *Lock) ([]string, error) {
+	return languageTargets(manager, path, lock, nil)
+}
+
+// languageTargets is LanguageTargets for the packages keep returns true
+// for, or every package when keep is nil
+func languageTargets(manager, path string, lock *Lock, keep func(name string) bool) ([]string, error) {
+	if manager == ManagerPipx || manager == ManagerUv {
+		tools, err := LoadToolEnvs(path)
+		if err != nil {
+			return nil, err
+		}
+		var specs []string
+		for _, tool := range tools {
+			if keep == nil || keep(tool.Name) {
+				specs = append(specs, pinnedSpec(tool.Spec, lock.Version(manager, tool.Name)))
+			}
+		}
+		return specs, nil
+	}
+
+	packages, err := readLanguageList(manager, path)
+	if err != nil {
+		return nil, err
+	}
+	var targets []string
+	for _, pkg := range packages {
+		if keep == nil || keep(pkg.Name) {
+			targets = append(targets, languageTarget(manager, pkg, lock.Version(manager, pkg.Name)))
+		}
+	}
+	return targets, nil
+}
+
+// languageTarget builds the install argument for pkg, pinned to version
+// when it isn't empty
+func languageTarget(manager string, pkg languagePackage, version string) string {
+	if pkg.Git != "" {
+		return pkg.Name + " --git " + pkg.Git
+	}
+	if version == "" {
+		return pkg.Name
+	}
+	switch manager {
+	case ManagerPip:
+		return pkg.Name + "==" + version
+	case ManagerCargo:
+		return pkg.Name + " --version " + version
+	case ManagerGem:
+		return pkg.Name + " -v " + version
+	case ManagerPnpm:
+		return pkg.Name + "@" + version
+	case ManagerComposer:
+		return pkg.Name + ":" + version
+	}
+	return pkg.Name
+}
+
+// pinnedSpec pins a pipx or uv spec that is a plain package name. Specs that
+// already carry a specifier or point at a URL are kept as they are.
+func pinnedSpec(spec, version string) string {
+	if version == "" || strings.ContainsAny(spec, "<>=!~@:/ ") {
+		return spec
+	}
+	return spec + "==" + version
+}
+
+// readLanguageList parses the raw list a collector wrote for manager
+func readLanguageList(manager, path string) ([]languagePackage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch manager {
+	case ManagerPip:
+		return parsePipRequirements(string(data)), nil
+	case ManagerCargo:
+		return parseCargoList(string(data)), nil
+	case ManagerGem:
+		return parseGemList(string(data)), nil
+	case ManagerPnpm:
+		return parsePnpmList(string(data)), nil
+	case ManagerComposer:
+		return parseComposerShow(string(data)), nil
+	}
+	return nil, fmt.Errorf("unknown package manager %q", manager)
+}
+
+// parsePipRequirements reads `pip freeze` output
+func parsePipRequirements(text string) []languagePackage {
+	var packages []languagePackage
+	for _, line := range nonEmptyLines([]byte(text)) {
+		// Comments, options and editable installs
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+			continue
+		}
+		// "name @ file:///..." was installed from a local path
+		if strings.Contains(line, "@ file:") {
+			continue
+		}
+		if name, version, ok := strings.Cut(line, "=="); ok {
+			packages = append(packages, languagePackage{Name: name, Version: version})
+			continue
+		}
+		name := line
+		if i := strings.IndexAny(name, "=<>~!@ ;"); i > 0 {
+			name = name[:i]
+		}
+		packages = append(packages, languagePackage{Name: name})
+	}
+	return packages
+}
+
+// parseCargoList reads `cargo install --list` output, where each crate is
+// "name v1.2.3:" with an optional "(source)" before the colon. Crates from a
+// local path are skipped.
+func parseCargoList(text string) []languagePackage {
+	var crates []languagePackage
+	for _, line := range strings.Split(text, "\n") {
+		// Binaries are listed indented below their crate
+		if line == "" || line[0] == ' ' || line[0] == '\t' || !strings.HasSuffix(line, ":") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimSuffix(line, ":"))
+		crate := languagePackage{Name: fields[0]}
+		if len(fields) > 1 {
+			crate.Version = strings.TrimPrefix(fields[1], "v")
+		}
+		if len(fields) > 2 {
+			source := strings.Trim(fields[2], "()")
+			if !strings.HasPrefix(source, "http") && !strings.HasPrefix(source, "ssh:") {
+				continue
+			}
+			crate.Git, _, _ = strings.Cut(source, "#")
+		}
+		crates = append(crates, crate)
+	}
+	return crates
+}
+
+// parseGemList reads `gem list` output, "name (2.1.0, 1.9.0, default: 1.2.0)",
+// skipping gems that only exist as defaults bundled with Ruby
+func parseGemList(text string) []languagePackage {
+	var gems []languagePackage
+	for _, line := range nonEmptyLines([]byte(text)) {
+		name, versions, ok := strings.Cut(line, " (")
+		if !ok {
+			continue
+		}
+		for _, version := range strings.Split(strings.TrimSuffix(versions, ")"), ", ") {
+			if !strings.HasPrefix(version, "default:") {
+				gems = append(gems, languagePackage{Name: name, Version: version})
+				break
+			}
+		}
+	}
+	return gems
+}
+
+// parsePnpmList reads `pnpm list -g --depth=0` output, where packages are
+// listed as "name version" below a dependencies: heading
+func parsePnpmList(text string) []languagePackage {
+	var packages []languagePackage
+	inDeps := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasSuffix(line, "dependencies:"):
+			inDeps = true
+		case line == "":
+			inDeps = false
+		case inDeps:
+			fields := strings.Fields(line)
+			pkg := languagePackage{Name: fields[0]}
+			if len(fields) > 1 {
+				pkg.Version = fields[1]
+			}
+			packages = append(packages, pkg)
+		}
+	}
+	return packages
+}
+
+// parseComposerShow reads `composer global show` output, "vendor/name version description"
+func parseComposerShow(text string) []languagePackage {
+	var packages []languagePackage
+	for _, line := range nonEmptyLines([]byte(text)) {
+		fields := strings.Fields(line)
+		if strings.HasPrefix(line, "#") || !strings.Contains(fields[0], "/") {
+			continue
+		}
+		pkg := languagePackage{Name: fields[0]}
+		if len(fields) > 1 {
+			pkg.Version = fields[1]
+		}
+		packages = append(packages, pkg)
+	}
+	return packages
+}
+
+// parseNpmList reads `npm list -g --depth=0` output, "├── name@version" lines
+func parseNpmList(text string) []languagePackage {
+	var packages []languagePackage
+	for _, line := range strings.Split(text, "\n") {
+		_, entry, ok := strings.Cut(line, "── ")
+		if !ok {
+			continue
+		}
+		entry = strings.Fields(entry)[0]
+		// Scoped packages start with @, so split on the last one
+		i := strings.LastIndex(entry, "@")
+		if i <= 0 {
+			packages = append(packages, languagePackage{Name: entry})
+			continue
+		}
+		packages = append(packages, languagePackage{Name: entry[:i], Version: entry[i+1:]})
+	}
+	return packages
+}
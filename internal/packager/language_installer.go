@@ -0,0 +1,204 @@
+package packager
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/harshpatel5940/stash/internal/ui"
+)
+
+// PackageInstallError lists the packages a manager failed to install, for
+// restore summaries
+type PackageInstallError struct {
+	Manager        string
+	FailedPackages []string
+}
+
+func (e *PackageInstallError) Error() string {
+	return fmt.Sprintf("failed to install: %s", strings.Join(e.FailedPackages, ", "))
+}
+
+// InstallLanguagePackages installs the list for one of LanguageManagers
+func (i *Installer) InstallLanguagePackages(manager, path string) (int, error) {
+	switch manager {
+	case ManagerPipx:
+		return i.InstallPipxTools(path)
+	case ManagerUv:
+		return i.InstallUvTools(path)
+	case ManagerPip:
+		return i.InstallPipPackages(path)
+	case ManagerCargo:
+		return i.InstallCargoPackages(path)
+	case ManagerGem:
+		return i.InstallGemPackages(path)
+	case ManagerPnpm:
+		return i.InstallPnpmPackages(path)
+	case ManagerComposer:
+		return i.InstallComposerPackages(path)
+	}
+	return 0, fmt.Errorf("unknown package manager %q", manager)
+}
+
+// listCommands build the install command for one target of each language
+// manager that installs from a plain package list
+var listCommands = map[string]func(target string) *exec.Cmd{
+	ManagerPip: func(pkg string) *exec.Cmd {
+		return exec.Command(pipCommand(), "install", pkg)
+	},
+	ManagerCargo: func(crate string) *exec.Cmd {
+		// Git crates carry their --git flag
+		return exec.Command("cargo", append([]string{"install"}, strings.Fields(crate)...)...)
+	},
+	ManagerGem: func(gem string) *exec.Cmd {
+		// Pinned gems carry their -v flag
+		return exec.Command("gem", append([]string{"install"}, strings.Fields(gem)...)...)
+	},
+	ManagerPnpm: func(pkg string) *exec.Cmd {
+		return exec.Command("pnpm", "add", "-g", pkg)
+	},
+	ManagerComposer: func(pkg string) *exec.Cmd {
+		return exec.Command("composer", "global", "require", pkg)
+	},
+}
+
+// pipCommand returns pip3 if it is installed, else pip
+func pipCommand() string {
+	if commandExists("pip3") {
+		return "pip3"
+	}
+	return "pip"
+}
+
+// InstallPipPackages installs the packages from `pip freeze`
+func (i *Installer) InstallPipPackages(pipFilePath string) (int, error) {
+	return i.installLanguageList(ManagerPip, pipCommand(), pipFilePath, listCommands[ManagerPip])
+}
+
+// InstallCargoPackages installs crates from `cargo install --list`
+func (i *Installer) InstallCargoPackages(cargoFilePath string) (int, error) {
+	return i.installLanguageList(ManagerCargo, "cargo", cargoFilePath, listCommands[ManagerCargo])
+}
+
+// InstallGemPackages installs gems from `gem list`, leaving out Ruby's default gems
+func (i *Installer) InstallGemPackages(gemFilePath string) (int, error) {
+	return i.installLanguageList(ManagerGem, "gem", gemFilePath, listCommands[ManagerGem])
+}
+
+// InstallPnpmPackages installs pnpm global packages
+func (i *Installer) InstallPnpmPackages(pnpmFilePath string) (int, error) {
+	return i.installLanguageList(ManagerPnpm, "pnpm", pnpmFilePath, listCommands[ManagerPnpm])
+}
+
+// InstallComposerPackages installs Composer global packages
+func (i *Installer) InstallComposerPackages(composerFilePath string) (int, error) {
+	return i.installLanguageList(ManagerComposer, "composer", composerFilePath, listCommands[ManagerComposer])
+}
+
+// installLanguageList installs a manager's list one package at a time, so a
+// single missing package doesn't stop the rest
+func (i *Installer) installLanguageList(manager, binary, path string, command func(pkg string) *exec.Cmd) (int, error) {
+	if !commandExists(binary) {
+		return 0, fmt.Errorf("%s not found", binary)
+	}
+
+	packages, err := languageTargets(manager, path, i.lock, i.wanted)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s file: %w", manager, err)
+	}
+
+	if len(packages) == 0 {
+		fmt.Println("  No packages found in file")
+		return 0, nil
+	}
+
+	fmt.Printf("  Installing %d %s packages...\n", len(packages), manager)
+	return i.installEach(manager, packages, command)
+}
+
+// InstallPipxTools reinstalls pipx applications, then injects the extra
+// packages each one had
+func (i *Installer) InstallPipxTools(pipxFilePath string) (int, error) {
+	return i.installToolEnvs(ManagerPipx, pipxFilePath)
+}
+
+// InstallUvTools reinstalls `uv tool` environments with the Python version
+// and --with packages they were created with
+func (i *Installer) InstallUvTools(uvFilePath string) (int, error) {
+	return i.installToolEnvs(ManagerUv, uvFilePath)
+}
+
+// installToolEnv installs one pipx or uv tool environment from tool.Spec
+func (i *Installer) installToolEnv(manager string, tool ToolEnv) error {
+	if manager == ManagerUv {
+		args := []string{"tool", "install", tool.Spec}
+		if tool.Python != "" {
+			args = append(args, "--python", tool.Python)
+		}
+		for _, pkg := range tool.Injected {
+			args = append(args, "--with", pkg)
+		}
+		return i.runCommand(exec.Command("uv", args...))
+	}
+
+	args := []string{"install", tool.Spec}
+	if tool.Suffix != "" {
+		args = append(args, "--suffix", tool.Suffix)
+	}
+	if err := i.runCommand(exec.Command("pipx", args...)); err != nil {
+		return err
+	}
+	if len(tool.Injected) == 0 {
+		return nil
+	}
+	return i.runCommand(exec.Command("pipx", append([]string{"inject", tool.Name}, tool.Injected...)...))
+}
+
+// installToolEnvs installs a manager's tool environments, pinned to their
+// locked versions when a lock is in use
+func (i *Installer) installToolEnvs(manager, path string) (int, error) {
+	if !commandExists(manager) {
+		return 0, fmt.Errorf("%s not found", manager)
+	}
+
+	all, err := LoadToolEnvs(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s file: %w", manager, err)
+	}
+	var tools []ToolEnv
+	for _, tool := range all {
+		if i.wanted(tool.Name) {
+			tools = append(tools, tool)
+		}
+	}
+
+	if len(tools) == 0 {
+		fmt.Println("  No tools found in file")
+		return 0, nil
+	}
+
+	fmt.Printf("  Installing %d %s tools...\n", len(tools), manager)
+
+	bar := ui.NewProgressBar(len(tools), manager)
+
+	installed := 0
+	var failed []string
+	for _, tool := range tools {
+		tool.Spec = pinnedSpec(tool.Spec, i.lock.Version(manager, tool.Name))
+		if err := i.installToolEnv(manager, tool); err != nil {
+			failed = append(failed, tool.Name)
+			if i.verbose {
+				fmt.Printf("    Failed to install %s: %v\n", tool.Name, err)
+			}
+		} else {
+			installed++
+		}
+		bar.Add(1)
+	}
+	bar.Finish()
+
+	if len(failed) > 0 {
+		return installed, &PackageInstallError{Manager: manager, FailedPackages: failed}
+	}
+	return installed, nil
+}
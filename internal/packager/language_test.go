@@ -0,0 +1,167 @@
+package packager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLanguagePackages(t *testing.T) {
+	tests := []struct {
+		manager string
+		content string
+		want    []string
+	}{
+		{
+			manager: ManagerPip,
+			content: "black==24.1.0\n-e git+https://github.com/me/tool.git@abc#egg=tool\nlocal @ file:///home/me/local\nrequests @ https://example.com/requests.whl\n",
+			want:    []string{"black", "requests"},
+		},
+		{
+			manager: ManagerCargo,
+			content: "ripgrep v14.1.0:\n    rg\nmytool v0.1.0 (/home/me/mytool):\n    mytool\nzellij v0.39.0 (https://github.com/zellij-org/zellij#a1b2c3):\n    zellij\n",
+			want:    []string{"ripgrep", "zellij --git https://github.com/zellij-org/zellij"},
+		},
+		{
+			manager: ManagerGem,
+			content: "\n*** LOCAL GEMS ***\n\nbundler (2.5.4, default: 2.4.10)\njson (default: 2.7.1)\nrake (13.1.0)\n",
+			want:    []string{"bundler", "rake"},
+		},
+		{
+			manager: ManagerPnpm,
+			content: "Legend: production dependency, optional only, dev only\n\n/home/me/.local/share/pnpm/global/5\n\ndependencies:\n@angular/cli 17.1.0\ntypescript 5.3.3\n",
+			want:    []string{"@angular/cli", "typescript"},
+		},
+		{
+			manager: ManagerComposer,
+			content: "laravel/installer v5.2.0 Laravel application installer.\nphpstan/phpstan   1.10.57 PHPStan - PHP Static Analysis Tool\n",
+			want:    []string{"laravel/installer", "phpstan/phpstan"},
+		},
+		{
+			manager: ManagerComposer,
+			content: "# No global composer packages installed\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.manager, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "list.txt")
+			os.WriteFile(path, []byte(tt.content), 0644)
+
+			got, err := LanguagePackages(tt.manager, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LanguagePackages(%s) = %q, want %q", tt.manager, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectPipxAndUvTools(t *testing.T) {
+	bin := t.TempDir()
+	t.Setenv("PATH", bin)
+
+	fakeCommand(t, bin, "pipx", `echo '{"venvs": {"black": {"metadata": {
+  "main_package": {"package": "black", "package_or_url": "black", "package_version": "24.1.0", "suffix": ""},
+  "injected_packages": {"black-macchiato": {"package": "black-macchiato", "package_or_url": "black-macchiato", "package_version": "1.3.0"}},
+  "python_version": "Python 3.12.1"}}}}'`)
+
+	toolDir := t.TempDir()
+	os.MkdirAll(filepath.Join(toolDir, "ruff"), 0755)
+	os.WriteFile(filepath.Join(toolDir, "ruff", "uv-receipt.toml"), []byte(`[tool]
+requirements = [{ name = "ruff", specifier = ">=0.4" }, { name = "mkdocs", extras = ["i18n"] }]
+python = "3.12"
+entrypoints = [{ name = "ruff", install-path = "/home/me/.local/bin/ruff" }]
+`), 0644)
+	fakeCommand(t, bin, "uv", `case "$2" in
+dir) echo "`+toolDir+`" ;;
+list) printf 'ruff v0.4.1\n- ruff\n' ;;
+esac`)
+
+	out := t.TempDir()
+	p := NewPackager(out)
+	if err := p.CollectPipx(); err != nil {
+		t.Fatalf("CollectPipx() failed: %v", err)
+	}
+	if err := p.CollectUvTools(); err != nil {
+		t.Fatalf("CollectUvTools() failed: %v", err)
+	}
+
+	pipx, _ := LoadToolEnvs(filepath.Join(out, "pipx-tools.json"))
+	wantPipx := []ToolEnv{{Name: "black", Spec: "black", Version: "24.1.0", Python: "3.12.1", Injected: []string{"black-macchiato"}}}
+	if !reflect.DeepEqual(pipx, wantPipx) {
+		t.Errorf("pipx tools = %+v, want %+v", pipx, wantPipx)
+	}
+
+	uv, _ := LoadToolEnvs(filepath.Join(out, "uv-tools.json"))
+	wantUv := []ToolEnv{{Name: "ruff", Spec: "ruff>=0.4", Version: "0.4.1", Python: "3.12", Injected: []string{"mkdocs[i18n]"}}}
+	if !reflect.DeepEqual(uv, wantUv) {
+		t.Errorf("uv tools = %+v, want %+v", uv, wantUv)
+	}
+}
+
+func TestInstallLanguagePackages(t *testing.T) {
+	bin := t.TempDir()
+	t.Setenv("PATH", bin)
+	log := filepath.Join(t.TempDir(), "commands.log")
+
+	fakeCommand(t, bin, "cargo", `echo "cargo $*" >> `+log+`
+case "$*" in *yanked*) echo "error: could not find yanked"; exit 101 ;; esac`)
+	fakeCommand(t, bin, "pipx", `echo "pipx $*" >> `+log)
+	fakeCommand(t, bin, "uv", `echo "uv $*" >> `+log)
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "cargo-packages.txt"),
+		[]byte("ripgrep v14.1.0:\n    rg\nyanked v0.1.0:\n    yanked\nzellij v0.39.0 (https://github.com/zellij-org/zellij#a1b2c3):\n    zellij\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "pipx-tools.json"),
+		[]byte(`[{"name": "black", "spec": "black", "injected": ["black-macchiato"]}]`), 0644)
+	os.WriteFile(filepath.Join(dir, "uv-tools.json"),
+		[]byte(`[{"name": "ruff", "spec": "ruff>=0.4", "python": "3.12", "injected": ["mkdocs[i18n]"]}]`), 0644)
+
+	installer := NewInstaller(false)
+
+	installed, err := installer.InstallLanguagePackages(ManagerCargo, filepath.Join(dir, "cargo-packages.txt"))
+	if installed != 2 {
+		t.Errorf("Expected 2 crates installed, got %d", installed)
+	}
+	var installErr *PackageInstallError
+	if !errors.As(err, &installErr) || !reflect.DeepEqual(installErr.FailedPackages, []string{"yanked"}) {
+		t.Errorf("Expected PackageInstallError for yanked, got %v", err)
+	}
+
+	for _, manager := range []string{ManagerPipx, ManagerUv} {
+		m, _ := LookupLanguageManager(manager)
+		if installed, err := installer.InstallLanguagePackages(manager, filepath.Join(dir, m.File)); installed != 1 || err != nil {
+			t.Errorf("InstallLanguagePackages(%s) = %d, %v", manager, installed, err)
+		}
+	}
+
+	data, _ := os.ReadFile(log)
+	want := []string{
+		"cargo install ripgrep",
+		"cargo install yanked",
+		"cargo install zellij --git https://github.com/zellij-org/zellij",
+		"pipx install black",
+		"pipx inject black black-macchiato",
+		"uv tool install ruff>=0.4 --python 3.12 --with mkdocs[i18n]",
+	}
+	if got := nonEmptyLines(data); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected commands:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestInstallLanguagePackages_MissingManager(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	path := filepath.Join(t.TempDir(), "gem-packages.txt")
+	os.WriteFile(path, []byte("rake (13.1.0)\n"), 0644)
+
+	if _, err := NewInstaller(false).InstallGemPackages(path); err == nil {
+		t.Error("Expected error when gem isn't installed")
+	}
+}
@@ -0,0 +1,289 @@
+package packager
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// LockFile records the exact version of every package in the backup
+const LockFile = "packages.lock.json"
+
+// Managers that only appear in the lock file
+const (
+	ManagerBrew = "brew"
+	ManagerCask = "cask"
+	ManagerNPM  = "npm"
+	ManagerGo   = "go"
+)
+
+// LockedPackage is a package at the version it was installed at
+type LockedPackage struct {
+	Manager string `json:"manager"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Lock is the structured version record written next to the package lists
+type Lock struct {
+	Packages []LockedPackage `json:"packages"`
+}
+
+// Version returns the locked version of a package, or "" if it isn't
+// locked. It's safe to call on a nil Lock.
+func (l *Lock) Version(manager, name string) string {
+	if l == nil {
+		return ""
+	}
+	for _, pkg := range l.Packages {
+		if pkg.Manager == manager && pkg.Name == name {
+			return pkg.Version
+		}
+	}
+	return ""
+}
+
+// WriteLock records versions from the lists already collected into the
+// output directory, plus Homebrew versions, which the Brewfile leaves out
+func (p *Packager) WriteLock() error {
+	lock := BuildLock(p.outputDir)
+	lock.Packages = append(lock.Packages, brewVersions()...)
+	if len(lock.Packages) == 0 {
+		return fmt.Errorf("no package versions found")
+	}
+	sortLock(lock)
+
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal package lock: %w", err)
+	}
+	return os.WriteFile(filepath.Join(p.outputDir, LockFile), data, 0644)
+}
+
+// BuildLock reads versions out of the package lists in packagesDir
+func BuildLock(packagesDir string) *Lock {
+	lock := &Lock{}
+	add := func(manager string, packages []languagePackage) {
+		for _, pkg := range packages {
+			if pkg.Version != "" && pkg.Git == "" {
+				lock.Packages = append(lock.Packages, LockedPackage{Manager: manager, Name: pkg.Name, Version: pkg.Version})
+			}
+		}
+	}
+
+	for _, m := range LanguageManagers {
+		path := filepath.Join(packagesDir, m.File)
+		if m.Name == ManagerPipx || m.Name == ManagerUv {
+			tools, _ := LoadToolEnvs(path)
+			for _, tool := range tools {
+				add(m.Name, []languagePackage{{Name: tool.Name, Version: tool.Version}})
+			}
+			continue
+		}
+		packages, _ := readLanguageList(m.Name, path)
+		add(m.Name, packages)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(packagesDir, "npm-global.txt")); err == nil {
+		add(ManagerNPM, parseNpmList(string(data)))
+	}
+
+	targets, _ := LoadGoBinaries(filepath.Join(packagesDir, GoBinariesFile))
+	for _, target := range targets {
+		path, version, _ := strings.Cut(target, "@")
+		add(ManagerGo, []languagePackage{{Name: path, Version: version}})
+	}
+
+	sortLock(lock)
+	return lock
+}
+
+// brewVersions lists installed formulae and casks with their newest version
+func brewVersions() []LockedPackage {
+	if !commandExists("brew") {
+		return nil
+	}
+	var packages []LockedPackage
+	for _, kind := range []struct{ flag, manager string }{{"--formula", ManagerBrew}, {"--cask", ManagerCask}} {
+		output, err := exec.Command("brew", "list", kind.flag, "--versions").Output()
+		if err != nil {
+			continue
+		}
+		// "name 1.2.0 1.3.0" when several versions are kept
+		for _, line := range nonEmptyLines(output) {
+			fields := strings.Fields(line)
+			if len(fields) >= 2 {
+				packages = append(packages, LockedPackage{Manager: kind.manager, Name: fields[0], Version: fields[len(fields)-1]})
+			}
+		}
+	}
+	return packages
+}
+
+func sortLock(lock *Lock) {
+	sort.Slice(lock.Packages, func(i, j int) bool {
+		a, b := lock.Packages[i], lock.Packages[j]
+		if a.Manager != b.Manager {
+			return a.Manager < b.Manager
+		}
+		return a.Name < b.Name
+	})
+}
+
+// LoadLock reads a package lock file
+func LoadLock(path string) (*Lock, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseLock(data)
+}
+
+// ParseLock parses the contents of a package lock file
+func ParseLock(data []byte) (*Lock, error) {
+	var lock Lock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse package lock: %w", err)
+	}
+	return &lock, nil
+}
+
+// VersionChange is a package whose version differs between two locks
+type VersionChange struct {
+	Manager string
+	Name    string
+	Old     string
+	New     string
+}
+
+// Kind describes the change: "added", "removed", "upgraded" or "downgraded"
+func (c VersionChange) Kind() string {
+	switch {
+	case c.Old == "":
+		return "added"
+	case c.New == "":
+		return "removed"
+	case CompareVersions(c.Old, c.New) > 0:
+		return "downgraded"
+	}
+	return "upgraded"
+}
+
+// String formats the change as "manager name: old → new"
+func (c VersionChange) String() string {
+	switch c.Kind() {
+	case "added":
+		return fmt.Sprintf("%s %s %s", c.Manager, c.Name, c.New)
+	case "removed":
+		return fmt.Sprintf("%s %s (was %s)", c.Manager, c.Name, c.Old)
+	}
+	return fmt.Sprintf("%s %s: %s → %s", c.Manager, c.Name, c.Old, c.New)
+}
+
+// DiffLocks lists packages added, removed or at a different version from
+// old to new, sorted by manager and name. Either lock may be nil.
+func DiffLocks(old, new *Lock) []VersionChange {
+	type key struct{ manager, name string }
+	versions := func(lock *Lock) map[key]string {
+		m := make(map[key]string)
+		if lock != nil {
+			for _, pkg := range lock.Packages {
+				m[key{pkg.Manager, pkg.Name}] = pkg.Version
+			}
+		}
+		return m
+	}
+	oldVersions, newVersions := versions(old), versions(new)
+
+	var changes []VersionChange
+	for k, newVersion := range newVersions {
+		if oldVersion := oldVersions[k]; oldVersion != newVersion {
+			changes = append(changes, VersionChange{Manager: k.manager, Name: k.name, Old: oldVersion, New: newVersion})
+		}
+	}
+	for k, oldVersion := range oldVersions {
+		if _, ok := newVersions[k]; !ok {
+			changes = append(changes, VersionChange{Manager: k.manager, Name: k.name, Old: oldVersion})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Manager != changes[j].Manager {
+			return changes[i].Manager < changes[j].Manager
+		}
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+// CompareVersions orders two version strings by their numeric parts, so
+// "1.10.0" sorts after "1.9.2" and "2.0.0-rc1" before "2.0.0". Non-numeric
+// parts are compared as text.
+func CompareVersions(a, b string) int {
+	as, bs := versionParts(a), versionParts(b)
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return compareInts(an, bn)
+			}
+		case as[i] != bs[i]:
+			return strings.Compare(as[i], bs[i])
+		}
+	}
+	switch {
+	case len(as) > len(bs):
+		return extraPartsOrder(as[len(bs)])
+	case len(as) < len(bs):
+		return -extraPartsOrder(bs[len(as)])
+	}
+	return 0
+}
+
+// extraPartsOrder decides between versions that match up to where one of
+// them ends: more numbers make it newer, a suffix such as "rc" older
+func extraPartsOrder(next string) int {
+	if _, err := strconv.Atoi(next); err == nil {
+		return 1
+	}
+	return -1
+}
+
+func compareInts(a, b int) int {
+	if a < b {
+		return -1
+	}
+	return 1
+}
+
+// versionParts splits "v1.2.3-rc1" into ["1", "2", "3", "rc", "1"]
+func versionParts(version string) []string {
+	var parts []string
+	var current []rune
+	for _, r := range strings.TrimPrefix(version, "v") {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			if len(current) > 0 {
+				parts = append(parts, string(current))
+			}
+			current = nil
+		case len(current) > 0 && unicode.IsDigit(r) != unicode.IsDigit(current[0]):
+			parts = append(parts, string(current))
+			current = []rune{r}
+		default:
+			current = append(current, r)
+		}
+	}
+	if len(current) > 0 {
+		parts = append(parts, string(current))
+	}
+	return parts
+}
@@ -0,0 +1,128 @@
+package packager
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.9.2", "1.10.0", -1},
+		{"v1.2.3", "1.2.3", 0},
+		{"2.0.0-rc1", "2.0.0", -1},
+		{"2.0.0", "2.0.0.1", -1},
+		{"14.1.0", "13.0.0", 1},
+		{"1.2.0_1", "1.2.0", 1},
+	}
+
+	for _, tt := range tests {
+		got := CompareVersions(tt.a, tt.b)
+		if got < 0 {
+			got = -1
+		} else if got > 0 {
+			got = 1
+		}
+		if got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestBuildLock(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "cargo-packages.txt"),
+		[]byte("ripgrep v14.1.0:\n    rg\nzellij v0.39.0 (https://github.com/zellij-org/zellij#a1b2c3):\n    zellij\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "npm-global.txt"),
+		[]byte("/usr/local/lib\n├── @vue/cli@5.0.8\n└── typescript@5.3.3\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "uv-tools.json"), []byte(`[{"name": "ruff", "spec": "ruff", "version": "0.4.1"}]`), 0644)
+	os.WriteFile(filepath.Join(dir, GoBinariesFile), []byte("golang.org/x/tools/gopls@v0.15.3\n"), 0644)
+
+	lock := BuildLock(dir)
+	want := []LockedPackage{
+		{Manager: ManagerCargo, Name: "ripgrep", Version: "14.1.0"},
+		{Manager: ManagerGo, Name: "golang.org/x/tools/gopls", Version: "v0.15.3"},
+		{Manager: ManagerNPM, Name: "@vue/cli", Version: "5.0.8"},
+		{Manager: ManagerNPM, Name: "typescript", Version: "5.3.3"},
+		{Manager: ManagerUv, Name: "ruff", Version: "0.4.1"},
+	}
+	if !reflect.DeepEqual(lock.Packages, want) {
+		t.Errorf("BuildLock() = %+v, want %+v", lock.Packages, want)
+	}
+
+	// Exact targets pin what the manager allows; git crates keep their source
+	targets, _ := LanguageTargets(ManagerCargo, filepath.Join(dir, "cargo-packages.txt"), lock)
+	wantTargets := []string{"ripgrep --version 14.1.0", "zellij --git https://github.com/zellij-org/zellij"}
+	if !reflect.DeepEqual(targets, wantTargets) {
+		t.Errorf("LanguageTargets() = %q, want %q", targets, wantTargets)
+	}
+	if targets, _ := LanguageTargets(ManagerUv, filepath.Join(dir, "uv-tools.json"), lock); !reflect.DeepEqual(targets, []string{"ruff==0.4.1"}) {
+		t.Errorf("Unexpected uv targets: %q", targets)
+	}
+}
+
+func TestDiffLocks(t *testing.T) {
+	old := &Lock{Packages: []LockedPackage{
+		{Manager: ManagerBrew, Name: "git", Version: "2.43.0"},
+		{Manager: ManagerBrew, Name: "node", Version: "21.5.0"},
+		{Manager: ManagerCargo, Name: "ripgrep", Version: "14.1.0"},
+		{Manager: ManagerPip, Name: "black", Version: "24.1.0"},
+	}}
+	new := &Lock{Packages: []LockedPackage{
+		{Manager: ManagerBrew, Name: "git", Version: "2.44.0"},
+		{Manager: ManagerBrew, Name: "node", Version: "20.11.0"},
+		{Manager: ManagerCargo, Name: "ripgrep", Version: "14.1.0"},
+		{Manager: ManagerGem, Name: "rake", Version: "13.1.0"},
+	}}
+
+	var got []string
+	for _, change := range DiffLocks(old, new) {
+		got = append(got, change.Kind()+" "+change.String())
+	}
+	want := []string{
+		"upgraded brew git: 2.43.0 → 2.44.0",
+		"downgraded brew node: 21.5.0 → 20.11.0",
+		"added gem rake 13.1.0",
+		"removed pip black (was 24.1.0)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffLocks() = %q, want %q", got, want)
+	}
+}
+
+func TestInstallNPMPackages(t *testing.T) {
+	bin := t.TempDir()
+	t.Setenv("PATH", bin)
+	log := filepath.Join(t.TempDir(), "commands.log")
+	fakeCommand(t, bin, "npm", `echo "npm $*" >> `+log)
+
+	list := filepath.Join(t.TempDir(), "npm-global.txt")
+	os.WriteFile(list, []byte("/usr/local/lib\n├── @vue/cli@5.0.8\n├── npm@10.2.4\n└── typescript@5.3.3\n"), 0644)
+
+	installer := NewInstaller(false)
+	installer.UseLock(BuildLock(filepath.Dir(list)))
+	installed, err := installer.InstallNPMPackages(list)
+	if err != nil || installed != 2 {
+		t.Fatalf("InstallNPMPackages() = %d, %v", installed, err)
+	}
+
+	data, _ := os.ReadFile(log)
+	want := []string{"npm install -g @vue/cli@5.0.8", "npm install -g typescript@5.3.3"}
+	if got := nonEmptyLines(data); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected commands:\n%s", strings.Join(got, "\n"))
+	}
+
+	os.Remove(log)
+	installer.UseLock(nil)
+	installer.InstallNPMPackages(list)
+	data, _ = os.ReadFile(log)
+	want = []string{"npm install -g @vue/cli", "npm install -g typescript"}
+	if got := nonEmptyLines(data); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected commands without a lock:\n%s", strings.Join(got, "\n"))
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"github.com/harshpatel5940/stash/internal/platform"
 )
 
 type AppInfo struct {
@@ -33,9 +35,20 @@ func (p *Packager) CollectAll() (map[string]int, error) {
 		counts["homebrew"] = count
 	}
 
-	if err := p.CollectMAS(); err == nil {
-		count := p.countLines(filepath.Join(p.outputDir, "mas-apps.txt"))
-		counts["mas"] = count
+	if err := p.CollectBrewServices(); err == nil {
+		if services, err := LoadBrewServices(filepath.Join(p.outputDir, BrewServicesFile)); err == nil {
+			counts["brew-services"] = len(services)
+		}
+	}
+
+	// The App Store and /Applications only exist on macOS
+	macOS := platform.Current().IsMacOS()
+
+	if macOS {
+		if err := p.CollectMAS(); err == nil {
+			count := p.countLines(filepath.Join(p.outputDir, "mas-apps.txt"))
+			counts["mas"] = count
+		}
 	}
 
 	if err := p.CollectVSCode(); err == nil {
@@ -73,9 +86,43 @@ func (p *Packager) CollectAll() (map[string]int, error) {
 		counts["composer"] = count
 	}
 
-	if err := p.DetectNonBrewApps(); err == nil {
-		count := p.countLines(filepath.Join(p.outputDir, "non-brew-apps.txt"))
-		counts["non-brew-apps"] = count
+	if err := p.CollectPipx(); err == nil {
+		if tools, err := LoadToolEnvs(filepath.Join(p.outputDir, "pipx-tools.json")); err == nil {
+			counts["pipx"] = len(tools)
+		}
+	}
+
+	if err := p.CollectUvTools(); err == nil {
+		if tools, err := LoadToolEnvs(filepath.Join(p.outputDir, "uv-tools.json")); err == nil {
+			counts["uv"] = len(tools)
+		}
+	}
+
+	if err := p.CollectToolchains(); err == nil {
+		if toolchains, err := LoadToolchains(filepath.Join(p.outputDir, ToolchainsFile)); err == nil {
+			counts["toolchains"] = CountToolchainVersions(toolchains)
+		}
+	}
+
+	if err := p.CollectGoBinaries(); err == nil {
+		counts["go"] = p.countLines(filepath.Join(p.outputDir, GoBinariesFile))
+	}
+
+	// Versions come from the lists above, so this runs after them
+	_ = p.WriteLock()
+
+	if platform.Current().IsLinux() {
+		for manager, count := range p.CollectSystemPackages() {
+			counts[manager] = count
+		}
+	}
+
+	if macOS {
+		if err := p.DetectNonBrewApps(); err == nil {
+			count := p.countLines(filepath.Join(p.outputDir, "non-brew-apps.txt"))
+			counts["non-brew-apps"] = count
+			counts["suggested-casks"] = p.countLines(filepath.Join(p.outputDir, SuggestedCasksFile))
+		}
 	}
 
 	return counts, nil
@@ -242,6 +289,9 @@ func commandExists(cmd string) bool {
 	return err == nil
 }
 
+// DetectNonBrewApps lists the apps Homebrew doesn't manage in
+// non-brew-apps.txt, and the casks that would install them in
+// Brewfile.suggested
 func (p *Packager) DetectNonBrewApps() error {
 
 	installedApps, err := p.getInstalledApps()
@@ -264,10 +314,29 @@ func (p *Packager) DetectNonBrewApps() error {
 		}
 	}
 
+	// App Store apps come back with mas, so they aren't matched to casks
+	masApps := p.masAppNames()
+	var candidates []string
+	for _, app := range nonBrewApps {
+		if !masApps[strings.ToLower(strings.TrimSuffix(app.Name, ".app"))] {
+			candidates = append(candidates, app.Name)
+		}
+	}
+	suggestions := SuggestCasks(candidates, searchBrewCasks)
+	if len(suggestions) > 0 {
+		if err := p.writeCaskSuggestions(suggestions); err != nil {
+			return err
+		}
+	}
+
 	var output strings.Builder
 	output.WriteString("# Applications not managed by Homebrew\n")
 	output.WriteString("# These apps were likely installed manually (DMG, App Store, etc.)\n")
-	output.WriteString("# You'll need to reinstall these manually after restore\n\n")
+	if len(suggestions) > 0 {
+		output.WriteString(fmt.Sprintf("# %d of them have a Homebrew cask, listed in %s; reinstall the rest manually\n\n", len(suggestions), SuggestedCasksFile))
+	} else {
+		output.WriteString("# You'll need to reinstall these manually after restore\n\n")
+	}
 
 	for _, app := range nonBrewApps {
 		output.WriteString(fmt.Sprintf("%s\n", app.Name))
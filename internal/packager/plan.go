@@ -0,0 +1,407 @@
+package packager
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/harshpatel5940/stash/internal/ui"
+)
+
+// InstallPlanFile keeps the state of a restore's package installs between
+// runs, next to the package lists it was made from
+const InstallPlanFile = "install-plan.json"
+
+// Install plan item states
+const (
+	ItemPending   = "pending"
+	ItemInstalled = "installed"
+	ItemFailed    = "failed"
+)
+
+// parallelManagers can install several packages at once; the rest take a
+// lock (brew) or prompt (mas) and run one at a time
+var parallelManagers = map[string]bool{
+	ManagerVSCode: true,
+}
+
+// planCommands is the binary each plan manager needs, where it isn't named
+// after the manager
+var planCommands = map[string]string{
+	ManagerVSCode: "code",
+}
+
+// planCommand returns the binary manager's plan items need
+func planCommand(manager string) string {
+	if manager == ManagerPip {
+		return pipCommand()
+	}
+	if name, ok := planCommands[manager]; ok {
+		return name
+	}
+	return manager
+}
+
+// PlanItem is one package in an install plan
+type PlanItem struct {
+	Manager string `json:"manager"`
+	Name    string `json:"name"`
+	// Line is what gets installed: a Brewfile line, an App Store "id name",
+	// an extension ID, a go install or package manager target, or a pipx or
+	// uv tool environment as JSON
+	Line     string `json:"line"`
+	Status   string `json:"status"`
+	Attempts int    `json:"attempts,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// InstallPlan lists the packages a restore installs and how far it got, so
+// an interrupted restore can continue with --resume-install
+type InstallPlan struct {
+	Backup  string    `json:"backup"`
+	Created time.Time `json:"created"`
+	// ExactVersions is whether the restore installs the backup's locked
+	// versions; item targets are pinned when they're added
+	ExactVersions bool       `json:"exact_versions,omitempty"`
+	Items         []PlanItem `json:"items"`
+
+	path string
+	mu   sync.Mutex
+}
+
+// NewInstallPlan starts an empty plan saved at path
+func NewInstallPlan(path, backup string) *InstallPlan {
+	return &InstallPlan{Backup: backup, Created: time.Now(), path: path}
+}
+
+// LoadInstallPlan reads a saved plan
+func LoadInstallPlan(path string) (*InstallPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	plan := &InstallPlan{path: path}
+	if err := json.Unmarshal(data, plan); err != nil {
+		return nil, fmt.Errorf("failed to parse install plan: %w", err)
+	}
+	return plan, nil
+}
+
+// AddBrewfile adds a Brewfile's entries, which brew bundle installs one by
+// one. Each keeps the file's cask_args so it installs as the whole file would.
+func (p *InstallPlan) AddBrewfile(brewfile *Brewfile) {
+	settings := brewfile.Settings()
+	for _, item := range brewfile.Items {
+		line := strings.Join(append(settings[:len(settings):len(settings)], item.RawLine), "\n")
+		p.Items = append(p.Items, PlanItem{Manager: ManagerBrew, Name: item.Name, Line: line, Status: ItemPending})
+	}
+}
+
+// AddListFile adds the App Store apps or VS Code extensions in a package list
+func (p *InstallPlan) AddListFile(manager, path string) error {
+	var lines []string
+	var err error
+	if manager == ManagerMAS {
+		lines, err = masNames(path)
+	} else {
+		lines, err = readNonEmptyLines(path)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		name := line
+		// Show App Store apps by name rather than ID
+		if _, appName, ok := strings.Cut(line, " "); ok && manager == ManagerMAS {
+			name = appName
+		}
+		p.Items = append(p.Items, PlanItem{Manager: manager, Name: name, Line: line, Status: ItemPending})
+	}
+	return nil
+}
+
+// AddGoBinaries adds the binaries in a Go binaries list, at their recorded
+// versions when lock is set and @latest otherwise
+func (p *InstallPlan) AddGoBinaries(path string, lock *Lock) error {
+	targets, err := LoadGoBinaries(path)
+	if err != nil {
+		return err
+	}
+	for _, target := range targets {
+		name, _, _ := strings.Cut(target, "@")
+		p.Items = append(p.Items, PlanItem{Manager: ManagerGo, Name: name, Line: goTarget(target, lock), Status: ItemPending})
+	}
+	return nil
+}
+
+// AddNPMPackages adds the global npm packages in a list, pinned to lock
+func (p *InstallPlan) AddNPMPackages(path string, lock *Lock) error {
+	names, err := npmNames(path)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		p.Items = append(p.Items, PlanItem{Manager: ManagerNPM, Name: name, Line: npmTarget(name, lock), Status: ItemPending})
+	}
+	return nil
+}
+
+// AddLanguageList adds the packages or tool environments in one of
+// LanguageManagers' lists, pinned to lock
+func (p *InstallPlan) AddLanguageList(manager, path string, lock *Lock) error {
+	if manager == ManagerPipx || manager == ManagerUv {
+		tools, err := LoadToolEnvs(path)
+		if err != nil {
+			return err
+		}
+		for _, tool := range tools {
+			tool.Spec = pinnedSpec(tool.Spec, lock.Version(manager, tool.Name))
+			line, err := json.Marshal(tool)
+			if err != nil {
+				return err
+			}
+			p.Items = append(p.Items, PlanItem{Manager: manager, Name: tool.Name, Line: string(line), Status: ItemPending})
+		}
+		return nil
+	}
+
+	if _, ok := listCommands[manager]; !ok {
+		return fmt.Errorf("unknown package manager %q", manager)
+	}
+	packages, err := readLanguageList(manager, path)
+	if err != nil {
+		return err
+	}
+	for _, pkg := range packages {
+		target := languageTarget(manager, pkg, lock.Version(manager, pkg.Name))
+		p.Items = append(p.Items, PlanItem{Manager: manager, Name: pkg.Name, Line: target, Status: ItemPending})
+	}
+	return nil
+}
+
+// Save writes the plan to disk, replacing the previous copy in one step so
+// a crash never leaves half a file
+func (p *InstallPlan) Save() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.save()
+}
+
+func (p *InstallPlan) save() error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal install plan: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(p.path), 0755); err != nil {
+		return fmt.Errorf("failed to create install plan directory: %w", err)
+	}
+	tmp := p.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write install plan: %w", err)
+	}
+	return os.Rename(tmp, p.path)
+}
+
+// Remaining counts the items that are pending or failed
+func (p *InstallPlan) Remaining() int {
+	count := 0
+	for _, item := range p.Items {
+		if item.Status != ItemInstalled {
+			count++
+		}
+	}
+	return count
+}
+
+// Managers lists the managers with items left to install, in the order a
+// restore installs them: Homebrew first, then Go and language packages,
+// which may need tools Homebrew provides, then the rest. Managers the
+// order doesn't know come last, in plan order.
+func (p *InstallPlan) Managers() []string {
+	order := installOrder()
+	left := make(map[string]bool)
+	var unknown []string
+	for _, item := range p.Items {
+		if item.Status == ItemInstalled || left[item.Manager] {
+			continue
+		}
+		left[item.Manager] = true
+		if !slices.Contains(order, item.Manager) {
+			unknown = append(unknown, item.Manager)
+		}
+	}
+
+	var managers []string
+	for _, manager := range order {
+		if left[manager] {
+			managers = append(managers, manager)
+		}
+	}
+	return append(managers, unknown...)
+}
+
+// installOrder is the order restore runs plan managers in. System packages
+// and runtimes aren't in the plan; restore installs them between Homebrew
+// and Go.
+func installOrder() []string {
+	order := []string{ManagerBrew, ManagerGo}
+	for _, m := range LanguageManagers {
+		order = append(order, m.Name)
+	}
+	return append(order, ManagerMAS, ManagerVSCode, ManagerNPM)
+}
+
+// Finish deletes the saved plan once everything in it is installed and
+// reports whether it did
+func (p *InstallPlan) Finish() bool {
+	if p.Remaining() > 0 {
+		return false
+	}
+	os.Remove(p.path)
+	return true
+}
+
+// update records the outcome of installing item i and saves the plan
+func (p *InstallPlan) update(i, attempts int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	item := &p.Items[i]
+	item.Attempts += attempts
+	if err != nil {
+		item.Status = ItemFailed
+		item.Error = err.Error()
+	} else {
+		item.Status = ItemInstalled
+		item.Error = ""
+	}
+	_ = p.save()
+}
+
+// SetWorkers sets how many packages managers that allow it install at once
+func (i *Installer) SetWorkers(workers int) {
+	i.workers = workers
+}
+
+// SetRetries sets how many more times a failed plan item is tried, waiting
+// backoff before the first retry and twice as long before each one after
+func (i *Installer) SetRetries(retries int, backoff time.Duration) {
+	i.retries = retries
+	i.backoff = backoff
+}
+
+// RunPlan installs the items for manager that aren't installed yet,
+// retrying failures and saving the plan after every item
+func (i *Installer) RunPlan(plan *InstallPlan, manager string) (int, error) {
+	var pending []int
+	for idx, item := range plan.Items {
+		if item.Manager == manager && item.Status != ItemInstalled {
+			pending = append(pending, idx)
+		}
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	if name := planCommand(manager); !commandExists(name) {
+		return 0, fmt.Errorf("%s not found", name)
+	}
+
+	workers := 1
+	if parallelManagers[manager] && i.workers > 1 {
+		workers = i.workers
+	}
+
+	fmt.Printf("  Installing %d %s packages...\n", len(pending), manager)
+	bar := ui.NewProgressBar(len(pending), manager)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < len(pending); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				item := plan.Items[idx]
+				err := i.installPlanItem(item)
+				attempts := 1
+				for ; err != nil && attempts <= i.retries; attempts++ {
+					time.Sleep(i.backoff << (attempts - 1))
+					err = i.installPlanItem(item)
+				}
+				if err != nil && i.verbose {
+					fmt.Printf("    Failed to install %s: %v\n", item.Name, err)
+				}
+				plan.update(idx, attempts, err)
+				bar.Add(1)
+			}
+		}()
+	}
+	for _, idx := range pending {
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+	bar.Finish()
+
+	installed := 0
+	var failed []string
+	for _, idx := range pending {
+		if plan.Items[idx].Status == ItemInstalled {
+			installed++
+		} else {
+			failed = append(failed, plan.Items[idx].Name)
+		}
+	}
+	if len(failed) > 0 {
+		return installed, &PackageInstallError{Manager: manager, FailedPackages: failed}
+	}
+	return installed, nil
+}
+
+// installPlanItem makes one attempt at installing item
+func (i *Installer) installPlanItem(item PlanItem) error {
+	switch item.Manager {
+	case ManagerBrew:
+		// A Brewfile of just this entry keeps its options, such as cask args
+		brewfile, err := os.CreateTemp("", "stash-Brewfile-*")
+		if err != nil {
+			return fmt.Errorf("failed to create temp Brewfile: %w", err)
+		}
+		defer os.Remove(brewfile.Name())
+		_, err = brewfile.WriteString(item.Line + "\n")
+		brewfile.Close()
+		if err != nil {
+			return fmt.Errorf("failed to write temp Brewfile: %w", err)
+		}
+
+		cmd := exec.Command("brew", "bundle", "--file="+brewfile.Name())
+		cmd.Env = append(os.Environ(), "HOMEBREW_NO_AUTO_UPDATE=1")
+		return i.runCommand(cmd)
+	case ManagerMAS:
+		return i.runCommand(exec.Command("mas", "install", packageKey(item.Line)))
+	case ManagerVSCode:
+		return i.runCommand(exec.Command("code", "--install-extension", item.Line, "--force"))
+	case ManagerGo:
+		return i.runCommand(goInstallCommand(item.Line))
+	case ManagerNPM:
+		return i.runCommand(npmInstallCommand(item.Line))
+	case ManagerPipx, ManagerUv:
+		var tool ToolEnv
+		if err := json.Unmarshal([]byte(item.Line), &tool); err != nil {
+			return fmt.Errorf("invalid %s tool: %w", item.Manager, err)
+		}
+		return i.installToolEnv(item.Manager, tool)
+	}
+	if command, ok := listCommands[item.Manager]; ok {
+		return i.runCommand(command(item.Line))
+	}
+	return fmt.Errorf("unknown package manager: %s", item.Manager)
+}
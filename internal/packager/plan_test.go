@@ -0,0 +1,210 @@
+package packager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestRunPlan(t *testing.T) {
+	bin := t.TempDir()
+	t.Setenv("PATH", bin)
+	state := t.TempDir()
+	log := filepath.Join(state, "commands.log")
+
+	fakeCommand(t, bin, "code", `echo "code $*" >> `+log)
+	// The first attempt at Xcode fails, the retry works
+	fakeCommand(t, bin, "mas", `echo "mas $*" >> `+log+`
+if [ "$2" = "497799835" ] && [ ! -e `+state+`/retried ]; then : > `+state+`/retried; echo "network error"; exit 1; fi`)
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "mas-apps.txt"), []byte("497799835  Xcode  (15.2)\n409183694  Keynote  (13.2)\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "vscode-extensions.txt"), []byte("golang.go\nms-python.python\nrust-lang.rust-analyzer\n"), 0644)
+
+	planPath := filepath.Join(dir, InstallPlanFile)
+	plan := NewInstallPlan(planPath, "backup-2026-01-01.tar.gz")
+	plan.AddBrewfile(ParseBrewfileContent("cask_args appdir: \"~/Applications\"\n\nbrew \"wget\"\n"))
+	if err := plan.AddListFile(ManagerMAS, filepath.Join(dir, "mas-apps.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.AddListFile(ManagerVSCode, filepath.Join(dir, "vscode-extensions.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	installer := NewInstaller(false)
+	installer.SetWorkers(3)
+	installer.SetRetries(1, 0)
+
+	// brew isn't installed, so its item stays pending
+	if _, err := installer.RunPlan(plan, ManagerBrew); err == nil {
+		t.Error("Expected error when brew isn't installed")
+	}
+	if installed, err := installer.RunPlan(plan, ManagerMAS); installed != 2 || err != nil {
+		t.Errorf("RunPlan(mas) = %d, %v", installed, err)
+	}
+	if installed, err := installer.RunPlan(plan, ManagerVSCode); installed != 3 || err != nil {
+		t.Errorf("RunPlan(vscode) = %d, %v", installed, err)
+	}
+
+	// The saved plan is what --resume-install picks up
+	saved, err := LoadInstallPlan(planPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Remaining() != 1 || !reflect.DeepEqual(saved.Managers(), []string{ManagerBrew}) {
+		t.Errorf("Expected only wget left, got %+v", saved.Items)
+	}
+	if line := saved.Items[0].Line; line != "cask_args appdir: \"~/Applications\"\nbrew \"wget\"" {
+		t.Errorf("Expected the brew item to keep cask_args, got %q", line)
+	}
+	if item := saved.Items[1]; item.Name != "Xcode" || item.Attempts != 2 || item.Status != ItemInstalled {
+		t.Errorf("Unexpected Xcode item: %+v", item)
+	}
+	if saved.Finish() {
+		t.Error("Finish() should keep a plan with items left")
+	}
+
+	data, _ := os.ReadFile(log)
+	got := nonEmptyLines(data)
+	sort.Strings(got)
+	want := []string{
+		"code --install-extension golang.go --force",
+		"code --install-extension ms-python.python --force",
+		"code --install-extension rust-lang.rust-analyzer --force",
+		"mas install 409183694",
+		"mas install 497799835",
+		"mas install 497799835",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected commands: %q", got)
+	}
+}
+
+func TestRunPlan_Failed(t *testing.T) {
+	bin := t.TempDir()
+	t.Setenv("PATH", bin)
+	fakeCommand(t, bin, "code", `echo "extension not found"; exit 1`)
+
+	planPath := filepath.Join(t.TempDir(), InstallPlanFile)
+	plan := NewInstallPlan(planPath, "backup")
+	plan.Items = []PlanItem{{Manager: ManagerVSCode, Name: "gone.extension", Line: "gone.extension", Status: ItemPending}}
+
+	installer := NewInstaller(false)
+	installer.SetRetries(2, 0)
+	_, err := installer.RunPlan(plan, ManagerVSCode)
+
+	var installErr *PackageInstallError
+	if !errors.As(err, &installErr) || !reflect.DeepEqual(installErr.FailedPackages, []string{"gone.extension"}) {
+		t.Errorf("Expected PackageInstallError, got %v", err)
+	}
+	item := plan.Items[0]
+	if item.Status != ItemFailed || item.Attempts != 3 || item.Error != "exit status 1: extension not found" {
+		t.Errorf("Unexpected item: %+v", item)
+	}
+
+	// Marking it installed lets the plan finish and removes it
+	plan.Items[0].Status = ItemInstalled
+	if !plan.Finish() {
+		t.Error("Finish() should remove a completed plan")
+	}
+	if _, err := os.Stat(planPath); !os.IsNotExist(err) {
+		t.Error("Expected the plan file to be removed")
+	}
+}
+
+func TestInstallPlan_Managers(t *testing.T) {
+	plan := NewInstallPlan(filepath.Join(t.TempDir(), InstallPlanFile), "backup")
+	plan.Items = []PlanItem{
+		{Manager: ManagerNPM, Name: "typescript", Status: ItemPending},
+		{Manager: ManagerPip, Name: "black", Status: ItemFailed},
+		{Manager: "nix", Name: "hello", Status: ItemPending},
+		{Manager: ManagerGo, Name: "gopls", Status: ItemPending},
+		{Manager: ManagerVSCode, Name: "golang.go", Status: ItemInstalled},
+		{Manager: ManagerBrew, Name: "python", Status: ItemPending},
+	}
+
+	// Resuming installs in restore order, not the order items were added
+	want := []string{ManagerBrew, ManagerGo, ManagerPip, ManagerNPM, "nix"}
+	if got := plan.Managers(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Managers() = %v, want %v", got, want)
+	}
+}
+
+func TestRunPlan_LanguagePackages(t *testing.T) {
+	bin := t.TempDir()
+	t.Setenv("PATH", bin)
+	log := filepath.Join(t.TempDir(), "commands.log")
+	for _, name := range []string{"go", "npm", "cargo", "uv"} {
+		fakeCommand(t, bin, name, `echo "`+name+` $*" >> `+log)
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, GoBinariesFile), []byte("golang.org/x/tools/gopls@v0.15.0\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "npm-global.txt"), []byte("/usr/lib\n├── typescript@5.3.3\n└── prettier@3.1.0\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "cargo-packages.txt"), []byte("ripgrep v14.1.0:\n    rg\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "uv-tools.json"), []byte(`[{"name": "ruff", "spec": "ruff", "python": "3.12"}]`), 0644)
+
+	lock := &Lock{Packages: []LockedPackage{
+		{Manager: ManagerNPM, Name: "typescript", Version: "5.3.3"},
+		{Manager: ManagerCargo, Name: "ripgrep", Version: "14.1.0"},
+		{Manager: ManagerUv, Name: "ruff", Version: "0.4.1"},
+	}}
+
+	planPath := filepath.Join(dir, InstallPlanFile)
+	plan := NewInstallPlan(planPath, "backup")
+	plan.ExactVersions = true
+	if err := plan.AddGoBinaries(filepath.Join(dir, GoBinariesFile), lock); err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.AddNPMPackages(filepath.Join(dir, "npm-global.txt"), lock); err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.AddLanguageList(ManagerCargo, filepath.Join(dir, "cargo-packages.txt"), lock); err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.AddLanguageList(ManagerUv, filepath.Join(dir, "uv-tools.json"), lock); err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A resumed restore reads the pinned targets and ExactVersions back
+	saved, err := LoadInstallPlan(planPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !saved.ExactVersions || saved.Remaining() != 5 {
+		t.Fatalf("Unexpected saved plan: %+v", saved)
+	}
+
+	installer := NewInstaller(false)
+	for _, manager := range saved.Managers() {
+		if _, err := installer.RunPlan(saved, manager); err != nil {
+			t.Errorf("RunPlan(%s): %v", manager, err)
+		}
+	}
+	if saved.Remaining() != 0 {
+		t.Errorf("Expected every item installed, got %+v", saved.Items)
+	}
+
+	data, _ := os.ReadFile(log)
+	got := nonEmptyLines(data)
+	sort.Strings(got)
+	want := []string{
+		"cargo install ripgrep --version 14.1.0",
+		"go install golang.org/x/tools/gopls@v0.15.0",
+		"npm install -g prettier",
+		"npm install -g typescript@5.3.3",
+		"uv tool install ruff==0.4.1 --python 3.12",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected commands: %q", got)
+	}
+}
@@ -0,0 +1,94 @@
+package packager
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// BrewServicesFile lists the Homebrew services that were running at backup time
+const BrewServicesFile = "brew-services.json"
+
+// BrewService is a service `brew services` manages
+type BrewService struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// User is who ran the service; root means it was started with sudo
+	User string `json:"user,omitempty"`
+}
+
+// CollectBrewServices records the running Homebrew services, so restore can
+// start them again
+func (p *Packager) CollectBrewServices() error {
+	if !commandExists("brew") {
+		return fmt.Errorf("brew not installed")
+	}
+
+	output, err := exec.Command("brew", "services", "list", "--json").Output()
+	if err != nil {
+		return fmt.Errorf("brew services list failed: %v", err)
+	}
+	var services []BrewService
+	if err := json.Unmarshal(output, &services); err != nil {
+		return fmt.Errorf("failed to parse brew services: %w", err)
+	}
+
+	var running []BrewService
+	for _, service := range services {
+		if service.Status == "started" {
+			running = append(running, service)
+		}
+	}
+	if len(running) == 0 {
+		return fmt.Errorf("no brew services running")
+	}
+
+	data, err := json.MarshalIndent(running, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(p.outputDir, BrewServicesFile), data, 0644)
+}
+
+// LoadBrewServices reads a brew services list
+func LoadBrewServices(path string) ([]BrewService, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var services []BrewService
+	if err := json.Unmarshal(data, &services); err != nil {
+		return nil, fmt.Errorf("failed to parse brew services: %w", err)
+	}
+	return services, nil
+}
+
+// StartBrewServices starts the services in the list. Services that root ran
+// are started with sudo, as they were before.
+func (i *Installer) StartBrewServices(servicesPath string) (int, error) {
+	if !commandExists("brew") {
+		return 0, fmt.Errorf("brew not found - install Homebrew first")
+	}
+
+	services, err := LoadBrewServices(servicesPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read brew services file: %w", err)
+	}
+
+	var names []string
+	asRoot := make(map[string]bool)
+	for _, service := range services {
+		if i.wanted(service.Name) {
+			names = append(names, service.Name)
+			asRoot[service.Name] = service.User == "root"
+		}
+	}
+	return i.installEach("brew services", names, func(name string) *exec.Cmd {
+		if asRoot[name] {
+			return privileged("brew", "services", "start", name)
+		}
+		return exec.Command("brew", "services", "start", name)
+	})
+}
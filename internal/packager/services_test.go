@@ -0,0 +1,54 @@
+package packager
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBrewServices(t *testing.T) {
+	bin := t.TempDir()
+	t.Setenv("PATH", bin)
+	log := filepath.Join(t.TempDir(), "commands.log")
+
+	fakeCommand(t, bin, "brew", `if [ "$1 $2" = "services list" ]; then
+  echo '[{"name": "postgresql@16", "status": "started", "user": "me", "exit_code": 0},'
+  echo ' {"name": "redis", "status": "none", "user": null, "exit_code": null},'
+  echo ' {"name": "nginx", "status": "started", "user": "root", "exit_code": 0}]'
+else
+  echo "brew $*" >> `+log+`
+fi`)
+	fakeCommand(t, bin, "sudo", `echo "sudo $*" >> `+log)
+
+	dir := t.TempDir()
+	if err := NewPackager(dir).CollectBrewServices(); err != nil {
+		t.Fatal(err)
+	}
+	services, err := LoadBrewServices(filepath.Join(dir, BrewServicesFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []BrewService{
+		{Name: "postgresql@16", Status: "started", User: "me"},
+		{Name: "nginx", Status: "started", User: "root"},
+	}
+	if !reflect.DeepEqual(services, want) {
+		t.Errorf("Unexpected services: %+v", services)
+	}
+
+	started, err := NewInstaller(false).StartBrewServices(filepath.Join(dir, BrewServicesFile))
+	if started != 2 || err != nil {
+		t.Errorf("StartBrewServices() = %d, %v", started, err)
+	}
+
+	data, _ := os.ReadFile(log)
+	commands := []string{"brew services start postgresql@16", "brew services start nginx"}
+	// Tests running as root start everything directly
+	if os.Geteuid() != 0 {
+		commands[1] = "sudo brew services start nginx"
+	}
+	if got := nonEmptyLines(data); !reflect.DeepEqual(got, commands) {
+		t.Errorf("Unexpected commands: %q", got)
+	}
+}
@@ -0,0 +1,351 @@
+package packager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Lists that are compared by status but have no versions in the lock file
+const (
+	ManagerTap    = "tap"
+	ManagerMAS    = "mas"
+	ManagerVSCode = "vscode"
+)
+
+// PackageStatus compares one package manager's list in a backup with what
+// is installed on this machine
+type PackageStatus struct {
+	Manager string
+	Label   string
+	// Packages is how many packages the backup lists
+	Packages int
+	// Missing are listed in the backup but not installed; Extra are
+	// installed but not in the backup
+	Missing    []string
+	Extra      []string
+	Mismatched []VersionMismatch
+	// Unavailable is set when the manager itself isn't installed, which
+	// leaves every package missing
+	Unavailable bool
+}
+
+// VersionMismatch is a package installed at a different version than the
+// backup recorded
+type VersionMismatch struct {
+	Name      string
+	Backup    string
+	Installed string
+}
+
+// InSync reports whether the machine matches the backup for this manager
+func (s PackageStatus) InSync() bool {
+	return len(s.Missing)+len(s.Extra)+len(s.Mismatched) == 0
+}
+
+// Summary describes the differences in one line
+func (s PackageStatus) Summary() string {
+	if s.Unavailable {
+		return fmt.Sprintf("not available on this machine, %d missing", len(s.Missing))
+	}
+	if s.InSync() {
+		return fmt.Sprintf("%d installed", s.Packages)
+	}
+	var parts []string
+	if len(s.Missing) > 0 {
+		parts = append(parts, fmt.Sprintf("%d missing", len(s.Missing)))
+	}
+	if len(s.Extra) > 0 {
+		parts = append(parts, fmt.Sprintf("%d extra", len(s.Extra)))
+	}
+	if len(s.Mismatched) > 0 {
+		parts = append(parts, fmt.Sprintf("%d at another version", len(s.Mismatched)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// statusSource is a list status can compare: the file a collector writes
+// and how to read package names back out of it. The first field of each
+// name identifies the package; anything after it is for display.
+type statusSource struct {
+	manager string
+	label   string
+	file    string
+	collect func(*Packager) error
+	names   func(path string) ([]string, error)
+}
+
+var languageCollectors = map[string]func(*Packager) error{
+	ManagerPipx:     (*Packager).CollectPipx,
+	ManagerUv:       (*Packager).CollectUvTools,
+	ManagerPip:      (*Packager).CollectPip,
+	ManagerCargo:    (*Packager).CollectCargo,
+	ManagerGem:      (*Packager).CollectGem,
+	ManagerPnpm:     (*Packager).CollectPnpm,
+	ManagerComposer: (*Packager).CollectComposer,
+}
+
+func statusSources() []statusSource {
+	sources := []statusSource{
+		{ManagerTap, "Homebrew taps", "Brewfile", (*Packager).CollectHomebrew, brewfileNames(ManagerTap)},
+		{ManagerBrew, "Homebrew formulae", "Brewfile", (*Packager).CollectHomebrew, brewfileNames(ManagerBrew)},
+		{ManagerCask, "Homebrew casks", "Brewfile", (*Packager).CollectHomebrew, brewfileNames(ManagerCask)},
+		{ManagerMAS, "App Store apps", "mas-apps.txt", (*Packager).CollectMAS, masNames},
+		{ManagerVSCode, "VS Code extensions", "vscode-extensions.txt", (*Packager).CollectVSCode, readNonEmptyLines},
+		{ManagerNPM, "npm globals", "npm-global.txt", (*Packager).CollectNPM, npmNames},
+	}
+	for _, m := range LanguageManagers {
+		sources = append(sources, statusSource{m.Name, m.Label, m.File, languageCollectors[m.Name], languageNames(m.Name)})
+	}
+	sources = append(sources, statusSource{ManagerGo, "Go binaries", GoBinariesFile, (*Packager).CollectGoBinaries, goBinaryNames})
+	for _, manager := range SystemManagers {
+		sources = append(sources, statusSource{manager, manager + " packages", systemPackageFiles[manager], systemCollectors[manager], readNonEmptyLines})
+	}
+	return sources
+}
+
+// CheckPackages compares the package lists a backup wrote to packagesDir
+// with the packages installed now, listing them again with the same
+// collectors. Only managers with packages in the backup are checked.
+func CheckPackages(packagesDir string) ([]PackageStatus, error) {
+	liveDir, err := os.MkdirTemp("", "stash-packages-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(liveDir)
+	live := NewPackager(liveDir)
+
+	type checked struct {
+		source statusSource
+		names  []string
+	}
+	var lists []checked
+	collected := make(map[string]error)
+	for _, source := range statusSources() {
+		names, err := source.names(filepath.Join(packagesDir, source.file))
+		if err != nil || len(names) == 0 {
+			continue
+		}
+		// The three Homebrew lists share one Brewfile
+		if _, ok := collected[source.file]; !ok {
+			collected[source.file] = source.collect(live)
+		}
+		lists = append(lists, checked{source, names})
+	}
+
+	// Versions come from the collected lists, so this runs after them
+	backupLock, _ := LoadLock(filepath.Join(packagesDir, LockFile))
+	liveLock := BuildLock(liveDir)
+	liveLock.Packages = append(liveLock.Packages, brewVersions()...)
+
+	var statuses []PackageStatus
+	for _, list := range lists {
+		status := PackageStatus{Manager: list.source.manager, Label: list.source.label, Packages: len(list.names)}
+
+		var installed []string
+		if collected[list.source.file] != nil {
+			status.Unavailable = true
+		} else {
+			installed, _ = list.source.names(filepath.Join(liveDir, list.source.file))
+		}
+
+		installedKeys := packageKeys(installed)
+		backupKeys := packageKeys(list.names)
+		for _, name := range list.names {
+			key := packageKey(name)
+			if !installedKeys[key] {
+				status.Missing = append(status.Missing, name)
+				continue
+			}
+			backupVersion := backupLock.Version(status.Manager, key)
+			installedVersion := liveLock.Version(status.Manager, key)
+			if backupVersion != "" && installedVersion != "" && backupVersion != installedVersion {
+				status.Mismatched = append(status.Mismatched, VersionMismatch{Name: key, Backup: backupVersion, Installed: installedVersion})
+			}
+		}
+		for _, name := range installed {
+			if !backupKeys[packageKey(name)] {
+				status.Extra = append(status.Extra, name)
+			}
+		}
+
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// InstallMissing installs the packages status found missing, reading what
+// each manager needs to install them from the lists in packagesDir
+func (i *Installer) InstallMissing(packagesDir string, status PackageStatus) (int, error) {
+	if len(status.Missing) == 0 {
+		return 0, nil
+	}
+
+	i.only = packageKeys(status.Missing)
+	defer func() { i.only = nil }()
+
+	path := filepath.Join(packagesDir, fileForManager(status.Manager))
+	switch status.Manager {
+	case ManagerTap, ManagerBrew, ManagerCask:
+		return i.installMissingBrew(path, status.Manager)
+	case ManagerMAS:
+		return i.InstallMASApps(path)
+	case ManagerVSCode:
+		return i.InstallVSCodeExtensions(path)
+	case ManagerNPM:
+		return i.InstallNPMPackages(path)
+	case ManagerGo:
+		return i.InstallGoBinaries(path)
+	}
+
+	if _, ok := LookupLanguageManager(status.Manager); ok {
+		return i.InstallLanguagePackages(status.Manager, path)
+	}
+	for _, plan := range PlanSystemPackages(packagesDir) {
+		if plan.Manager == status.Manager {
+			// Sources were added when the backup was restored
+			return i.InstallSystemPackages(SystemPlan{Manager: plan.Manager, Packages: i.wantedLines(plan.Packages)})
+		}
+	}
+	return 0, fmt.Errorf("unknown package manager: %s", status.Manager)
+}
+
+// installMissingBrew runs brew bundle on the backup's Brewfile cut down to
+// the wanted entries of one type, keeping their options and cask_args
+func (i *Installer) installMissingBrew(brewfilePath, itemType string) (int, error) {
+	brewfile, err := LoadBrewfile(brewfilePath)
+	if err != nil {
+		return 0, err
+	}
+	missing := brewfile.Filter(func(item BrewfileItem) bool {
+		return item.Type == itemType && i.wanted(item.Name)
+	})
+
+	tempFile, err := os.CreateTemp("", "stash-Brewfile-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temp Brewfile: %w", err)
+	}
+	tempFile.Close()
+	defer os.Remove(tempFile.Name())
+
+	if err := missing.WriteFile(tempFile.Name()); err != nil {
+		return 0, err
+	}
+	if err := i.InstallBrewPackages(tempFile.Name()); err != nil {
+		return 0, err
+	}
+	return len(missing.Items), nil
+}
+
+// fileForManager returns the list file status reads for manager
+func fileForManager(manager string) string {
+	for _, source := range statusSources() {
+		if source.manager == manager {
+			return source.file
+		}
+	}
+	return ""
+}
+
+// packageKey returns the part of a status name that identifies the package
+func packageKey(name string) string {
+	if fields := strings.Fields(name); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
+
+func packageKeys(names []string) map[string]bool {
+	keys := make(map[string]bool)
+	for _, name := range names {
+		keys[packageKey(name)] = true
+	}
+	return keys
+}
+
+func brewfileNames(itemType string) func(path string) ([]string, error) {
+	return func(path string) ([]string, error) {
+		items, err := ParseBrewfile(path)
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, item := range items {
+			if item.Type == itemType {
+				names = append(names, item.Name)
+			}
+		}
+		return names, nil
+	}
+}
+
+// masNames reads `mas list` output, "id  Name  (version)", dropping the version
+func masNames(path string) ([]string, error) {
+	lines, err := readNonEmptyLines(path)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, line := range lines {
+		if i := strings.LastIndex(line, " ("); i > 0 {
+			line = line[:i]
+		}
+		names = append(names, strings.Join(strings.Fields(line), " "))
+	}
+	return names, nil
+}
+
+func npmNames(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, pkg := range parseNpmList(string(data)) {
+		// npm itself ships with Node.js
+		if pkg.Name != "npm" {
+			names = append(names, pkg.Name)
+		}
+	}
+	return names, nil
+}
+
+func languageNames(manager string) func(path string) ([]string, error) {
+	return func(path string) ([]string, error) {
+		if manager == ManagerPipx || manager == ManagerUv {
+			tools, err := LoadToolEnvs(path)
+			if err != nil {
+				return nil, err
+			}
+			var names []string
+			for _, tool := range tools {
+				names = append(names, tool.Name)
+			}
+			return names, nil
+		}
+
+		packages, err := readLanguageList(manager, path)
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, pkg := range packages {
+			names = append(names, pkg.Name)
+		}
+		return names, nil
+	}
+}
+
+func goBinaryNames(path string) ([]string, error) {
+	targets, err := LoadGoBinaries(path)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, target := range targets {
+		name, _, _ := strings.Cut(target, "@")
+		names = append(names, name)
+	}
+	return names, nil
+}
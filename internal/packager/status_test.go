@@ -0,0 +1,76 @@
+package packager
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckPackages(t *testing.T) {
+	bin := t.TempDir()
+	t.Setenv("PATH", bin)
+	log := filepath.Join(t.TempDir(), "commands.log")
+
+	fakeCommand(t, bin, "code", `case "$1" in
+--list-extensions) printf 'golang.go\nms-python.python\n' ;;
+*) echo "code $*" >> `+log+` ;;
+esac`)
+	fakeCommand(t, bin, "cargo", `case "$1 $2" in
+"install --list") printf 'ripgrep v14.1.0:\n    rg\nbat v0.24.0:\n    bat\n' ;;
+*) echo "cargo $*" >> `+log+` ;;
+esac`)
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "vscode-extensions.txt"), []byte("golang.go\nms-python.python\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "cargo-packages.txt"),
+		[]byte("ripgrep v13.0.0:\n    rg\nzoxide v0.9.4:\n    zoxide\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "gem-packages.txt"), []byte("rake (13.1.0)\n"), 0644)
+	lock := BuildLock(dir)
+	data, _ := json.Marshal(lock)
+	os.WriteFile(filepath.Join(dir, LockFile), data, 0644)
+
+	statuses, err := CheckPackages(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []PackageStatus{
+		{Manager: ManagerVSCode, Label: "VS Code extensions", Packages: 2},
+		{
+			Manager:    ManagerCargo,
+			Label:      "Cargo crates",
+			Packages:   2,
+			Missing:    []string{"zoxide"},
+			Extra:      []string{"bat"},
+			Mismatched: []VersionMismatch{{Name: "ripgrep", Backup: "13.0.0", Installed: "14.1.0"}},
+		},
+		{Manager: ManagerGem, Label: "Ruby gems", Packages: 1, Missing: []string{"rake"}, Unavailable: true},
+	}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Fatalf("CheckPackages() = %+v, want %+v", statuses, want)
+	}
+	if !statuses[0].InSync() || statuses[1].InSync() {
+		t.Error("Expected only VS Code to be in sync")
+	}
+	if got := statuses[1].Summary(); got != "1 missing, 1 extra, 1 at another version" {
+		t.Errorf("Summary() = %q", got)
+	}
+
+	// Only the missing crate is installed, pinned when a lock is in use
+	installer := NewInstaller(false)
+	installer.UseLock(lock)
+	if installed, err := installer.InstallMissing(dir, statuses[1]); installed != 1 || err != nil {
+		t.Errorf("InstallMissing() = %d, %v", installed, err)
+	}
+	if _, err := installer.InstallMissing(dir, statuses[2]); err == nil {
+		t.Error("Expected error when gem isn't installed")
+	}
+
+	output, _ := os.ReadFile(log)
+	if got := nonEmptyLines(output); !reflect.DeepEqual(got, []string{"cargo install zoxide --version 0.9.4"}) {
+		t.Errorf("Unexpected commands:\n%s", strings.Join(got, "\n"))
+	}
+}
@@ -0,0 +1,467 @@
+package packager
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Linux system package managers
+const (
+	ManagerApt     = "apt"
+	ManagerDnf     = "dnf"
+	ManagerPacman  = "pacman"
+	ManagerFlatpak = "flatpak"
+	ManagerSnap    = "snap"
+)
+
+// SystemManagers lists the Linux package managers in install order. Native
+// managers come first so flatpak and snap can be installed by them.
+var SystemManagers = []string{ManagerApt, ManagerDnf, ManagerPacman, ManagerFlatpak, ManagerSnap}
+
+// Files written for each system package manager in the packages directory
+var systemPackageFiles = map[string]string{
+	ManagerApt:     "apt-packages.txt",
+	ManagerDnf:     "dnf-packages.txt",
+	ManagerPacman:  "pacman-packages.txt",
+	ManagerFlatpak: "flatpak-apps.txt",
+	ManagerSnap:    "snap-packages.txt",
+}
+
+var systemSourceFiles = map[string]string{
+	ManagerApt:     "apt-sources.txt",
+	ManagerDnf:     "dnf-repos.txt",
+	ManagerPacman:  "pacman-repos.txt",
+	ManagerFlatpak: "flatpak-remotes.txt",
+}
+
+var systemCollectors = map[string]func(*Packager) error{
+	ManagerApt:     (*Packager).CollectApt,
+	ManagerDnf:     (*Packager).CollectDnf,
+	ManagerPacman:  (*Packager).CollectPacman,
+	ManagerFlatpak: (*Packager).CollectFlatpak,
+	ManagerSnap:    (*Packager).CollectSnap,
+}
+
+// pacmanAURFile lists foreign packages, which pacman can't install itself
+const pacmanAURFile = "pacman-aur.txt"
+
+// Locations of repository configuration, overridable in tests
+var (
+	aptSourcesDir = "/etc/apt/sources.list.d"
+	pacmanConf    = "/etc/pacman.conf"
+)
+
+// Apt hosts serving the distribution itself; sources from these come with the OS
+var officialAptHosts = []string{
+	"archive.ubuntu.com",
+	"security.ubuntu.com",
+	"ports.ubuntu.com",
+	"deb.debian.org",
+	"security.debian.org",
+}
+
+// Repositories Fedora ships enabled; the rest were added by the user
+var officialDnfRepos = map[string]bool{
+	"fedora":                  true,
+	"updates":                 true,
+	"updates-testing":         true,
+	"fedora-modular":          true,
+	"updates-modular":         true,
+	"fedora-cisco-openh264":   true,
+	"updates-testing-modular": true,
+}
+
+// Repositories every pacman.conf ships with
+var officialPacmanRepos = map[string]bool{
+	"options":          true,
+	"core":             true,
+	"extra":            true,
+	"community":        true,
+	"multilib":         true,
+	"core-testing":     true,
+	"extra-testing":    true,
+	"multilib-testing": true,
+}
+
+var ppaURIPattern = regexp.MustCompile(`^https?://ppa\.launchpad(?:content)?\.net/([^/]+)/([^/]+)/`)
+
+// Snaps that come with the system rather than being installed by the user
+var snapSystemTypes = []string{"base", "core", "snapd"}
+
+// CollectSystemPackages records packages from the Linux system package
+// managers that are installed, returning counts keyed by manager name
+func (p *Packager) CollectSystemPackages() map[string]int {
+	counts := make(map[string]int)
+	for _, manager := range SystemManagers {
+		if err := systemCollectors[manager](p); err == nil {
+			counts[manager] = p.countLines(filepath.Join(p.outputDir, systemPackageFiles[manager]))
+		}
+	}
+	return counts
+}
+
+// CollectApt records manually installed apt packages and third-party sources
+func (p *Packager) CollectApt() error {
+	if !commandExists("apt-mark") {
+		return fmt.Errorf("apt not installed")
+	}
+
+	output, err := exec.Command("apt-mark", "showmanual").Output()
+	if err != nil {
+		return fmt.Errorf("apt-mark showmanual failed: %v", err)
+	}
+
+	if err := p.writeList(systemPackageFiles[ManagerApt], "", sortedLines(output)); err != nil {
+		return err
+	}
+	return p.writeList(systemSourceFiles[ManagerApt],
+		"# Third-party apt sources (ppa:owner/name or deb lines)", aptSources(aptSourcesDir))
+}
+
+// CollectDnf records user-installed dnf packages and enabled repositories
+// other than the ones Fedora ships with
+func (p *Packager) CollectDnf() error {
+	if !commandExists("dnf") {
+		return fmt.Errorf("dnf not installed")
+	}
+
+	// dnf5 needs the trailing newline, dnf4 adds its own; blank lines are skipped
+	output, err := exec.Command("dnf", "repoquery", "--userinstalled", "--queryformat", "%{name}\n").Output()
+	if err != nil {
+		return fmt.Errorf("dnf repoquery failed: %v", err)
+	}
+	if err := p.writeList(systemPackageFiles[ManagerDnf], "", sortedLines(output)); err != nil {
+		return err
+	}
+
+	enabled, err := dnfEnabledRepos()
+	if err != nil {
+		return err
+	}
+	var repos []string
+	for _, repo := range enabled {
+		if !officialDnfRepos[repo] {
+			repos = append(repos, repo)
+		}
+	}
+	return p.writeList(systemSourceFiles[ManagerDnf], "# Enabled dnf repositories added to the system", repos)
+}
+
+// CollectPacman records explicitly installed packages, keeping AUR packages
+// separate, and any repositories added to pacman.conf
+func (p *Packager) CollectPacman() error {
+	if !commandExists("pacman") {
+		return fmt.Errorf("pacman not installed")
+	}
+
+	native, err := exec.Command("pacman", "-Qqen").Output()
+	if err != nil {
+		return fmt.Errorf("pacman -Qqen failed: %v", err)
+	}
+	if err := p.writeList(systemPackageFiles[ManagerPacman], "", sortedLines(native)); err != nil {
+		return err
+	}
+
+	// -Qqem exits non-zero when there are no foreign packages
+	foreign, _ := exec.Command("pacman", "-Qqem").Output()
+	if err := p.writeList(pacmanAURFile, "# Foreign (AUR) packages - install with your AUR helper", sortedLines(foreign)); err != nil {
+		return err
+	}
+
+	var repos []string
+	for _, repo := range pacmanRepos(pacmanConf) {
+		if !officialPacmanRepos[repo.Name] {
+			repos = append(repos, repo.String())
+		}
+	}
+	return p.writeList(systemSourceFiles[ManagerPacman], "# Repositories added to pacman.conf", repos)
+}
+
+// CollectFlatpak records installed flatpak apps and their remotes
+func (p *Packager) CollectFlatpak() error {
+	if !commandExists("flatpak") {
+		return fmt.Errorf("flatpak not installed")
+	}
+
+	apps, err := exec.Command("flatpak", "list", "--app", "--columns=application,origin,installation").Output()
+	if err != nil {
+		return fmt.Errorf("flatpak list failed: %v", err)
+	}
+	if err := p.writeList(systemPackageFiles[ManagerFlatpak], "# application origin installation", nonEmptyLines(apps)); err != nil {
+		return err
+	}
+
+	remotes, err := exec.Command("flatpak", "remotes", "--columns=name,url,options").Output()
+	if err != nil {
+		return fmt.Errorf("flatpak remotes failed: %v", err)
+	}
+	return p.writeList(systemSourceFiles[ManagerFlatpak], "# name url options", nonEmptyLines(remotes))
+}
+
+// CollectSnap records installed snaps with their channel and confinement
+func (p *Packager) CollectSnap() error {
+	if !commandExists("snap") {
+		return fmt.Errorf("snap not installed")
+	}
+
+	output, err := exec.Command("snap", "list").Output()
+	if err != nil {
+		return fmt.Errorf("snap list failed: %v", err)
+	}
+	return p.writeList(systemPackageFiles[ManagerSnap], "# name channel [classic]", parseSnapList(string(output)))
+}
+
+func (p *Packager) writeList(name, header string, lines []string) error {
+	var content strings.Builder
+	if header != "" {
+		content.WriteString(header + "\n")
+	}
+	for _, line := range lines {
+		content.WriteString(line + "\n")
+	}
+	return os.WriteFile(filepath.Join(p.outputDir, name), []byte(content.String()), 0644)
+}
+
+// parseSnapList turns `snap list` output into "name channel [classic]" lines,
+// leaving out bases, cores and snapd itself
+func parseSnapList(output string) []string {
+	var snaps []string
+	for i, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		// Name Version Rev Tracking Publisher Notes
+		if i == 0 || len(fields) < 4 {
+			continue
+		}
+
+		notes := ""
+		if len(fields) >= 6 {
+			notes = fields[5]
+		}
+		if isSnapSystem(fields[0], notes) {
+			continue
+		}
+
+		entry := fields[0] + " " + fields[3]
+		if strings.Contains(notes, "classic") {
+			entry += " classic"
+		}
+		snaps = append(snaps, entry)
+	}
+	return snaps
+}
+
+func isSnapSystem(name, notes string) bool {
+	for _, kind := range snapSystemTypes {
+		if name == kind || strings.Contains(notes, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// aptSources lists third-party sources from one-line .list files and deb822
+// .sources files, turning Launchpad PPAs into ppa:owner/name
+func aptSources(dir string) []string {
+	var sources []string
+	seen := make(map[string]bool)
+	add := func(source string) {
+		if source != "" && !seen[source] {
+			seen[source] = true
+			sources = append(sources, source)
+		}
+	}
+
+	lists, _ := filepath.Glob(filepath.Join(dir, "*.list"))
+	for _, path := range lists {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "deb ") {
+				add(aptSource(line))
+			}
+		}
+	}
+
+	deb822, _ := filepath.Glob(filepath.Join(dir, "*.sources"))
+	for _, path := range deb822 {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		for _, line := range deb822Lines(string(data)) {
+			add(aptSource(line))
+		}
+	}
+
+	return sources
+}
+
+// aptSource normalizes a "deb ..." line, returning "" for official mirrors
+func aptSource(line string) string {
+	var uri string
+	for _, field := range strings.Fields(line)[1:] {
+		if !strings.HasPrefix(field, "[") && !strings.HasSuffix(field, "]") && !strings.Contains(field, "=") {
+			uri = field
+			break
+		}
+	}
+
+	if m := ppaURIPattern.FindStringSubmatch(uri); m != nil {
+		return "ppa:" + m[1] + "/" + m[2]
+	}
+	for _, host := range officialAptHosts {
+		if strings.Contains(uri, "://"+host+"/") || strings.Contains(uri, "."+host+"/") {
+			return ""
+		}
+	}
+	return line
+}
+
+// deb822Lines converts enabled deb822 stanzas to one-line "deb" entries
+func deb822Lines(content string) []string {
+	var lines []string
+	for _, stanza := range strings.Split(content, "\n\n") {
+		fields := make(map[string]string)
+		for _, line := range strings.Split(stanza, "\n") {
+			if key, value, ok := strings.Cut(line, ":"); ok && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "#") {
+				fields[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+			}
+		}
+		if !strings.Contains(fields["types"], "deb") || strings.EqualFold(fields["enabled"], "no") {
+			continue
+		}
+		for _, uri := range strings.Fields(fields["uris"]) {
+			for _, suite := range strings.Fields(fields["suites"]) {
+				lines = append(lines, strings.TrimSpace("deb "+uri+" "+suite+" "+fields["components"]))
+			}
+		}
+	}
+	return lines
+}
+
+// pacmanRepo is a [section] in pacman.conf with the servers it uses
+type pacmanRepo struct {
+	Name    string
+	Servers []string
+}
+
+func (r pacmanRepo) String() string {
+	if len(r.Servers) == 0 {
+		return r.Name
+	}
+	return r.Name + " " + strings.Join(r.Servers, " ")
+}
+
+// pacmanRepos reads the repository sections of a pacman.conf
+func pacmanRepos(path string) []pacmanRepo {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var repos []pacmanRepo
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			repos = append(repos, pacmanRepo{Name: strings.Trim(line, "[]")})
+		case len(repos) > 0 && strings.HasPrefix(line, "Server"):
+			if _, server, ok := strings.Cut(line, "="); ok {
+				repos[len(repos)-1].Servers = append(repos[len(repos)-1].Servers, strings.TrimSpace(server))
+			}
+		}
+	}
+	return repos
+}
+
+// dnfEnabledRepos returns the ids of enabled dnf repositories
+func dnfEnabledRepos() ([]string, error) {
+	output, err := exec.Command("dnf", "repolist", "--enabled").Output()
+	if err != nil {
+		return nil, fmt.Errorf("dnf repolist failed: %v", err)
+	}
+
+	var repos []string
+	for i, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		// First line is the "repo id  repo name" header
+		if i == 0 || len(fields) == 0 {
+			continue
+		}
+		repos = append(repos, fields[0])
+	}
+	return repos, nil
+}
+
+func nonEmptyLines(output []byte) []string {
+	var lines []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func sortedLines(output []byte) []string {
+	lines := nonEmptyLines(output)
+	sort.Strings(lines)
+	return lines
+}
+
+// SystemPlan is what restoring one system package manager would do
+type SystemPlan struct {
+	Manager  string
+	Packages []string
+	// Sources are PPAs, repos or remotes to add before installing
+	Sources []string
+	// Manual lists items stash can't install itself, such as AUR packages
+	Manual []string
+}
+
+// Summary describes the plan in one line
+func (p SystemPlan) Summary() string {
+	summary := fmt.Sprintf("%s: %d package(s)", p.Manager, len(p.Packages))
+	if len(p.Sources) > 0 {
+		summary += fmt.Sprintf(", %d source(s)", len(p.Sources))
+	}
+	if len(p.Manual) > 0 {
+		summary += fmt.Sprintf(", %d to install by hand", len(p.Manual))
+	}
+	return summary
+}
+
+// PlanSystemPackages reads the system package lists in packagesDir
+func PlanSystemPackages(packagesDir string) []SystemPlan {
+	var plans []SystemPlan
+	for _, manager := range SystemManagers {
+		packages, err := readNonEmptyLines(filepath.Join(packagesDir, systemPackageFiles[manager]))
+		if err != nil {
+			continue
+		}
+
+		plan := SystemPlan{Manager: manager, Packages: packages}
+		if name, ok := systemSourceFiles[manager]; ok {
+			plan.Sources, _ = readNonEmptyLines(filepath.Join(packagesDir, name))
+		}
+		if manager == ManagerPacman {
+			plan.Manual, _ = readNonEmptyLines(filepath.Join(packagesDir, pacmanAURFile))
+		}
+
+		if len(plan.Packages)+len(plan.Manual) > 0 {
+			plans = append(plans, plan)
+		}
+	}
+	return plans
+}
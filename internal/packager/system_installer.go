@@ -0,0 +1,273 @@
+package packager
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/harshpatel5940/stash/internal/ui"
+)
+
+// systemCommands is the binary each manager needs on the restoring machine
+var systemCommands = map[string]string{
+	ManagerApt:     "apt-get",
+	ManagerDnf:     "dnf",
+	ManagerPacman:  "pacman",
+	ManagerFlatpak: "flatpak",
+	ManagerSnap:    "snap",
+}
+
+// InstallSystemPackages adds the plan's sources and installs its packages,
+// returning how many packages were installed
+func (i *Installer) InstallSystemPackages(plan SystemPlan) (int, error) {
+	if name := systemCommands[plan.Manager]; !commandExists(name) {
+		return 0, fmt.Errorf("%s not found on this system", name)
+	}
+
+	var manual []string
+	var installed int
+	var err error
+
+	switch plan.Manager {
+	case ManagerApt:
+		manual = i.addAptSources(plan.Sources)
+		installed, err = i.installBatch(plan.Manager, plan.Packages, aptInstallCommand)
+	case ManagerDnf:
+		manual = i.addDnfRepos(plan.Sources)
+		installed, err = i.installBatch(plan.Manager, plan.Packages, func(pkgs ...string) *exec.Cmd {
+			return privileged("dnf", append([]string{"install", "-y"}, pkgs...)...)
+		})
+	case ManagerPacman:
+		manual = missingPacmanRepos(plan.Sources)
+		installed, err = i.installBatch(plan.Manager, plan.Packages, func(pkgs ...string) *exec.Cmd {
+			return privileged("pacman", append([]string{"-S", "--needed", "--noconfirm"}, pkgs...)...)
+		})
+	case ManagerFlatpak:
+		manual = i.addFlatpakRemotes(plan.Sources)
+		installed, err = i.installEach(plan.Manager, plan.Packages, flatpakInstallCommand)
+	case ManagerSnap:
+		installed, err = i.installEach(plan.Manager, plan.Packages, snapInstallCommand)
+	default:
+		return 0, fmt.Errorf("unknown package manager: %s", plan.Manager)
+	}
+
+	if len(manual) > 0 {
+		fmt.Printf("  💡 Add these %s sources by hand:\n", plan.Manager)
+		for _, source := range manual {
+			fmt.Printf("    %s\n", source)
+		}
+	}
+	if len(plan.Manual) > 0 {
+		fmt.Printf("  💡 Install these with your AUR helper: %s\n", strings.Join(plan.Manual, " "))
+	}
+
+	return installed, err
+}
+
+// installBatch installs packages in one command. If that fails, typically
+// because a package doesn't exist on this release, it retries one at a time
+// so the rest still get installed.
+func (i *Installer) installBatch(manager string, packages []string, command func(pkgs ...string) *exec.Cmd) (int, error) {
+	if len(packages) == 0 {
+		return 0, nil
+	}
+
+	fmt.Printf("  Installing %d %s packages...\n", len(packages), manager)
+	if err := i.runCommand(command(packages...)); err == nil {
+		return len(packages), nil
+	} else if i.verbose {
+		fmt.Printf("    Batch install failed (%v), retrying one at a time\n", err)
+	}
+
+	return i.installEach(manager, packages, func(pkg string) *exec.Cmd {
+		return command(pkg)
+	})
+}
+
+// installEach installs packages one at a time with a progress bar
+func (i *Installer) installEach(manager string, packages []string, command func(pkg string) *exec.Cmd) (int, error) {
+	if len(packages) == 0 {
+		return 0, nil
+	}
+
+	bar := ui.NewProgressBar(len(packages), manager)
+
+	installed := 0
+	var failed []string
+	for _, pkg := range packages {
+		if err := i.runCommand(command(pkg)); err != nil {
+			failed = append(failed, strings.Fields(pkg)[0])
+			if i.verbose {
+				fmt.Printf("    Failed to install %s: %v\n", pkg, err)
+			}
+		} else {
+			installed++
+		}
+		bar.Add(1)
+	}
+	bar.Finish()
+
+	if len(failed) > 0 {
+		return installed, &PackageInstallError{Manager: manager, FailedPackages: failed}
+	}
+	return installed, nil
+}
+
+// runCommand runs cmd, streaming its output in verbose mode and otherwise
+// including the last lines of output in the error
+func (i *Installer) runCommand(cmd *exec.Cmd) error {
+	// sudo may need to ask for a password
+	cmd.Stdin = os.Stdin
+	if i.verbose {
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	}
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		lines := nonEmptyLines(output)
+		if len(lines) > 0 {
+			return fmt.Errorf("%v: %s", err, lines[len(lines)-1])
+		}
+	}
+	return err
+}
+
+// addAptSources adds PPAs and refreshes the package index, returning the
+// sources that need a signing key and have to be added by hand
+func (i *Installer) addAptSources(sources []string) []string {
+	var manual []string
+	added := 0
+	for _, source := range sources {
+		if !strings.HasPrefix(source, "ppa:") || !commandExists("add-apt-repository") {
+			manual = append(manual, source)
+			continue
+		}
+		if err := i.runCommand(privileged("add-apt-repository", "-y", "--no-update", source)); err != nil {
+			ui.PrintWarning("Failed to add %s: %v", source, err)
+			continue
+		}
+		added++
+	}
+
+	if added > 0 {
+		if err := i.runCommand(privileged("apt-get", "update")); err != nil {
+			ui.PrintWarning("apt-get update failed: %v", err)
+		}
+	}
+	return manual
+}
+
+// addDnfRepos enables COPR repositories that aren't enabled yet, returning
+// other missing repositories
+func (i *Installer) addDnfRepos(repos []string) []string {
+	enabled := make(map[string]bool)
+	current, _ := dnfEnabledRepos()
+	for _, repo := range current {
+		enabled[repo] = true
+	}
+
+	var manual []string
+	for _, repo := range repos {
+		if enabled[repo] {
+			continue
+		}
+		// copr:copr.fedorainfracloud.org:owner:project
+		parts := strings.Split(repo, ":")
+		if len(parts) != 4 || parts[0] != "copr" {
+			manual = append(manual, repo)
+			continue
+		}
+		project := parts[2] + "/" + parts[3]
+		if err := i.runCommand(privileged("dnf", "copr", "enable", "-y", project)); err != nil {
+			ui.PrintWarning("Failed to enable COPR %s: %v", project, err)
+		}
+	}
+	return manual
+}
+
+// missingPacmanRepos returns saved repositories absent from pacman.conf.
+// They're reported rather than added, since editing pacman.conf needs a
+// signing key to be trusted first.
+func missingPacmanRepos(repos []string) []string {
+	present := make(map[string]bool)
+	for _, repo := range pacmanRepos(pacmanConf) {
+		present[repo.Name] = true
+	}
+
+	var missing []string
+	for _, repo := range repos {
+		if !present[strings.Fields(repo)[0]] {
+			missing = append(missing, repo)
+		}
+	}
+	return missing
+}
+
+// addFlatpakRemotes adds saved remotes, returning those that couldn't be added
+func (i *Installer) addFlatpakRemotes(remotes []string) []string {
+	var manual []string
+	for _, remote := range remotes {
+		fields := strings.Fields(remote)
+		if len(fields) < 2 {
+			continue
+		}
+		name, url := fields[0], fields[1]
+		// Flathub's repo URL has no GPG key; its .flatpakrepo file does
+		if name == "flathub" {
+			url = "https://dl.flathub.org/repo/flathub.flatpakrepo"
+		}
+
+		args := []string{"remote-add", "--if-not-exists"}
+		if len(fields) > 2 && strings.Contains(fields[2], "user") {
+			args = append(args, "--user")
+		}
+		if err := i.runCommand(exec.Command("flatpak", append(args, name, url)...)); err != nil {
+			manual = append(manual, remote)
+		}
+	}
+	return manual
+}
+
+// flatpakInstallCommand installs an "application origin installation" entry
+func flatpakInstallCommand(entry string) *exec.Cmd {
+	fields := strings.Fields(entry)
+	args := []string{"install", "-y", "--noninteractive"}
+	if len(fields) > 2 && fields[2] == "user" {
+		args = append(args, "--user")
+	}
+	if len(fields) > 1 {
+		args = append(args, fields[1])
+	}
+	return exec.Command("flatpak", append(args, fields[0])...)
+}
+
+// snapInstallCommand installs a "name channel [classic]" entry
+func snapInstallCommand(entry string) *exec.Cmd {
+	fields := strings.Fields(entry)
+	args := []string{"install", fields[0]}
+	if len(fields) > 1 && fields[1] != "-" {
+		args = append(args, "--channel="+fields[1])
+	}
+	if len(fields) > 2 && fields[2] == "classic" {
+		args = append(args, "--classic")
+	}
+	return privileged("snap", args...)
+}
+
+// aptInstallCommand installs packages without debconf prompts. The variable
+// is set on the command line because sudo drops the caller's environment.
+func aptInstallCommand(pkgs ...string) *exec.Cmd {
+	args := append([]string{"DEBIAN_FRONTEND=noninteractive", "apt-get", "install", "-y"}, pkgs...)
+	return privileged("env", args...)
+}
+
+// privileged builds a command that runs as root, through sudo when needed
+func privileged(name string, args ...string) *exec.Cmd {
+	if os.Geteuid() != 0 && commandExists("sudo") {
+		return exec.Command("sudo", append([]string{name}, args...)...)
+	}
+	return exec.Command(name, args...)
+}
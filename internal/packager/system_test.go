@@ -0,0 +1,194 @@
+package packager
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeCommand puts a shell script named name in dir
+func fakeCommand(t *testing.T, dir, name, script string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCollectSystemPackages(t *testing.T) {
+	bin := t.TempDir()
+	t.Setenv("PATH", bin)
+
+	fakeCommand(t, bin, "apt-mark", `printf 'vim\ncurl\n'`)
+	fakeCommand(t, bin, "dnf", `case "$1" in
+repoquery) printf 'htop\n' ;;
+repolist) printf '%s\n' \
+	'repo id                              repo name' \
+	'copr:copr.fedorainfracloud.org:atim:starship  Copr repo for starship' \
+	'fedora                               Fedora 40 - x86_64' \
+	'updates                              Fedora 40 - x86_64 - Updates' \
+	'updates-testing                      Fedora 40 - x86_64 - Test Updates' ;;
+esac`)
+	fakeCommand(t, bin, "flatpak", `case "$1" in
+list) printf 'org.gimp.GIMP\tflathub\tsystem\n' ;;
+remotes) printf 'flathub\thttps://dl.flathub.org/repo/\tsystem\n' ;;
+esac`)
+	fakeCommand(t, bin, "snap", `printf '%s\n' \
+	'Name      Version  Rev   Tracking       Publisher   Notes' \
+	'core22    20240111 1122  latest/stable  canonical✓  base' \
+	'code      1.85     150   latest/stable  vscode✓     classic' \
+	'spotify   1.2      70    latest/stable  spotify✓    -' \
+	'snapd     2.61     20671 latest/stable  canonical✓  snapd'`)
+
+	sources := t.TempDir()
+	os.WriteFile(filepath.Join(sources, "neovim.list"),
+		[]byte("deb https://ppa.launchpadcontent.net/neovim-ppa/stable/ubuntu jammy main\n# deb-src ignored\n"), 0644)
+	os.WriteFile(filepath.Join(sources, "ubuntu.sources"),
+		[]byte("Types: deb\nURIs: http://archive.ubuntu.com/ubuntu\nSuites: noble\nComponents: main\n"), 0644)
+	os.WriteFile(filepath.Join(sources, "docker.sources"),
+		[]byte("Types: deb\nURIs: https://download.docker.com/linux/ubuntu\nSuites: noble\nComponents: stable\nSigned-By: /etc/apt/keyrings/docker.asc\n"), 0644)
+	original := aptSourcesDir
+	aptSourcesDir = sources
+	defer func() { aptSourcesDir = original }()
+
+	out := t.TempDir()
+	counts := NewPackager(out).CollectSystemPackages()
+
+	want := map[string]int{ManagerApt: 2, ManagerDnf: 1, ManagerFlatpak: 1, ManagerSnap: 2}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("CollectSystemPackages() = %v, want %v", counts, want)
+	}
+
+	plans := PlanSystemPackages(out)
+	if len(plans) != 4 {
+		t.Fatalf("Expected 4 plans, got %d", len(plans))
+	}
+
+	apt := plans[0]
+	if !reflect.DeepEqual(apt.Packages, []string{"curl", "vim"}) {
+		t.Errorf("Unexpected apt packages: %v", apt.Packages)
+	}
+	wantSources := []string{"ppa:neovim-ppa/stable", "deb https://download.docker.com/linux/ubuntu noble stable"}
+	if !reflect.DeepEqual(apt.Sources, wantSources) {
+		t.Errorf("Unexpected apt sources: %v", apt.Sources)
+	}
+
+	// Only repositories added on top of Fedora's own are kept
+	dnf := plans[1]
+	if !reflect.DeepEqual(dnf.Sources, []string{"copr:copr.fedorainfracloud.org:atim:starship"}) {
+		t.Errorf("Unexpected dnf repos: %v", dnf.Sources)
+	}
+
+	snap := plans[3]
+	if !reflect.DeepEqual(snap.Packages, []string{"code latest/stable classic", "spotify latest/stable"}) {
+		t.Errorf("Unexpected snaps: %v", snap.Packages)
+	}
+	if got := snap.Summary(); got != "snap: 2 package(s)" {
+		t.Errorf("Summary() = %q", got)
+	}
+}
+
+func TestPacmanRepos(t *testing.T) {
+	conf := filepath.Join(t.TempDir(), "pacman.conf")
+	os.WriteFile(conf, []byte(`[options]
+HoldPkg = pacman glibc
+
+[core]
+Include = /etc/pacman.d/mirrorlist
+
+[chaotic-aur]
+Server = https://cdn-mirror.chaotic.cx/$repo/$arch
+`), 0644)
+
+	repos := pacmanRepos(conf)
+	var names []string
+	for _, repo := range repos {
+		names = append(names, repo.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"options", "core", "chaotic-aur"}) {
+		t.Fatalf("Unexpected repos: %v", names)
+	}
+	if got := repos[2].String(); got != "chaotic-aur https://cdn-mirror.chaotic.cx/$repo/$arch" {
+		t.Errorf("Unexpected repo line: %s", got)
+	}
+
+	original := pacmanConf
+	pacmanConf = conf
+	defer func() { pacmanConf = original }()
+
+	missing := missingPacmanRepos([]string{"chaotic-aur https://example.com", "archlinuxcn https://repo.archlinuxcn.org/$arch"})
+	if !reflect.DeepEqual(missing, []string{"archlinuxcn https://repo.archlinuxcn.org/$arch"}) {
+		t.Errorf("Unexpected missing repos: %v", missing)
+	}
+}
+
+func TestInstallSystemPackages(t *testing.T) {
+	bin := t.TempDir()
+	t.Setenv("PATH", bin)
+	log := filepath.Join(t.TempDir(), "commands.log")
+
+	t.Setenv("DEBIAN_FRONTEND", "")
+	fakeCommand(t, bin, "sudo", `exec "$@"`)
+	fakeCommand(t, bin, "env", `while [ "${1#*=}" != "$1" ]; do export "$1"; shift; done; exec "$@"`)
+	fakeCommand(t, bin, "add-apt-repository", `echo "add-apt-repository $*" >> `+log)
+	// Installing fails whenever a package this release doesn't have is asked for
+	fakeCommand(t, bin, "apt-get", `echo "${DEBIAN_FRONTEND:+$DEBIAN_FRONTEND }apt-get $*" >> `+log+`
+case "$*" in *missing*) echo "E: Unable to locate package missing"; exit 100 ;; esac`)
+
+	plan := SystemPlan{
+		Manager:  ManagerApt,
+		Packages: []string{"curl", "missing", "vim"},
+		Sources:  []string{"ppa:neovim-ppa/stable", "deb https://download.docker.com/linux/ubuntu noble stable"},
+	}
+
+	installed, err := NewInstaller(false).InstallSystemPackages(plan)
+	if installed != 2 {
+		t.Errorf("Expected 2 packages installed, got %d", installed)
+	}
+	if err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("Expected error naming the missing package, got %v", err)
+	}
+
+	data, _ := os.ReadFile(log)
+	want := []string{
+		"add-apt-repository -y --no-update ppa:neovim-ppa/stable",
+		"apt-get update",
+		"noninteractive apt-get install -y curl missing vim",
+		"noninteractive apt-get install -y curl",
+		"noninteractive apt-get install -y missing",
+		"noninteractive apt-get install -y vim",
+	}
+	if got := nonEmptyLines(data); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected commands:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestInstallSystemPackages_MissingManager(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	if _, err := NewInstaller(false).InstallSystemPackages(SystemPlan{Manager: ManagerDnf, Packages: []string{"vim"}}); err == nil {
+		t.Error("Expected error when dnf isn't installed")
+	}
+}
+
+func TestSystemInstallCommands(t *testing.T) {
+	// No sudo on PATH, so commands run directly
+	t.Setenv("PATH", t.TempDir())
+
+	tests := []struct {
+		got  []string
+		want []string
+	}{
+		{snapInstallCommand("code latest/stable classic").Args, []string{"snap", "install", "code", "--channel=latest/stable", "--classic"}},
+		{snapInstallCommand("spotify -").Args, []string{"snap", "install", "spotify"}},
+		{aptInstallCommand("curl", "vim").Args, []string{"env", "DEBIAN_FRONTEND=noninteractive", "apt-get", "install", "-y", "curl", "vim"}},
+		{flatpakInstallCommand("org.gimp.GIMP flathub user").Args, []string{"flatpak", "install", "-y", "--noninteractive", "--user", "flathub", "org.gimp.GIMP"}},
+	}
+
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("Got %v, want %v", tt.got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package packager
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ToolchainsFile is the name of the toolchains list in the packages directory
+const ToolchainsFile = "toolchains.json"
+
+// Version managers, in the order they're restored. rustup comes first so
+// cargo-based tools can build; mise and asdf come last since their plugins
+// may need a runtime from one of the others.
+const (
+	ManagerRustup = "rustup"
+	ManagerNvm    = "nvm"
+	ManagerPyenv  = "pyenv"
+	ManagerRbenv  = "rbenv"
+	ManagerAsdf   = "asdf"
+	ManagerMise   = "mise"
+)
+
+var toolchainManagers = []string{ManagerRustup, ManagerNvm, ManagerPyenv, ManagerRbenv, ManagerAsdf, ManagerMise}
+
+// Toolchain is a runtime installed through a version manager
+type Toolchain struct {
+	Manager  string   `json:"manager"`
+	Tool     string   `json:"tool"`
+	Versions []string `json:"versions"`
+	// Global is the version selected outside any project. pyenv allows
+	// several, separated by spaces.
+	Global string `json:"global,omitempty"`
+	// PluginURL is the asdf plugin repository
+	PluginURL string `json:"plugin_url,omitempty"`
+	// Components and Targets are added to rustup's default toolchain
+	Components []string `json:"components,omitempty"`
+	Targets    []string `json:"targets,omitempty"`
+}
+
+// rustupToolchainPattern splits a toolchain name into its channel (with an
+// optional date) and host triple
+var rustupToolchainPattern = regexp.MustCompile(`^((?:stable|beta|nightly|\d+\.\d+(?:\.\d+)?)(?:-\d{4}-\d{2}-\d{2})?)(?:-(.+))?$`)
+
+// CollectToolchains records runtimes from every version manager found
+func (p *Packager) CollectToolchains() error {
+	var toolchains []Toolchain
+	toolchains = append(toolchains, collectRustup()...)
+	toolchains = append(toolchains, collectNvm()...)
+	toolchains = append(toolchains, collectVersionsBare(ManagerPyenv, "python")...)
+	toolchains = append(toolchains, collectVersionsBare(ManagerRbenv, "ruby")...)
+	toolchains = append(toolchains, collectAsdf()...)
+	toolchains = append(toolchains, collectMise()...)
+
+	if len(toolchains) == 0 {
+		return fmt.Errorf("no version managers found")
+	}
+
+	data, err := json.MarshalIndent(toolchains, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal toolchains: %w", err)
+	}
+	return os.WriteFile(filepath.Join(p.outputDir, ToolchainsFile), data, 0644)
+}
+
+// LoadToolchains reads a toolchains list
+func LoadToolchains(path string) ([]Toolchain, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var toolchains []Toolchain
+	if err := json.Unmarshal(data, &toolchains); err != nil {
+		return nil, fmt.Errorf("failed to parse toolchains: %w", err)
+	}
+	return toolchains, nil
+}
+
+// CountToolchainVersions returns the number of runtime versions in the list
+func CountToolchainVersions(toolchains []Toolchain) int {
+	count := 0
+	for _, tc := range toolchains {
+		count += len(tc.Versions)
+	}
+	return count
+}
+
+// SortToolchains puts toolchains in install order: by manager, then core
+// runtimes before backend tools such as mise's "npm:prettier" that need them
+func SortToolchains(toolchains []Toolchain) {
+	rank := make(map[string]int)
+	for i, manager := range toolchainManagers {
+		rank[manager] = i
+	}
+	sort.SliceStable(toolchains, func(i, j int) bool {
+		a, b := toolchains[i], toolchains[j]
+		if rank[a.Manager] != rank[b.Manager] {
+			return rank[a.Manager] < rank[b.Manager]
+		}
+		return !strings.Contains(a.Tool, ":") && strings.Contains(b.Tool, ":")
+	})
+}
+
+func collectRustup() []Toolchain {
+	if !commandExists("rustup") {
+		return nil
+	}
+	output, err := exec.Command("rustup", "toolchain", "list").Output()
+	if err != nil {
+		return nil
+	}
+
+	tc := Toolchain{Manager: ManagerRustup, Tool: "rust"}
+	host := ""
+	for _, line := range nonEmptyLines(output) {
+		name, _, _ := strings.Cut(line, " ")
+		m := rustupToolchainPattern.FindStringSubmatch(name)
+		if m == nil {
+			// Linked custom toolchains can't be reinstalled
+			continue
+		}
+		tc.Versions = append(tc.Versions, m[1])
+		// "(default)" or "(active, default)"
+		if strings.Contains(line, "default") {
+			tc.Global, host = m[1], m[2]
+		}
+	}
+	if len(tc.Versions) == 0 {
+		return nil
+	}
+
+	// Components and targets carry the host triple, which differs between machines
+	components, _ := exec.Command("rustup", "component", "list", "--installed").Output()
+	for _, component := range nonEmptyLines(components) {
+		if host != "" {
+			component = strings.TrimSuffix(component, "-"+host)
+		}
+		if component != "rust-std" {
+			tc.Components = append(tc.Components, component)
+		}
+	}
+	targets, _ := exec.Command("rustup", "target", "list", "--installed").Output()
+	for _, target := range nonEmptyLines(targets) {
+		if target != host {
+			tc.Targets = append(tc.Targets, target)
+		}
+	}
+
+	return []Toolchain{tc}
+}
+
+// nvmDir returns $NVM_DIR, defaulting to ~/.nvm
+func nvmDir() string {
+	if dir := os.Getenv("NVM_DIR"); dir != "" {
+		return dir
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".nvm")
+}
+
+// collectNvm reads installed Node versions from $NVM_DIR, since nvm is a
+// shell function rather than a command
+func collectNvm() []Toolchain {
+	entries, err := os.ReadDir(filepath.Join(nvmDir(), "versions", "node"))
+	if err != nil {
+		return nil
+	}
+
+	tc := Toolchain{Manager: ManagerNvm, Tool: "node"}
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), "v") {
+			tc.Versions = append(tc.Versions, entry.Name())
+		}
+	}
+	if len(tc.Versions) == 0 {
+		return nil
+	}
+	if alias, err := os.ReadFile(filepath.Join(nvmDir(), "alias", "default")); err == nil {
+		tc.Global = strings.TrimSpace(string(alias))
+	}
+	return []Toolchain{tc}
+}
+
+// collectVersionsBare reads pyenv and rbenv, which share a command interface
+func collectVersionsBare(manager, tool string) []Toolchain {
+	if !commandExists(manager) {
+		return nil
+	}
+	output, err := exec.Command(manager, "versions", "--bare", "--skip-aliases").Output()
+	if err != nil {
+		// Older releases don't know --skip-aliases
+		if output, err = exec.Command(manager, "versions", "--bare").Output(); err != nil {
+			return nil
+		}
+	}
+
+	tc := Toolchain{Manager: manager, Tool: tool, Versions: nonEmptyLines(output)}
+	if len(tc.Versions) == 0 {
+		return nil
+	}
+	if global, err := exec.Command(manager, "global").Output(); err == nil {
+		tc.Global = strings.Join(nonEmptyLines(global), " ")
+	}
+	return []Toolchain{tc}
+}
+
+func collectAsdf() []Toolchain {
+	if !commandExists("asdf") {
+		return nil
+	}
+	plugins, err := exec.Command("asdf", "plugin", "list", "--urls").Output()
+	if err != nil {
+		return nil
+	}
+
+	globals := readToolVersions()
+	var toolchains []Toolchain
+	for _, line := range nonEmptyLines(plugins) {
+		fields := strings.Fields(line)
+		tc := Toolchain{Manager: ManagerAsdf, Tool: fields[0], Global: globals[fields[0]]}
+		if len(fields) > 1 {
+			tc.PluginURL = fields[1]
+		}
+
+		versions, _ := exec.Command("asdf", "list", tc.Tool).Output()
+		for _, version := range nonEmptyLines(versions) {
+			// The current version is marked with a leading *
+			version = strings.TrimSpace(strings.TrimPrefix(version, "*"))
+			if version != "" && !strings.HasPrefix(version, "No versions") {
+				tc.Versions = append(tc.Versions, version)
+			}
+		}
+		if len(tc.Versions) > 0 {
+			toolchains = append(toolchains, tc)
+		}
+	}
+	return toolchains
+}
+
+// readToolVersions returns the first version of each tool in ~/.tool-versions
+func readToolVersions() map[string]string {
+	versions := make(map[string]string)
+	homeDir, _ := os.UserHomeDir()
+	data, err := os.ReadFile(filepath.Join(homeDir, ".tool-versions"))
+	if err != nil {
+		return versions
+	}
+	for _, line := range nonEmptyLines(data) {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && !strings.HasPrefix(fields[0], "#") {
+			versions[fields[0]] = fields[1]
+		}
+	}
+	return versions
+}
+
+// miseVersion is one entry of `mise ls --json`
+type miseVersion struct {
+	Version          string `json:"version"`
+	RequestedVersion string `json:"requested_version"`
+}
+
+func collectMise() []Toolchain {
+	if !commandExists("mise") {
+		return nil
+	}
+	installed, err := readMiseList("--installed")
+	if err != nil {
+		return nil
+	}
+	global, _ := readMiseList("--global")
+
+	var toolchains []Toolchain
+	for _, tool := range sortedMapKeys(installed) {
+		tc := Toolchain{Manager: ManagerMise, Tool: tool}
+		for _, v := range installed[tool] {
+			tc.Versions = append(tc.Versions, v.Version)
+		}
+		// Keep what the config asks for ("20"), not what it resolved to
+		if g := global[tool]; len(g) > 0 {
+			tc.Global = g[0].RequestedVersion
+			if tc.Global == "" {
+				tc.Global = g[0].Version
+			}
+		}
+		if len(tc.Versions) > 0 {
+			toolchains = append(toolchains, tc)
+		}
+	}
+	return toolchains
+}
+
+func readMiseList(flag string) (map[string][]miseVersion, error) {
+	output, err := exec.Command("mise", "ls", flag, "--json").Output()
+	if err != nil {
+		return nil, err
+	}
+	tools := make(map[string][]miseVersion)
+	if err := json.Unmarshal(output, &tools); err != nil {
+		return nil, err
+	}
+	return tools, nil
+}
+
+func sortedMapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
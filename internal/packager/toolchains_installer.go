@@ -0,0 +1,192 @@
+package packager
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/harshpatel5940/stash/internal/ui"
+)
+
+// toolchainStep is one command in restoring a toolchain
+type toolchainStep struct {
+	Label string
+	Args  []string
+	// Fallback runs when Args fails, for commands that changed between releases
+	Fallback []string
+	// Optional steps don't count as failures, e.g. adding a plugin that exists
+	Optional bool
+	// Version is set for steps that install a runtime version
+	Version bool
+}
+
+// InstallToolchains reinstalls runtime versions and restores the global
+// version for each, in dependency order. It returns how many versions were
+// installed.
+func (i *Installer) InstallToolchains(toolchainsPath string) (int, error) {
+	toolchains, err := LoadToolchains(toolchainsPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read toolchains file: %w", err)
+	}
+	SortToolchains(toolchains)
+
+	var steps []toolchainStep
+	missing := make(map[string]bool)
+	for _, tc := range toolchains {
+		if !toolchainManagerAvailable(tc.Manager) {
+			missing[tc.Manager] = true
+			continue
+		}
+		steps = append(steps, toolchainSteps(tc)...)
+	}
+
+	if len(steps) > 0 {
+		fmt.Printf("  Installing %d runtime version(s)...\n", CountToolchainVersions(toolchains))
+	}
+
+	bar := ui.NewProgressBar(len(steps), "Runtimes")
+	installed := 0
+	var failed []string
+	for _, step := range steps {
+		err := i.runCommand(toolchainCommand(step.Args))
+		if err != nil && step.Fallback != nil {
+			err = i.runCommand(toolchainCommand(step.Fallback))
+		}
+		switch {
+		case err == nil && step.Version:
+			installed++
+		case err != nil && !step.Optional:
+			failed = append(failed, step.Label)
+			if i.verbose {
+				fmt.Printf("    Failed: %s: %v\n", step.Label, err)
+			}
+		}
+		bar.Add(1)
+	}
+	bar.Finish()
+
+	var problems []string
+	if len(missing) > 0 {
+		problems = append(problems, fmt.Sprintf("install %s first", strings.Join(sortedMapKeys(missing), ", ")))
+	}
+	if len(failed) > 0 {
+		problems = append(problems, fmt.Sprintf("failed: %s", strings.Join(failed, ", ")))
+	}
+	if len(problems) > 0 {
+		return installed, fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return installed, nil
+}
+
+// toolchainSteps returns the commands that reinstall tc: plugins, then each
+// version, then the global version and rustup components
+func toolchainSteps(tc Toolchain) []toolchainStep {
+	var steps []toolchainStep
+	m := tc.Manager
+
+	if m == ManagerAsdf {
+		args := []string{"asdf", "plugin", "add", tc.Tool}
+		if tc.PluginURL != "" {
+			args = append(args, tc.PluginURL)
+		}
+		steps = append(steps, toolchainStep{Label: "asdf plugin " + tc.Tool, Args: args, Optional: true})
+	}
+
+	for _, version := range tc.Versions {
+		label := tc.Tool + "@" + version
+		switch m {
+		case ManagerRustup:
+			steps = append(steps, toolchainStep{Label: label, Version: true, Args: []string{"rustup", "toolchain", "install", version}})
+		case ManagerNvm:
+			steps = append(steps, toolchainStep{Label: label, Version: true, Args: []string{"nvm", "install", version}})
+		case ManagerPyenv, ManagerRbenv:
+			steps = append(steps, toolchainStep{Label: label, Version: true, Args: []string{m, "install", "-s", version}})
+		case ManagerAsdf:
+			steps = append(steps, toolchainStep{Label: label, Version: true, Args: []string{"asdf", "install", tc.Tool, version}})
+		case ManagerMise:
+			steps = append(steps, toolchainStep{Label: label, Version: true, Args: []string{"mise", "install", label}})
+		}
+	}
+
+	if tc.Global != "" {
+		label := "global " + tc.Tool + "@" + tc.Global
+		switch m {
+		case ManagerRustup:
+			steps = append(steps, toolchainStep{Label: label, Args: []string{"rustup", "default", tc.Global}})
+		case ManagerNvm:
+			steps = append(steps, toolchainStep{Label: label, Args: []string{"nvm", "alias", "default", tc.Global}})
+		case ManagerPyenv, ManagerRbenv:
+			steps = append(steps, toolchainStep{Label: label, Args: append([]string{m, "global"}, strings.Fields(tc.Global)...)})
+		case ManagerAsdf:
+			// asdf 0.16 replaced `asdf global` with `asdf set --home`
+			steps = append(steps, toolchainStep{
+				Label:    label,
+				Args:     []string{"asdf", "set", "--home", tc.Tool, tc.Global},
+				Fallback: []string{"asdf", "global", tc.Tool, tc.Global},
+			})
+		case ManagerMise:
+			steps = append(steps, toolchainStep{Label: label, Args: []string{"mise", "use", "--global", tc.Tool + "@" + tc.Global}})
+		}
+	}
+
+	if m == ManagerRustup && tc.Global != "" {
+		if len(tc.Components) > 0 {
+			steps = append(steps, toolchainStep{
+				Label: "rust components",
+				Args:  append([]string{"rustup", "component", "add", "--toolchain", tc.Global}, tc.Components...),
+			})
+		}
+		if len(tc.Targets) > 0 {
+			steps = append(steps, toolchainStep{
+				Label: "rust targets",
+				Args:  append([]string{"rustup", "target", "add", "--toolchain", tc.Global}, tc.Targets...),
+			})
+		}
+	}
+
+	return steps
+}
+
+// toolchainCommand builds the command for args, running nvm through the
+// shell since it's a function sourced from nvm.sh
+func toolchainCommand(args []string) *exec.Cmd {
+	if args[0] != ManagerNvm {
+		return exec.Command(args[0], args[1:]...)
+	}
+	script := `. "$NVM_DIR/nvm.sh" && nvm "$@"`
+	cmd := exec.Command("bash", append([]string{"-c", script, "nvm"}, args[1:]...)...)
+	cmd.Env = append(os.Environ(), "NVM_DIR="+nvmDir())
+	return cmd
+}
+
+func toolchainManagerAvailable(manager string) bool {
+	if manager == ManagerNvm {
+		_, err := os.Stat(filepath.Join(nvmDir(), "nvm.sh"))
+		return err == nil && commandExists("bash")
+	}
+	return commandExists(manager)
+}
+
+// ToolchainPlan describes what InstallToolchains would do, one line per toolchain
+func ToolchainPlan(toolchains []Toolchain) []string {
+	sorted := append([]Toolchain(nil), toolchains...)
+	SortToolchains(sorted)
+
+	var lines []string
+	for _, tc := range sorted {
+		versions := append([]string(nil), tc.Versions...)
+		sort.Strings(versions)
+		line := fmt.Sprintf("%s %s: %s", tc.Manager, tc.Tool, strings.Join(versions, ", "))
+		if tc.Global != "" {
+			line += " (global " + tc.Global + ")"
+		}
+		if !toolchainManagerAvailable(tc.Manager) {
+			line += " [" + tc.Manager + " not installed]"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,155 @@
+package packager
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCollectToolchains(t *testing.T) {
+	bin := t.TempDir()
+	t.Setenv("PATH", bin)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	nvm := filepath.Join(home, ".nvm")
+	os.MkdirAll(filepath.Join(nvm, "versions", "node", "v20.11.0"), 0755)
+	os.MkdirAll(filepath.Join(nvm, "versions", "node", "v18.19.0"), 0755)
+	os.MkdirAll(filepath.Join(nvm, "alias"), 0755)
+	os.WriteFile(filepath.Join(nvm, "alias", "default"), []byte("20\n"), 0644)
+	t.Setenv("NVM_DIR", nvm)
+
+	fakeCommand(t, bin, "rustup", `case "$1 $2" in
+"toolchain list") printf '%s\n' 'stable-x86_64-unknown-linux-gnu (default)' 'nightly-2024-01-15-x86_64-unknown-linux-gnu' 'custom' ;;
+"component list") printf '%s\n' 'cargo-x86_64-unknown-linux-gnu' 'clippy-x86_64-unknown-linux-gnu' 'rust-std-x86_64-unknown-linux-gnu' ;;
+"target list") printf '%s\n' 'wasm32-unknown-unknown' 'x86_64-unknown-linux-gnu' ;;
+esac`)
+	// Old pyenv without --skip-aliases
+	fakeCommand(t, bin, "pyenv", `case "$*" in
+*skip-aliases*) exit 1 ;;
+"versions --bare") printf '3.11.7\n3.12.1\n' ;;
+global) printf '3.12.1\n3.11.7\n' ;;
+esac`)
+	fakeCommand(t, bin, "mise", `case "$*" in
+*installed*) echo '{"node":[{"version":"20.11.0","requested_version":"20"}],"npm:prettier":[{"version":"3.2.4"}]}' ;;
+*global*) echo '{"node":[{"version":"20.11.0","requested_version":"20"}]}' ;;
+esac`)
+
+	out := t.TempDir()
+	if err := NewPackager(out).CollectToolchains(); err != nil {
+		t.Fatalf("CollectToolchains() failed: %v", err)
+	}
+	toolchains, err := LoadToolchains(filepath.Join(out, ToolchainsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Toolchain{
+		{
+			Manager:    ManagerRustup,
+			Tool:       "rust",
+			Versions:   []string{"stable", "nightly-2024-01-15"},
+			Global:     "stable",
+			Components: []string{"cargo", "clippy"},
+			Targets:    []string{"wasm32-unknown-unknown"},
+		},
+		{Manager: ManagerNvm, Tool: "node", Versions: []string{"v18.19.0", "v20.11.0"}, Global: "20"},
+		{Manager: ManagerPyenv, Tool: "python", Versions: []string{"3.11.7", "3.12.1"}, Global: "3.12.1 3.11.7"},
+		{Manager: ManagerMise, Tool: "node", Versions: []string{"20.11.0"}, Global: "20"},
+		{Manager: ManagerMise, Tool: "npm:prettier", Versions: []string{"3.2.4"}},
+	}
+	if !reflect.DeepEqual(toolchains, want) {
+		t.Errorf("CollectToolchains() =\n%+v\nwant\n%+v", toolchains, want)
+	}
+}
+
+func TestCollectToolchains_NoneFound(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("NVM_DIR", "")
+
+	if err := NewPackager(t.TempDir()).CollectToolchains(); err == nil {
+		t.Error("Expected error when no version managers are installed")
+	}
+}
+
+func TestSortToolchains(t *testing.T) {
+	toolchains := []Toolchain{
+		{Manager: ManagerMise, Tool: "npm:prettier"},
+		{Manager: ManagerAsdf, Tool: "ruby"},
+		{Manager: ManagerMise, Tool: "node"},
+		{Manager: ManagerRustup, Tool: "rust"},
+		{Manager: ManagerPyenv, Tool: "python"},
+	}
+	SortToolchains(toolchains)
+
+	var got []string
+	for _, tc := range toolchains {
+		got = append(got, tc.Manager+" "+tc.Tool)
+	}
+	want := []string{"rustup rust", "pyenv python", "asdf ruby", "mise node", "mise npm:prettier"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SortToolchains() = %v, want %v", got, want)
+	}
+}
+
+func TestInstallToolchains(t *testing.T) {
+	bin := t.TempDir()
+	t.Setenv("PATH", bin)
+	t.Setenv("NVM_DIR", t.TempDir())
+	log := filepath.Join(t.TempDir(), "commands.log")
+
+	fakeCommand(t, bin, "rustup", `echo "rustup $*" >> `+log)
+	fakeCommand(t, bin, "pyenv", `echo "pyenv $*" >> `+log+`
+case "$*" in *3.8.0*) exit 1 ;; esac`)
+	fakeCommand(t, bin, "mise", `echo "mise $*" >> `+log)
+	// asdf 0.15 has no `set` command
+	fakeCommand(t, bin, "asdf", `echo "asdf $*" >> `+log+`
+case "$1" in set) exit 1 ;; esac`)
+
+	toolchains := []Toolchain{
+		{Manager: ManagerMise, Tool: "npm:prettier", Versions: []string{"3.2.4"}},
+		{Manager: ManagerMise, Tool: "node", Versions: []string{"20.11.0"}, Global: "20"},
+		{Manager: ManagerNvm, Tool: "node", Versions: []string{"v20.11.0"}},
+		{Manager: ManagerAsdf, Tool: "ruby", Versions: []string{"3.3.0"}, Global: "3.3.0", PluginURL: "https://github.com/asdf-vm/asdf-ruby.git"},
+		{Manager: ManagerPyenv, Tool: "python", Versions: []string{"3.12.1", "3.8.0"}, Global: "3.12.1 3.8.0"},
+		{Manager: ManagerRustup, Tool: "rust", Versions: []string{"stable"}, Global: "stable", Components: []string{"clippy"}, Targets: []string{"wasm32-unknown-unknown"}},
+	}
+	path := filepath.Join(t.TempDir(), ToolchainsFile)
+	data, _ := json.Marshal(toolchains)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	installed, err := NewInstaller(false).InstallToolchains(path)
+	if installed != 5 {
+		t.Errorf("Expected 5 versions installed, got %d", installed)
+	}
+	if err == nil || !strings.Contains(err.Error(), "nvm") || !strings.Contains(err.Error(), "python@3.8.0") {
+		t.Errorf("Expected error naming nvm and the failed version, got %v", err)
+	}
+
+	data, _ = os.ReadFile(log)
+	want := []string{
+		"rustup toolchain install stable",
+		"rustup default stable",
+		"rustup component add --toolchain stable clippy",
+		"rustup target add --toolchain stable wasm32-unknown-unknown",
+		"pyenv install -s 3.12.1",
+		"pyenv install -s 3.8.0",
+		"pyenv global 3.12.1 3.8.0",
+		"asdf plugin add ruby https://github.com/asdf-vm/asdf-ruby.git",
+		"asdf install ruby 3.3.0",
+		"asdf set --home ruby 3.3.0",
+		"asdf global ruby 3.3.0",
+		"mise install node@20.11.0",
+		"mise use --global node@20",
+		"mise install npm:prettier@3.2.4",
+	}
+	if got := nonEmptyLines(data); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected commands:\n%s", strings.Join(got, "\n"))
+	}
+}
@@ -0,0 +1,99 @@
+// Package platform describes where per-user data lives on the operating
+// systems stash supports. macOS keeps fonts and app data under ~/Library;
+// Linux follows the XDG base directory spec.
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const (
+	MacOS = "darwin"
+	Linux = "linux"
+)
+
+// Platform resolves per-user paths for one operating system
+type Platform struct {
+	OS      string
+	HomeDir string
+	getenv  func(string) string
+}
+
+// Current returns the platform stash is running on
+func Current() Platform {
+	homeDir, _ := os.UserHomeDir()
+	return New(runtime.GOOS, homeDir)
+}
+
+// New returns the platform for goos with the given home directory.
+// XDG variables are read from the environment.
+func New(goos, homeDir string) Platform {
+	return Platform{OS: goos, HomeDir: homeDir, getenv: os.Getenv}
+}
+
+// IsMacOS reports whether the platform is macOS
+func (p Platform) IsMacOS() bool {
+	return p.OS == MacOS
+}
+
+// IsLinux reports whether the platform is Linux
+func (p Platform) IsLinux() bool {
+	return p.OS == Linux
+}
+
+// Name returns a human-readable platform name
+func (p Platform) Name() string {
+	return Name(p.OS)
+}
+
+// Name returns a human-readable name for a GOOS value
+func Name(goos string) string {
+	switch goos {
+	case MacOS:
+		return "macOS"
+	case Linux:
+		return "Linux"
+	case "":
+		return "unknown"
+	}
+	return goos
+}
+
+// ConfigHome returns $XDG_CONFIG_HOME, defaulting to ~/.config
+func (p Platform) ConfigHome() string {
+	return p.xdgDir("XDG_CONFIG_HOME", ".config")
+}
+
+// DataHome returns $XDG_DATA_HOME, defaulting to ~/.local/share
+func (p Platform) DataHome() string {
+	return p.xdgDir("XDG_DATA_HOME", filepath.Join(".local", "share"))
+}
+
+// FontsDir returns the per-user font directory
+func (p Platform) FontsDir() string {
+	if p.IsMacOS() {
+		return filepath.Join(p.HomeDir, "Library", "Fonts")
+	}
+	return filepath.Join(p.DataHome(), "fonts")
+}
+
+// AppSupportDir returns where desktop apps keep per-user data: ~/Library/Application
+// Support on macOS and the XDG config directory elsewhere
+func (p Platform) AppSupportDir() string {
+	if p.IsMacOS() {
+		return filepath.Join(p.HomeDir, "Library", "Application Support")
+	}
+	return p.ConfigHome()
+}
+
+func (p Platform) xdgDir(env, fallback string) string {
+	if p.getenv != nil {
+		// The spec says relative values are invalid and must be ignored
+		if dir := p.getenv(env); dir != "" && filepath.IsAbs(dir) {
+			return dir
+		}
+	}
+	return filepath.Join(p.HomeDir, fallback)
+}
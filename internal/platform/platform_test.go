@@ -0,0 +1,43 @@
+package platform
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPaths(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_DATA_HOME", "")
+
+	tests := []struct {
+		goos       string
+		fonts      string
+		appSupport string
+	}{
+		{MacOS, "/home/u/Library/Fonts", "/home/u/Library/Application Support"},
+		{Linux, "/home/u/.local/share/fonts", "/home/u/.config"},
+	}
+
+	for _, tt := range tests {
+		p := New(tt.goos, "/home/u")
+		if got := p.FontsDir(); got != filepath.FromSlash(tt.fonts) {
+			t.Errorf("%s FontsDir() = %s, want %s", tt.goos, got, tt.fonts)
+		}
+		if got := p.AppSupportDir(); got != filepath.FromSlash(tt.appSupport) {
+			t.Errorf("%s AppSupportDir() = %s, want %s", tt.goos, got, tt.appSupport)
+		}
+	}
+}
+
+func TestXDGOverrides(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", "/data")
+	t.Setenv("XDG_CONFIG_HOME", "relative/ignored")
+
+	p := New(Linux, "/home/u")
+	if got := p.FontsDir(); got != filepath.FromSlash("/data/fonts") {
+		t.Errorf("Expected XDG_DATA_HOME to be used, got %s", got)
+	}
+	if got := p.ConfigHome(); got != filepath.FromSlash("/home/u/.config") {
+		t.Errorf("Expected relative XDG_CONFIG_HOME to be ignored, got %s", got)
+	}
+}
@@ -0,0 +1,449 @@
+// Package rollback provides pre-restore safety snapshots.
+// Before a restore overwrites files, every path it will touch is captured
+// into an encrypted rollback archive together with a restore journal, so the
+// machine can later be put back exactly as it was with `stash undo`.
+//
+// Journals and snapshot archives are stored in a .rollback directory inside
+// the backup directory.
+package rollback
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/harshpatel5940/stash/internal/archiver"
+	"github.com/harshpatel5940/stash/internal/crypto"
+	"github.com/harshpatel5940/stash/internal/security"
+)
+
+// ErrNoKey is returned when there is no age key to encrypt a snapshot with
+var ErrNoKey = errors.New("encryption key not found")
+
+// Entry records the state of a single path before a restore touched it
+type Entry struct {
+	Path         string      `json:"path"`
+	Existed      bool        `json:"existed"`
+	IsDir        bool        `json:"is_dir"`
+	Mode         os.FileMode `json:"mode,omitempty"`
+	SnapshotPath string      `json:"snapshot_path,omitempty"` // location inside the snapshot archive
+}
+
+// Journal describes one restore run and how to revert it
+type Journal struct {
+	ID         string    `json:"id"`
+	Timestamp  time.Time `json:"timestamp"`
+	BackupName string    `json:"backup_name"`
+	Snapshot   string    `json:"snapshot"`
+	Encrypted  bool      `json:"encrypted"`
+	Entries    []Entry   `json:"entries"`
+	Undone     bool      `json:"undone,omitempty"`
+	UndoneAt   time.Time `json:"undone_at,omitempty"`
+}
+
+// Manager handles rollback journals and snapshot archives
+type Manager struct {
+	rollbackDir string
+}
+
+// Snapshot collects the pre-restore state of files before they are overwritten
+type Snapshot struct {
+	manager    *Manager
+	journal    *Journal
+	stagingDir string
+	seen       map[string]bool
+	fileCount  int
+}
+
+// UndoResult summarizes what an undo changed
+type UndoResult struct {
+	Restored int
+	Removed  int
+	Failed   []string
+}
+
+// NewManager creates a new rollback manager
+func NewManager(backupDir string) *Manager {
+	return &Manager{
+		rollbackDir: filepath.Join(backupDir, ".rollback"),
+	}
+}
+
+// Check reports whether a snapshot encrypted with the key at keyPath can be
+// written, so a restore can find out before it changes anything
+func (m *Manager) Check(keyPath string) error {
+	if keyPath == "" || !crypto.NewEncryptor(keyPath).KeyExists() {
+		return fmt.Errorf("%w: %s", ErrNoKey, keyPath)
+	}
+	if err := os.MkdirAll(m.rollbackDir, 0700); err != nil {
+		return fmt.Errorf("failed to create rollback directory: %w", err)
+	}
+	return nil
+}
+
+// Begin starts a new snapshot for a restore of the given backup
+func (m *Manager) Begin(backupName string) (*Snapshot, error) {
+	if err := os.MkdirAll(m.rollbackDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create rollback directory: %w", err)
+	}
+
+	id := m.newID(time.Now())
+	stagingDir, err := os.MkdirTemp("", "stash-rollback-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	return &Snapshot{
+		manager: m,
+		journal: &Journal{
+			ID:         id,
+			Timestamp:  time.Now(),
+			BackupName: backupName,
+			Entries:    []Entry{},
+		},
+		stagingDir: stagingDir,
+		seen:       make(map[string]bool),
+	}, nil
+}
+
+// newID returns a unique restore ID based on the given time
+func (m *Manager) newID(now time.Time) string {
+	base := "restore-" + now.Format("2006-01-02-150405")
+	id := base
+	for i := 2; ; i++ {
+		if _, err := os.Stat(m.journalPath(id)); os.IsNotExist(err) {
+			return id
+		}
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// ID returns the restore ID of this snapshot
+func (s *Snapshot) ID() string {
+	return s.journal.ID
+}
+
+// CaptureFile records the current state of a file that is about to be written
+func (s *Snapshot) CaptureFile(path string) error {
+	path = filepath.Clean(path)
+	if s.seen[path] {
+		return nil
+	}
+
+	if err := s.captureMissingDirs(filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		s.seen[path] = true
+		s.journal.Entries = append(s.journal.Entries, Entry{Path: path})
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	if info.IsDir() {
+		// Existing directories are left in place by restore, nothing to record
+		s.seen[path] = true
+		return nil
+	}
+
+	s.fileCount++
+	snapshotPath := filepath.Join("files", fmt.Sprintf("%06d", s.fileCount))
+	arch := archiver.NewArchiver()
+	if err := arch.CopyFile(path, filepath.Join(s.stagingDir, snapshotPath)); err != nil {
+		return fmt.Errorf("failed to snapshot %s: %w", path, err)
+	}
+
+	s.seen[path] = true
+	s.journal.Entries = append(s.journal.Entries, Entry{
+		Path:         path,
+		Existed:      true,
+		Mode:         info.Mode(),
+		SnapshotPath: snapshotPath,
+	})
+	return nil
+}
+
+// CaptureTree records every path a directory copy from srcDir to destDir
+// would write. Like archiver.CopyDirExcept it leaves out excluded names,
+// symlinks and files for which skip, if set, returns true.
+func (s *Snapshot) CaptureTree(srcDir, destDir string, skip func(destPath string) bool) error {
+	return filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		if path != srcDir && archiver.Excluded(info.Name()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return nil
+		}
+
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(destDir, rel)
+
+		if info.IsDir() {
+			return s.captureMissingDirs(dest)
+		}
+		if skip != nil && skip(dest) {
+			return nil
+		}
+		return s.CaptureFile(dest)
+	})
+}
+
+// captureMissingDirs records dir and any missing parents that restore would create
+func (s *Snapshot) captureMissingDirs(dir string) error {
+	var missing []string
+	for current := filepath.Clean(dir); ; current = filepath.Dir(current) {
+		if s.seen[current] {
+			break
+		}
+		if _, err := os.Stat(current); err == nil {
+			s.seen[current] = true
+			break
+		}
+		missing = append(missing, current)
+		if filepath.Dir(current) == current {
+			break
+		}
+	}
+
+	// Record outermost first so undo can remove them innermost first
+	for i := len(missing) - 1; i >= 0; i-- {
+		s.seen[missing[i]] = true
+		s.journal.Entries = append(s.journal.Entries, Entry{Path: missing[i], IsDir: true})
+	}
+	return nil
+}
+
+// Commit writes the snapshot archive, encrypted with the age key at
+// keyPath, and the journal. Snapshots hold copies of secrets, so without
+// the key nothing is written and an error is returned.
+func (s *Snapshot) Commit(keyPath string) (*Journal, error) {
+	defer os.RemoveAll(s.stagingDir)
+
+	encryptor := crypto.NewEncryptor(keyPath)
+	if keyPath == "" || !encryptor.KeyExists() {
+		return nil, fmt.Errorf("%w: %s", ErrNoKey, keyPath)
+	}
+
+	m := s.manager
+	archivePath := filepath.Join(m.rollbackDir, s.journal.ID+".tar.gz")
+
+	arch := archiver.NewArchiver()
+	if err := arch.Create(s.stagingDir, archivePath); err != nil {
+		return nil, fmt.Errorf("failed to create rollback archive: %w", err)
+	}
+
+	encryptedPath := archivePath + ".age"
+	err := encryptor.Encrypt(archivePath, encryptedPath)
+	os.Remove(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt rollback archive: %w", err)
+	}
+	s.journal.Encrypted = true
+
+	s.journal.Snapshot = filepath.Base(encryptedPath)
+	if err := m.save(s.journal); err != nil {
+		return nil, err
+	}
+
+	return s.journal, nil
+}
+
+// Abort discards the snapshot without writing anything
+func (s *Snapshot) Abort() {
+	os.RemoveAll(s.stagingDir)
+}
+
+// Load reads a restore journal by ID
+func (m *Manager) Load(id string) (*Journal, error) {
+	data, err := os.ReadFile(m.journalPath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("restore not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to read restore journal: %w", err)
+	}
+
+	var journal Journal
+	if err := json.Unmarshal(data, &journal); err != nil {
+		return nil, fmt.Errorf("failed to parse restore journal: %w", err)
+	}
+
+	return &journal, nil
+}
+
+// List returns all restore journals, newest first
+func (m *Manager) List() ([]*Journal, error) {
+	files, err := os.ReadDir(m.rollbackDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read rollback directory: %w", err)
+	}
+
+	var journals []*Journal
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+
+		journal, err := m.Load(strings.TrimSuffix(file.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		journals = append(journals, journal)
+	}
+
+	sort.Slice(journals, func(i, j int) bool {
+		return journals[i].Timestamp.After(journals[j].Timestamp)
+	})
+
+	return journals, nil
+}
+
+// Latest returns the most recent restore that has not been undone
+func (m *Manager) Latest() (*Journal, error) {
+	journals, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, journal := range journals {
+		if !journal.Undone {
+			return journal, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no restore to undo")
+}
+
+// Undo reverts the restore described by the journal. Files that existed before
+// are put back from the snapshot, files and directories the restore created are removed.
+func (m *Manager) Undo(journal *Journal, keyPath string) (*UndoResult, error) {
+	if journal.Undone {
+		return nil, fmt.Errorf("restore %s was already undone on %s", journal.ID, journal.UndoneAt.Format("2006-01-02 15:04:05"))
+	}
+
+	tempDir, err := os.MkdirTemp("", "stash-undo-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	archivePath := security.CleanPath(filepath.Join(m.rollbackDir, journal.Snapshot))
+	if journal.Encrypted {
+		encryptor := crypto.NewEncryptor(keyPath)
+		if !encryptor.KeyExists() {
+			return nil, fmt.Errorf("decryption key not found: %s", keyPath)
+		}
+		decryptedPath := filepath.Join(tempDir, "snapshot.tar.gz")
+		if err := encryptor.Decrypt(archivePath, decryptedPath); err != nil {
+			return nil, fmt.Errorf("failed to decrypt rollback archive: %w", err)
+		}
+		archivePath = decryptedPath
+	}
+
+	extractDir := filepath.Join(tempDir, "snapshot")
+	arch := archiver.NewArchiver()
+	if err := arch.Extract(archivePath, extractDir); err != nil {
+		return nil, fmt.Errorf("failed to extract rollback archive: %w", err)
+	}
+
+	result := &UndoResult{}
+	var createdDirs []string
+
+	for _, entry := range journal.Entries {
+		switch {
+		case entry.IsDir:
+			createdDirs = append(createdDirs, entry.Path)
+		case entry.Existed:
+			src, err := security.SanitizePath(extractDir, entry.SnapshotPath)
+			if err != nil {
+				result.Failed = append(result.Failed, entry.Path)
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(entry.Path), 0755); err != nil {
+				result.Failed = append(result.Failed, entry.Path)
+				continue
+			}
+			if err := arch.CopyFile(src, entry.Path); err != nil {
+				result.Failed = append(result.Failed, entry.Path)
+				continue
+			}
+			_ = os.Chmod(entry.Path, entry.Mode)
+			result.Restored++
+		default:
+			if err := os.Remove(entry.Path); err != nil {
+				if !os.IsNotExist(err) {
+					result.Failed = append(result.Failed, entry.Path)
+				}
+				continue
+			}
+			result.Removed++
+		}
+	}
+
+	// Remove created directories innermost first; only empty ones go away
+	for i := len(createdDirs) - 1; i >= 0; i-- {
+		if err := os.Remove(createdDirs[i]); err == nil {
+			result.Removed++
+		}
+	}
+
+	journal.Undone = true
+	journal.UndoneAt = time.Now()
+	if err := m.save(journal); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// save writes the journal to disk
+func (m *Manager) save(journal *Journal) error {
+	data, err := json.MarshalIndent(journal, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal restore journal: %w", err)
+	}
+
+	if err := os.WriteFile(m.journalPath(journal.ID), data, 0600); err != nil {
+		return fmt.Errorf("failed to save restore journal: %w", err)
+	}
+
+	return nil
+}
+
+// journalPath returns the path to the journal file for a restore ID
+func (m *Manager) journalPath(id string) string {
+	return filepath.Join(m.rollbackDir, filepath.Base(id)+".json")
+}
+
+// FileCount returns how many files were captured with their previous contents
+func (j *Journal) FileCount() int {
+	count := 0
+	for _, entry := range j.Entries {
+		if entry.Existed {
+			count++
+		}
+	}
+	return count
+}
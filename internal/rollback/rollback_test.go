@@ -0,0 +1,249 @@
+package rollback
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/harshpatel5940/stash/internal/crypto"
+)
+
+func TestSnapshotAndUndo(t *testing.T) {
+	tempDir := t.TempDir()
+	homeDir := filepath.Join(tempDir, "home")
+	backupDir := filepath.Join(tempDir, "backups")
+
+	existing := filepath.Join(homeDir, ".zshrc")
+	if err := os.MkdirAll(homeDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(existing, []byte("original"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	created := filepath.Join(homeDir, ".config", "app", "settings.json")
+
+	keyPath := filepath.Join(tempDir, "test.key")
+	if err := crypto.NewEncryptor(keyPath).GenerateKey(); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewManager(backupDir)
+	snap, err := m.Begin("backup-test.tar.gz.age")
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	if err := snap.CaptureFile(existing); err != nil {
+		t.Fatalf("CaptureFile failed: %v", err)
+	}
+	if err := snap.CaptureFile(created); err != nil {
+		t.Fatalf("CaptureFile failed: %v", err)
+	}
+
+	journal, err := snap.Commit(keyPath)
+	if err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if !journal.Encrypted {
+		t.Error("Expected snapshot to be encrypted")
+	}
+	if journal.FileCount() != 1 {
+		t.Errorf("Expected 1 captured file, got %d", journal.FileCount())
+	}
+
+	// Simulate the restore
+	os.WriteFile(existing, []byte("restored"), 0644)
+	os.MkdirAll(filepath.Dir(created), 0755)
+	os.WriteFile(created, []byte("new"), 0644)
+
+	latest, err := m.Latest()
+	if err != nil {
+		t.Fatalf("Latest failed: %v", err)
+	}
+	if latest.ID != journal.ID {
+		t.Errorf("Expected latest %s, got %s", journal.ID, latest.ID)
+	}
+
+	result, err := m.Undo(latest, keyPath)
+	if err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if result.Restored != 1 {
+		t.Errorf("Expected 1 restored file, got %d", result.Restored)
+	}
+
+	content, _ := os.ReadFile(existing)
+	if string(content) != "original" {
+		t.Errorf("Expected original content, got %q", content)
+	}
+	info, _ := os.Stat(existing)
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	if _, err := os.Stat(created); !os.IsNotExist(err) {
+		t.Error("Expected created file to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(homeDir, ".config")); !os.IsNotExist(err) {
+		t.Error("Expected created directories to be removed")
+	}
+
+	reloaded, err := m.Load(journal.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.Undone {
+		t.Error("Expected journal to be marked undone")
+	}
+	if _, err := m.Undo(reloaded, keyPath); err == nil {
+		t.Error("Expected error when undoing twice")
+	}
+}
+
+func TestCaptureTree(t *testing.T) {
+	tempDir := t.TempDir()
+	src := filepath.Join(tempDir, "src")
+	dest := filepath.Join(tempDir, "dest")
+
+	os.MkdirAll(filepath.Join(src, "sub"), 0755)
+	os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(src, "sub", "b.txt"), []byte("b"), 0644)
+
+	os.MkdirAll(dest, 0755)
+	os.WriteFile(filepath.Join(dest, "a.txt"), []byte("old a"), 0644)
+
+	m := NewManager(filepath.Join(tempDir, "backups"))
+	snap, err := m.Begin("backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := snap.CaptureTree(src, dest, nil); err != nil {
+		t.Fatalf("CaptureTree failed: %v", err)
+	}
+	keyPath := filepath.Join(tempDir, "test.key")
+	if err := crypto.NewEncryptor(keyPath).GenerateKey(); err != nil {
+		t.Fatal(err)
+	}
+	journal, err := snap.Commit(keyPath)
+	if err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	var existed, createdFiles, createdDirs int
+	for _, e := range journal.Entries {
+		switch {
+		case e.IsDir:
+			createdDirs++
+		case e.Existed:
+			existed++
+		default:
+			createdFiles++
+		}
+	}
+
+	if existed != 1 || createdFiles != 1 || createdDirs != 1 {
+		t.Errorf("Unexpected entries: existed=%d created=%d dirs=%d", existed, createdFiles, createdDirs)
+	}
+}
+
+func TestCommitWithoutKey(t *testing.T) {
+	tempDir := t.TempDir()
+	target := filepath.Join(tempDir, "secret")
+	os.WriteFile(target, []byte("token"), 0600)
+
+	m := NewManager(filepath.Join(tempDir, "backups"))
+	snap, err := m.Begin("backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := snap.CaptureFile(target); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := snap.Commit(filepath.Join(tempDir, "missing.key")); err == nil {
+		t.Fatal("Expected Commit to fail without a key")
+	}
+
+	entries, _ := os.ReadDir(m.rollbackDir)
+	if len(entries) != 0 {
+		t.Errorf("Expected nothing written without a key, found %d entries", len(entries))
+	}
+}
+
+func TestLatestNoJournals(t *testing.T) {
+	m := NewManager(t.TempDir())
+	if _, err := m.Latest(); err == nil {
+		t.Error("Expected error with no journals")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tempDir := t.TempDir()
+	m := NewManager(filepath.Join(tempDir, "backups"))
+
+	if err := m.Check(filepath.Join(tempDir, "missing.key")); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Expected ErrNoKey, got %v", err)
+	}
+
+	keyPath := filepath.Join(tempDir, "test.key")
+	if err := crypto.NewEncryptor(keyPath).GenerateKey(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Check(keyPath); err != nil {
+		t.Errorf("Expected Check to pass with a key: %v", err)
+	}
+}
+
+func TestCaptureTreeSkipsUnwritten(t *testing.T) {
+	tempDir := t.TempDir()
+	src := filepath.Join(tempDir, "src")
+	dest := filepath.Join(tempDir, "dest")
+
+	os.MkdirAll(filepath.Join(src, "node_modules", "pkg"), 0755)
+	os.WriteFile(filepath.Join(src, "node_modules", "pkg", "index.js"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(src, "kept.txt"), []byte("backup"), 0644)
+	os.WriteFile(filepath.Join(src, "new.txt"), []byte("new"), 0644)
+
+	m := NewManager(filepath.Join(tempDir, "backups"))
+	snap, err := m.Begin("backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept := filepath.Join(dest, "kept.txt")
+	if err := snap.CaptureTree(src, dest, func(path string) bool { return path == kept }); err != nil {
+		t.Fatalf("CaptureTree failed: %v", err)
+	}
+	keyPath := filepath.Join(tempDir, "test.key")
+	if err := crypto.NewEncryptor(keyPath).GenerateKey(); err != nil {
+		t.Fatal(err)
+	}
+	journal, err := snap.Commit(keyPath)
+	if err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	for _, e := range journal.Entries {
+		if e.Path == kept || strings.Contains(e.Path, "node_modules") {
+			t.Errorf("Expected %s not to be recorded", e.Path)
+		}
+	}
+
+	// Files the copy never writes appear locally after the snapshot and
+	// must survive an undo
+	os.MkdirAll(filepath.Join(dest, "node_modules", "pkg"), 0755)
+	os.WriteFile(filepath.Join(dest, "node_modules", "pkg", "index.js"), []byte("local"), 0644)
+	os.WriteFile(kept, []byte("local"), 0644)
+	os.WriteFile(filepath.Join(dest, "new.txt"), []byte("new"), 0644)
+
+	if _, err := m.Undo(journal, keyPath); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	for _, path := range []string{kept, filepath.Join(dest, "node_modules", "pkg", "index.js")} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected %s to survive undo: %v", path, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dest, "new.txt")); !os.IsNotExist(err) {
+		t.Error("Expected new.txt, which the restore created, to be removed")
+	}
+}
@@ -0,0 +1,145 @@
+// Package secrets takes inventory of the keys and credentials stash backs
+// up: SSH private keys, PEM certificates and keys, AWS credential profiles
+// and kubeconfig users. Each is described without its secret material and
+// flagged when it is weak, expired or stored unencrypted.
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Kinds of findings
+const (
+	KindSSHKey      = "ssh-key"
+	KindCertificate = "certificate"
+	KindPrivateKey  = "private-key"
+	KindAWSProfile  = "aws-profile"
+	KindKubeUser    = "kube-user"
+)
+
+// ExpiryWarning is how close to expiry a certificate gets flagged
+const ExpiryWarning = 30 * 24 * time.Hour
+
+// Finding is one key, certificate or credential
+type Finding struct {
+	Kind      string     `json:"kind"`
+	Name      string     `json:"name"`
+	Path      string     `json:"path"`
+	Algorithm string     `json:"algorithm,omitempty"`
+	Bits      int        `json:"bits,omitempty"`
+	Encrypted *bool      `json:"encrypted,omitempty"`
+	Subject   string     `json:"subject,omitempty"`
+	Expires   *time.Time `json:"expires,omitempty"`
+	// Auth is how an AWS profile or kube user authenticates
+	Auth   string   `json:"auth,omitempty"`
+	Issues []string `json:"issues,omitempty"`
+}
+
+// Details describes the finding in a few words, for tables
+func (f Finding) Details() string {
+	var parts []string
+	if f.Algorithm != "" {
+		key := f.Algorithm
+		if f.Bits > 0 {
+			key = fmt.Sprintf("%s %d", f.Algorithm, f.Bits)
+		}
+		parts = append(parts, key)
+	}
+	if f.Encrypted != nil {
+		if *f.Encrypted {
+			parts = append(parts, "passphrase")
+		} else {
+			parts = append(parts, "no passphrase")
+		}
+	}
+	if f.Subject != "" {
+		parts = append(parts, f.Subject)
+	}
+	if f.Expires != nil {
+		parts = append(parts, "expires "+f.Expires.Format("2006-01-02"))
+	}
+	if f.Auth != "" {
+		parts = append(parts, f.Auth)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Report is the result of an audit
+type Report struct {
+	Findings []Finding `json:"findings"`
+}
+
+// Flagged returns the findings with issues
+func (r *Report) Flagged() []Finding {
+	var flagged []Finding
+	for _, f := range r.Findings {
+		if len(f.Issues) > 0 {
+			flagged = append(flagged, f)
+		}
+	}
+	return flagged
+}
+
+// Counts returns how many findings there are of each kind
+func (r *Report) Counts() map[string]int {
+	counts := make(map[string]int)
+	for _, f := range r.Findings {
+		counts[f.Kind]++
+	}
+	return counts
+}
+
+// Auditor finds and inspects secrets under a home directory
+type Auditor struct {
+	homeDir  string
+	pemFiles []string
+	now      time.Time
+}
+
+// NewAuditor returns an auditor for homeDir that also inspects pemFiles,
+// the .pem files found in the search paths
+func NewAuditor(homeDir string, pemFiles []string) *Auditor {
+	return &Auditor{homeDir: homeDir, pemFiles: pemFiles, now: time.Now()}
+}
+
+// Run inspects SSH keys, PEM files, AWS profiles and kubeconfig users.
+// Files that can't be read are left out.
+func (a *Auditor) Run() *Report {
+	report := &Report{}
+	seen := make(map[string]bool)
+
+	for _, path := range sshKeyFiles(filepath.Join(a.homeDir, ".ssh")) {
+		seen[path] = true
+		if f, ok := a.auditSSHKey(path); ok {
+			report.Findings = append(report.Findings, f)
+		}
+	}
+
+	for _, path := range a.pemFiles {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		report.Findings = append(report.Findings, a.auditPEMFile(path)...)
+	}
+
+	report.Findings = append(report.Findings, a.auditAWSProfiles()...)
+	report.Findings = append(report.Findings, a.auditKubeUsers()...)
+	return report
+}
+
+// envPath returns the path in an environment variable, else fallback
+func envPath(name, fallback string) string {
+	if path := os.Getenv(name); path != "" {
+		return path
+	}
+	return fallback
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,244 @@
+package secrets
+
+import (
+	"bufio"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// awsProfile is a profile from the AWS credentials and config files
+type awsProfile struct {
+	name   string
+	path   string
+	values map[string]string
+}
+
+// auditAWSProfiles describes how each AWS profile authenticates, flagging
+// long-lived access keys kept in plaintext
+func (a *Auditor) auditAWSProfiles() []Finding {
+	awsDir := filepath.Join(a.homeDir, ".aws")
+	var profiles []*awsProfile
+	byName := make(map[string]*awsProfile)
+	files := []string{
+		envPath("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(awsDir, "credentials")),
+		envPath("AWS_CONFIG_FILE", filepath.Join(awsDir, "config")),
+	}
+	for i, path := range files {
+		for _, p := range parseAWSFile(path, i == 1) {
+			existing, ok := byName[p.name]
+			if !ok {
+				byName[p.name] = p
+				profiles = append(profiles, p)
+				continue
+			}
+			for key, value := range p.values {
+				if _, set := existing.values[key]; !set {
+					existing.values[key] = value
+				}
+			}
+		}
+	}
+
+	var findings []Finding
+	for _, p := range profiles {
+		f := Finding{Kind: KindAWSProfile, Name: p.name, Path: p.path}
+		v := p.values
+		var methods []string
+		if keyID := v["aws_access_key_id"]; keyID != "" {
+			if v["aws_session_token"] != "" || strings.HasPrefix(keyID, "ASIA") {
+				methods = append(methods, "temporary key "+maskKeyID(keyID))
+			} else {
+				methods = append(methods, "access key "+maskKeyID(keyID))
+				f.Issues = append(f.Issues, "long-lived access key in plaintext")
+			}
+		}
+		if v["sso_start_url"] != "" || v["sso_session"] != "" {
+			methods = append(methods, "sso")
+		}
+		if v["role_arn"] != "" {
+			methods = append(methods, "assume role")
+		}
+		if v["credential_process"] != "" {
+			methods = append(methods, "credential process")
+		}
+		if v["web_identity_token_file"] != "" {
+			methods = append(methods, "web identity")
+		}
+		// Profiles holding only settings such as region aren't credentials
+		if len(methods) == 0 {
+			continue
+		}
+		f.Auth = strings.Join(methods, " + ")
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// parseAWSFile reads the profiles of an AWS credentials or config file.
+// The config file names profiles "[profile name]", except the default one.
+func parseAWSFile(path string, isConfig bool) []*awsProfile {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var profiles []*awsProfile
+	var current *awsProfile
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if isConfig {
+				var ok bool
+				if name, ok = strings.CutPrefix(name, "profile "); !ok && name != "default" {
+					// sso-session and services sections aren't profiles
+					current = nil
+					continue
+				}
+				name = strings.TrimSpace(name)
+			}
+			current = &awsProfile{name: name, path: path, values: make(map[string]string)}
+			profiles = append(profiles, current)
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok && current != nil {
+			current.values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return profiles
+}
+
+// maskKeyID shows only the ends of an access key ID
+func maskKeyID(keyID string) string {
+	if len(keyID) <= 8 {
+		return "****"
+	}
+	return keyID[:4] + "…" + keyID[len(keyID)-4:]
+}
+
+// kubeConfig is the part of a kubeconfig that describes users
+type kubeConfig struct {
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string `yaml:"token"`
+			TokenFile             string `yaml:"tokenFile"`
+			Username              string `yaml:"username"`
+			Password              string `yaml:"password"`
+			ClientCertificate     string `yaml:"client-certificate"`
+			ClientCertificateData string `yaml:"client-certificate-data"`
+			ClientKeyData         string `yaml:"client-key-data"`
+			Exec                  *struct {
+				Command string `yaml:"command"`
+			} `yaml:"exec"`
+			AuthProvider *struct {
+				Name string `yaml:"name"`
+			} `yaml:"auth-provider"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+}
+
+// auditKubeUsers describes how each kubeconfig user authenticates,
+// flagging plaintext tokens and passwords, embedded keys and client
+// certificates that have expired
+func (a *Auditor) auditKubeUsers() []Finding {
+	paths := filepath.SplitList(os.Getenv("KUBECONFIG"))
+	if len(paths) == 0 {
+		paths = []string{filepath.Join(a.homeDir, ".kube", "config")}
+	}
+
+	var findings []Finding
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var config kubeConfig
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			findings = append(findings, Finding{Kind: KindKubeUser, Name: filepath.Base(path), Path: path,
+				Issues: []string{"unreadable kubeconfig: " + err.Error()}})
+			continue
+		}
+
+		for _, entry := range config.Users {
+			user := entry.User
+			f := Finding{Kind: KindKubeUser, Name: entry.Name, Path: path}
+			var methods []string
+
+			if user.Exec != nil {
+				methods = append(methods, "exec ("+filepath.Base(user.Exec.Command)+")")
+			}
+			if user.AuthProvider != nil {
+				methods = append(methods, "auth provider ("+user.AuthProvider.Name+")")
+			}
+			if user.ClientCertificateData != "" || user.ClientCertificate != "" {
+				methods = append(methods, "client certificate")
+				if cert := a.kubeClientCert(user.ClientCertificateData, user.ClientCertificate, path); cert != nil {
+					audited := a.auditCertificate(cert)
+					f.Subject, f.Expires = audited.Subject, audited.Expires
+					f.Issues = append(f.Issues, audited.Issues...)
+				}
+			}
+			if user.ClientKeyData != "" {
+				f.Issues = append(f.Issues, "unencrypted client key embedded")
+			}
+			if user.Token != "" {
+				methods = append(methods, "token")
+				f.Issues = append(f.Issues, "static token in plaintext")
+			}
+			if user.TokenFile != "" {
+				methods = append(methods, "token file")
+			}
+			if user.Password != "" {
+				methods = append(methods, "basic auth")
+				f.Issues = append(f.Issues, "password in plaintext")
+			}
+			f.Auth = strings.Join(methods, " + ")
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// kubeClientCert returns a kube user's client certificate, embedded as
+// base64 or in a file relative to the kubeconfig
+func (a *Auditor) kubeClientCert(data, file, configPath string) *x509.Certificate {
+	var pemData []byte
+	if data != "" {
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil
+		}
+		pemData = decoded
+	} else {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(filepath.Dir(configPath), file)
+		}
+		read, err := os.ReadFile(file)
+		if err != nil {
+			return nil
+		}
+		pemData = read
+	}
+
+	block, _ := pem.Decode(pemData)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil
+	}
+	return cert
+}
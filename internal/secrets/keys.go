@@ -0,0 +1,269 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// maxKeySize skips files too large to be a key
+const maxKeySize = 64 * 1024
+
+// minRSABits is the smallest RSA key not flagged as weak
+const minRSABits = 2048
+
+// sshSkipNames are files in ~/.ssh that aren't private keys
+var sshSkipNames = map[string]bool{
+	"config":          true,
+	"known_hosts":     true,
+	"known_hosts.old": true,
+	"authorized_keys": true,
+	"environment":     true,
+}
+
+// sshKeyFiles returns the files in dir that look like private keys
+func sshKeyFiles(dir string) []string {
+	var paths []string
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+		name := d.Name()
+		if sshSkipNames[name] || strings.HasSuffix(name, ".pub") {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > maxKeySize {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err == nil && bytes.Contains(data, []byte("PRIVATE KEY-----")) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths
+}
+
+// auditSSHKey inspects an SSH private key. Encrypted keys in the OpenSSH
+// format carry their public key; for others the .pub file next to the key
+// gives the type and size.
+func (a *Auditor) auditSSHKey(path string) (Finding, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Finding{}, false
+	}
+	f := Finding{Kind: KindSSHKey, Name: filepath.Base(path), Path: path}
+
+	var pub ssh.PublicKey
+	key, err := ssh.ParseRawPrivateKey(data)
+	var missing *ssh.PassphraseMissingError
+	switch {
+	case err == nil:
+		f.Encrypted = boolPtr(false)
+		if signer, err := ssh.NewSignerFromKey(key); err == nil {
+			pub = signer.PublicKey()
+		}
+	case errors.As(err, &missing):
+		f.Encrypted = boolPtr(true)
+		pub = missing.PublicKey
+	case isEncryptedPEM(data):
+		f.Encrypted = boolPtr(true)
+	default:
+		f.Issues = append(f.Issues, fmt.Sprintf("unreadable key: %v", err))
+		return f, true
+	}
+
+	if pub == nil {
+		if pubData, err := os.ReadFile(path + ".pub"); err == nil {
+			pub, _, _, _, _ = ssh.ParseAuthorizedKey(pubData)
+		}
+	}
+	if pub != nil {
+		f.Algorithm, f.Bits = sshKeyInfo(pub)
+	}
+
+	// Security keys keep the secret on the hardware token
+	hardware := strings.HasSuffix(f.Algorithm, "-sk")
+	if f.Encrypted != nil && !*f.Encrypted && !hardware {
+		f.Issues = append(f.Issues, "no passphrase")
+	}
+	f.Issues = append(f.Issues, keyStrengthIssues(f.Algorithm, f.Bits)...)
+	return f, true
+}
+
+// sshKeyInfo returns the algorithm and size of an SSH public key
+func sshKeyInfo(pub ssh.PublicKey) (string, int) {
+	switch pub.Type() {
+	case ssh.KeyAlgoED25519:
+		return "ed25519", 256
+	case ssh.KeyAlgoSKED25519:
+		return "ed25519-sk", 256
+	case ssh.KeyAlgoSKECDSA256:
+		return "ecdsa-sk", 256
+	case ssh.KeyAlgoDSA:
+		return "dsa", 1024
+	}
+	if cryptoPub, ok := pub.(ssh.CryptoPublicKey); ok {
+		algorithm, bits := publicKeyInfo(cryptoPub.CryptoPublicKey())
+		if algorithm != "" {
+			return algorithm, bits
+		}
+	}
+	return pub.Type(), 0
+}
+
+// publicKeyInfo returns the algorithm and size of a crypto public key
+func publicKeyInfo(pub any) (string, int) {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return "rsa", k.N.BitLen()
+	case *ecdsa.PublicKey:
+		return "ecdsa", k.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return "ed25519", 256
+	}
+	return "", 0
+}
+
+// keyStrengthIssues flags deprecated algorithms and short RSA keys
+func keyStrengthIssues(algorithm string, bits int) []string {
+	switch {
+	case algorithm == "dsa":
+		return []string{"weak: DSA is deprecated"}
+	case algorithm == "rsa" && bits > 0 && bits < minRSABits:
+		return []string{fmt.Sprintf("weak: RSA %d bits", bits)}
+	}
+	return nil
+}
+
+// isEncryptedPEM reports whether data holds a passphrase-protected PEM key
+func isEncryptedPEM(data []byte) bool {
+	block, _ := pem.Decode(data)
+	return block != nil && isEncryptedBlock(block)
+}
+
+// isEncryptedBlock reports whether a PEM key block is passphrase-protected:
+// PKCS#8 or a legacy OpenSSL key with a Proc-Type header
+func isEncryptedBlock(block *pem.Block) bool {
+	return block.Type == "ENCRYPTED PRIVATE KEY" || strings.Contains(block.Headers["Proc-Type"], "ENCRYPTED")
+}
+
+// auditPEMFile inspects the certificates and private keys in a PEM file
+func (a *Auditor) auditPEMFile(path string) []Finding {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var findings []Finding
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		switch {
+		case block.Type == "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				findings = append(findings, Finding{Kind: KindCertificate, Name: filepath.Base(path), Path: path,
+					Issues: []string{fmt.Sprintf("unreadable certificate: %v", err)}})
+				continue
+			}
+			f := a.auditCertificate(cert)
+			f.Name, f.Path = filepath.Base(path), path
+			findings = append(findings, f)
+		case strings.HasSuffix(block.Type, "PRIVATE KEY"):
+			findings = append(findings, auditPEMKey(path, block))
+		}
+	}
+	return findings
+}
+
+// auditCertificate checks a certificate's expiry, key and signature
+func (a *Auditor) auditCertificate(cert *x509.Certificate) Finding {
+	expires := cert.NotAfter
+	f := Finding{Kind: KindCertificate, Subject: certSubject(cert), Expires: &expires}
+	f.Algorithm, f.Bits = publicKeyInfo(cert.PublicKey)
+
+	switch {
+	case a.now.After(cert.NotAfter):
+		f.Issues = append(f.Issues, "expired")
+	case cert.NotAfter.Sub(a.now) < ExpiryWarning:
+		days := int(math.Ceil(cert.NotAfter.Sub(a.now).Hours() / 24))
+		f.Issues = append(f.Issues, fmt.Sprintf("expires in %d days", days))
+	case a.now.Before(cert.NotBefore):
+		f.Issues = append(f.Issues, "not valid yet")
+	}
+	f.Issues = append(f.Issues, keyStrengthIssues(f.Algorithm, f.Bits)...)
+	switch cert.SignatureAlgorithm {
+	case x509.MD5WithRSA, x509.SHA1WithRSA, x509.ECDSAWithSHA1, x509.DSAWithSHA1:
+		f.Issues = append(f.Issues, fmt.Sprintf("weak signature: %s", cert.SignatureAlgorithm))
+	}
+	return f
+}
+
+// certSubject returns a certificate's common name, else its full subject
+func certSubject(cert *x509.Certificate) string {
+	if cert.Subject.CommonName != "" {
+		return "CN=" + cert.Subject.CommonName
+	}
+	return cert.Subject.String()
+}
+
+// auditPEMKey inspects a private key in a PEM file
+func auditPEMKey(path string, block *pem.Block) Finding {
+	f := Finding{Kind: KindPrivateKey, Name: filepath.Base(path), Path: path}
+	if isEncryptedBlock(block) {
+		f.Encrypted = boolPtr(true)
+		return f
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "OPENSSH PRIVATE KEY":
+		key, err = ssh.ParseRawPrivateKey(pem.EncodeToMemory(block))
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+
+	// OpenSSH keys say whether they're encrypted only when parsed
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		f.Encrypted = boolPtr(true)
+		if missing.PublicKey != nil {
+			f.Algorithm, f.Bits = sshKeyInfo(missing.PublicKey)
+		}
+		return f
+	}
+	if err != nil {
+		f.Issues = append(f.Issues, fmt.Sprintf("unreadable key: %v", err))
+		return f
+	}
+
+	f.Encrypted = boolPtr(false)
+	f.Issues = append(f.Issues, "unencrypted")
+	if signer, ok := key.(crypto.Signer); ok {
+		f.Algorithm, f.Bits = publicKeyInfo(signer.Public())
+	}
+	f.Issues = append(f.Issues, keyStrengthIssues(f.Algorithm, f.Bits)...)
+	return f
+}
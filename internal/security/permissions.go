@@ -0,0 +1,171 @@
+package security
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// PermissionRule caps the permissions of a path under the home directory
+// and everything in it. A zero mode leaves that kind of entry alone.
+type PermissionRule struct {
+	// Path is relative to the home directory and may contain globs
+	Path     string
+	DirMode  os.FileMode
+	FileMode os.FileMode
+}
+
+// DefaultPermissionRules keep credentials readable by their owner only,
+// which ssh and gpg insist on. Public keys may be read by anyone.
+var DefaultPermissionRules = []PermissionRule{
+	{Path: ".ssh", DirMode: 0700, FileMode: 0600},
+	{Path: ".ssh/*.pub", FileMode: 0644},
+	{Path: ".gnupg", DirMode: 0700, FileMode: 0600},
+	{Path: ".aws", DirMode: 0700, FileMode: 0600},
+	{Path: ".kube", DirMode: 0700, FileMode: 0600},
+}
+
+// ParseMode parses an octal permission string such as "0700"
+func ParseMode(s string) (os.FileMode, error) {
+	if s == "" {
+		return 0, nil
+	}
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("invalid mode %q: want octal permissions such as 0700", s)
+	}
+	return os.FileMode(mode), nil
+}
+
+// PermissionIssue is an entry with more permissions than its rule allows
+type PermissionIssue struct {
+	Path string
+	Mode os.FileMode
+	Want os.FileMode
+}
+
+func (pi PermissionIssue) String() string {
+	return fmt.Sprintf("%s is %04o, want %04o", pi.Path, pi.Mode, pi.Want)
+}
+
+// PermissionPolicy applies permission rules under a home directory. Rules
+// are checked in order and the last one matching a path wins, so later
+// rules can narrow or relax earlier ones.
+type PermissionPolicy struct {
+	homeDir string
+	rules   []PermissionRule
+}
+
+func NewPermissionPolicy(homeDir string, rules []PermissionRule) *PermissionPolicy {
+	return &PermissionPolicy{homeDir: filepath.Clean(homeDir), rules: rules}
+}
+
+// Allowed returns the most permissive mode the policy allows for path, and
+// false if no rule covers it
+func (pp *PermissionPolicy) Allowed(path string, isDir bool) (os.FileMode, bool) {
+	rel, err := filepath.Rel(pp.homeDir, path)
+	if err != nil || !filepath.IsLocal(rel) {
+		return 0, false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+
+	var allowed os.FileMode
+	for _, rule := range pp.rules {
+		mode := rule.FileMode
+		if isDir {
+			mode = rule.DirMode
+		}
+		if mode != 0 && ruleMatches(rule.Path, parts) {
+			allowed = mode
+		}
+	}
+	return allowed, allowed != 0
+}
+
+// ruleMatches reports whether a rule's path is parts or one of its parents
+func ruleMatches(rulePath string, parts []string) bool {
+	rulePath = strings.TrimPrefix(filepath.ToSlash(rulePath), "~/")
+	pattern := strings.Split(strings.Trim(rulePath, "/"), "/")
+	if len(pattern) > len(parts) {
+		return false
+	}
+	for i, elem := range pattern {
+		if ok, _ := filepath.Match(elem, parts[i]); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply removes permissions the policy doesn't allow from path, everything
+// under it and the parent directories between it and the home directory.
+// Symlinks are left alone. It returns how many entries were changed.
+func (pp *PermissionPolicy) Apply(path string) (int, error) {
+	changed := 0
+	fix := func(p string, info fs.FileInfo) error {
+		if info.Mode()&os.ModeSymlink != 0 {
+			return nil
+		}
+		want, ok := pp.Allowed(p, info.IsDir())
+		if !ok {
+			return nil
+		}
+		if mode := info.Mode().Perm(); mode&^want != 0 {
+			if err := os.Chmod(p, mode&want); err != nil {
+				return err
+			}
+			changed++
+		}
+		return nil
+	}
+
+	for dir := filepath.Dir(path); dir != pp.homeDir && strings.HasPrefix(dir, pp.homeDir); dir = filepath.Dir(dir) {
+		if info, err := os.Lstat(dir); err == nil {
+			if err := fix(dir, info); err != nil {
+				return changed, err
+			}
+		}
+	}
+
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fix(p, info)
+	})
+	return changed, err
+}
+
+// Audit lists the entries under the rules' paths with more permissions
+// than the policy allows
+func (pp *PermissionPolicy) Audit() []PermissionIssue {
+	var issues []PermissionIssue
+	seen := make(map[string]bool)
+	for _, rule := range pp.rules {
+		matches, _ := filepath.Glob(filepath.Join(pp.homeDir, strings.TrimPrefix(rule.Path, "~/")))
+		for _, match := range matches {
+			_ = filepath.WalkDir(match, func(p string, d fs.DirEntry, err error) error {
+				if err != nil || seen[p] || d.Type()&os.ModeSymlink != 0 {
+					return nil
+				}
+				seen[p] = true
+				info, err := d.Info()
+				if err != nil {
+					return nil
+				}
+				if want, ok := pp.Allowed(p, d.IsDir()); ok && info.Mode().Perm()&^want != 0 {
+					issues = append(issues, PermissionIssue{Path: p, Mode: info.Mode().Perm(), Want: want})
+				}
+				return nil
+			})
+		}
+	}
+	return issues
+}
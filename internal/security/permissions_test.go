@@ -0,0 +1,98 @@
+package security
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPermissionPolicyAllowed(t *testing.T) {
+	rules := append(DefaultPermissionRules, PermissionRule{Path: "~/.aws/config", FileMode: 0644})
+	policy := NewPermissionPolicy("/home/me", rules)
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  os.FileMode
+		ok    bool
+	}{
+		{"/home/me/.ssh", true, 0700, true},
+		{"/home/me/.ssh/id_ed25519", false, 0600, true},
+		{"/home/me/.ssh/id_ed25519.pub", false, 0644, true},
+		{"/home/me/.gnupg/private-keys-v1.d", true, 0700, true},
+		{"/home/me/.aws/credentials", false, 0600, true},
+		{"/home/me/.aws/config", false, 0644, true},
+		{"/home/me/.kube/config", false, 0600, true},
+		{"/home/me/.zshrc", false, 0, false},
+		{"/home/me/.sshrc", false, 0, false},
+		{"/etc/ssh/ssh_config", false, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := policy.Allowed(tt.path, tt.isDir)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Allowed(%q) = %04o, %v, want %04o, %v", tt.path, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestPermissionPolicyApplyAndAudit(t *testing.T) {
+	home := t.TempDir()
+	ssh := filepath.Join(home, ".ssh")
+	os.MkdirAll(filepath.Join(ssh, "sockets"), 0755)
+	os.WriteFile(filepath.Join(ssh, "id_ed25519"), []byte("key"), 0644)
+	os.WriteFile(filepath.Join(ssh, "id_ed25519.pub"), []byte("pub"), 0644)
+	os.WriteFile(filepath.Join(ssh, "config"), []byte("Host *"), 0400)
+	os.WriteFile(filepath.Join(home, ".zshrc"), []byte(""), 0644)
+	os.Symlink(filepath.Join(home, ".zshrc"), filepath.Join(ssh, "link"))
+
+	policy := NewPermissionPolicy(home, DefaultPermissionRules)
+
+	var paths []string
+	for _, issue := range policy.Audit() {
+		paths = append(paths, issue.Path)
+	}
+	want := []string{ssh, filepath.Join(ssh, "id_ed25519"), filepath.Join(ssh, "sockets")}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("Audit() = %v, want %v", paths, want)
+	}
+
+	// Restoring one key fixes it and the directory created for it
+	changed, err := policy.Apply(filepath.Join(ssh, "id_ed25519"))
+	if err != nil || changed != 2 {
+		t.Fatalf("Apply() = %d, %v", changed, err)
+	}
+	for path, want := range map[string]os.FileMode{
+		ssh:                                  0700,
+		filepath.Join(ssh, "id_ed25519"):     0600,
+		filepath.Join(ssh, "id_ed25519.pub"): 0644,
+		filepath.Join(ssh, "config"):         0400, // stricter than needed is fine
+		filepath.Join(ssh, "sockets"):        0755,
+		filepath.Join(home, ".zshrc"):        0644,
+	} {
+		info, _ := os.Stat(path)
+		if info.Mode().Perm() != want {
+			t.Errorf("%s is %04o, want %04o", path, info.Mode().Perm(), want)
+		}
+	}
+
+	if _, err := policy.Apply(ssh); err != nil {
+		t.Fatal(err)
+	}
+	if issues := policy.Audit(); len(issues) != 0 {
+		t.Errorf("Expected no issues after Apply, got %v", issues)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for s, want := range map[string]os.FileMode{"0700": 0700, "600": 0600, "": 0} {
+		if got, err := ParseMode(s); err != nil || got != want {
+			t.Errorf("ParseMode(%q) = %04o, %v", s, got, err)
+		}
+	}
+	for _, s := range []string{"0800", "rwx", "01777"} {
+		if _, err := ParseMode(s); err == nil {
+			t.Errorf("ParseMode(%q) should fail", s)
+		}
+	}
+}
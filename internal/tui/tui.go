@@ -5,6 +5,7 @@ package tui
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/charmbracelet/huh"
@@ -15,21 +16,58 @@ import (
 type RestoreOptions struct {
 	RestoreFiles         bool
 	RestoreMacOSDefaults bool
+	RestoreDesktop       bool
+	ImportGPG            bool
+	EditorSettings       []string
 	InstallHomebrew      bool
+	StartServices        bool
+	InstallSuggested     bool
+	InstallSystem        bool
+	InstallToolchains    bool
+	InstallGo            bool
+	LanguagePackages     []string
+	ExactVersions        bool
 	InstallMAS           bool
 	InstallVSCode        bool
 	InstallNPM           bool
 	RestoreShellHistory  bool
+	RestoreGitRepos      bool
 }
 
 // AvailableOptions indicates which restore options are available
 type AvailableOptions struct {
 	HasBrewfile      bool
+	HasServices      bool
+	HasSuggested     bool
+	HasSystem        bool
+	HasToolchains    bool
+	HasGo            bool
+	LanguageManagers []LanguageOption
+	HasLock          bool
 	HasMAS           bool
 	HasVSCode        bool
 	HasNPM           bool
 	HasMacOSDefaults bool
+	HasDesktop       bool
+	HasGPG           bool
+	Editors          []EditorOption
 	HasShellHistory  bool
+	HasGitRepos      bool
+}
+
+// LanguageOption is a language package list that can be installed
+type LanguageOption struct {
+	Name    string
+	Label   string
+	Count   int
+	Default bool
+}
+
+// EditorOption is an editor whose settings can be restored
+type EditorOption struct {
+	Name  string
+	Label string
+	Count int
 }
 
 // RestoreOptionsForm presents an interactive multi-select form for restore options
@@ -50,14 +88,60 @@ func RestoreOptionsForm(available AvailableOptions) (RestoreOptions, error) {
 		options = append(options, huh.NewOption("macOS defaults", "macos").Selected(true))
 	}
 
+	if available.HasDesktop {
+		options = append(options, huh.NewOption("Desktop settings (GNOME/KDE)", "desktop").Selected(true))
+	}
+
+	if available.HasGPG {
+		options = append(options, huh.NewOption("GPG keys and trust", "gpg").Selected(true))
+	}
+
+	for _, editor := range available.Editors {
+		label := fmt.Sprintf("%s settings (%d items)", editor.Label, editor.Count)
+		options = append(options, huh.NewOption(label, "editor:"+editor.Name).Selected(true))
+	}
+
 	if available.HasShellHistory {
 		options = append(options, huh.NewOption("Shell history", "history").Selected(true))
 	}
 
+	if available.HasGitRepos {
+		options = append(options, huh.NewOption("Git repositories (clone + saved work)", "git").Selected(false))
+	}
+
 	if available.HasBrewfile {
 		options = append(options, huh.NewOption("Homebrew packages", "brew").Selected(true))
 	}
 
+	if available.HasServices {
+		options = append(options, huh.NewOption("Homebrew services that were running", "services").Selected(true))
+	}
+
+	if available.HasSuggested {
+		options = append(options, huh.NewOption("Homebrew casks for manually installed apps", "casks").Selected(false))
+	}
+
+	if available.HasSystem {
+		options = append(options, huh.NewOption("System packages (apt, dnf, pacman, flatpak, snap)", "system").Selected(true))
+	}
+
+	if available.HasToolchains {
+		options = append(options, huh.NewOption("Language runtimes (mise, asdf, nvm, pyenv, rbenv, rustup)", "toolchains").Selected(true))
+	}
+
+	if available.HasGo {
+		options = append(options, huh.NewOption("Go binaries (go install)", "go").Selected(true))
+	}
+
+	for _, lang := range available.LanguageManagers {
+		label := fmt.Sprintf("%s (%d)", lang.Label, lang.Count)
+		options = append(options, huh.NewOption(label, "lang:"+lang.Name).Selected(lang.Default))
+	}
+
+	if available.HasLock {
+		options = append(options, huh.NewOption("Exact package versions from the backup (where possible)", "exact").Selected(false))
+	}
+
 	if available.HasMAS {
 		options = append(options, huh.NewOption("Mac App Store apps", "mas").Selected(false))
 	}
@@ -91,10 +175,35 @@ func RestoreOptionsForm(available AvailableOptions) (RestoreOptions, error) {
 			opts.RestoreFiles = true
 		case "macos":
 			opts.RestoreMacOSDefaults = true
+		case "desktop":
+			opts.RestoreDesktop = true
+		case "gpg":
+			opts.ImportGPG = true
 		case "history":
 			opts.RestoreShellHistory = true
+		case "git":
+			opts.RestoreGitRepos = true
 		case "brew":
 			opts.InstallHomebrew = true
+		case "services":
+			opts.StartServices = true
+		case "casks":
+			opts.InstallSuggested = true
+		case "system":
+			opts.InstallSystem = true
+		case "toolchains":
+			opts.InstallToolchains = true
+		case "go":
+			opts.InstallGo = true
+		case "exact":
+			opts.ExactVersions = true
+		default:
+			if name, ok := strings.CutPrefix(sel, "lang:"); ok {
+				opts.LanguagePackages = append(opts.LanguagePackages, name)
+			}
+			if name, ok := strings.CutPrefix(sel, "editor:"); ok {
+				opts.EditorSettings = append(opts.EditorSettings, name)
+			}
 		case "mas":
 			opts.InstallMAS = true
 		case "vscode":
@@ -304,24 +413,111 @@ func BrewPackagePickerForm(items []BrewPackageItem) ([]BrewPackageItem, error) {
 		return items, nil
 	}
 
-	var selected []string
-	itemMap := make(map[string]BrewPackageItem)
+	// One page per tap, so a long Brewfile is picked a tap at a time
+	groups := groupBrewItems(items)
+	selected := make([][]int, len(groups))
+	var pages []*huh.Group
+	for g, group := range groups {
+		var options []huh.Option[int]
+		for _, idx := range group.items {
+			options = append(options, huh.NewOption(items[idx].Label, idx).Selected(true))
+		}
+		pages = append(pages, huh.NewGroup(
+			huh.NewMultiSelect[int]().
+				Title(fmt.Sprintf("%s (%d/%d)", group.title, g+1, len(groups))).
+				Description("Space to toggle, Enter for the next page, / to filter").
+				Options(options...).
+				Height(20).
+				Value(&selected[g]),
+		))
+	}
 
-	// Build options for each package
-	var options []huh.Option[string]
+	if err := ApplyTheme(huh.NewForm(pages...)).Run(); err != nil {
+		return nil, err
+	}
+
+	// Keep the Brewfile's order
+	picked := make(map[int]bool)
+	for _, indexes := range selected {
+		for _, idx := range indexes {
+			picked[idx] = true
+		}
+	}
+	var result []BrewPackageItem
 	for i, item := range items {
-		key := fmt.Sprintf("%d", i)
-		itemMap[key] = item
-		options = append(options, huh.NewOption(item.Label, key).Selected(true))
+		if picked[i] {
+			result = append(result, item)
+		}
+	}
+
+	return result, nil
+}
+
+type brewItemGroup struct {
+	title string
+	items []int
+}
+
+// groupBrewItems splits items by Group: Homebrew's own formulae and casks
+// first, then third-party taps by name, then everything else as it appears
+func groupBrewItems(items []BrewPackageItem) []brewItemGroup {
+	var groups []brewItemGroup
+	index := make(map[string]int)
+	for i, item := range items {
+		g, ok := index[item.Group]
+		if !ok {
+			g = len(groups)
+			index[item.Group] = g
+			groups = append(groups, brewItemGroup{title: item.Group})
+		}
+		groups[g].items = append(groups[g].items, i)
+	}
+
+	rank := func(title string) int {
+		switch {
+		case title == "homebrew/core":
+			return 0
+		case title == "homebrew/cask":
+			return 1
+		case strings.Contains(title, "/"):
+			return 2
+		}
+		return 3
+	}
+	sort.SliceStable(groups, func(a, b int) bool {
+		ra, rb := rank(groups[a].title), rank(groups[b].title)
+		if ra != rb {
+			return ra < rb
+		}
+		return ra == 2 && groups[a].title < groups[b].title
+	})
+	return groups
+}
+
+// DesktopSectionItem is a dconf path, gsettings schema or KDE file for selection
+type DesktopSectionItem struct {
+	ID    string
+	Label string
+}
+
+// DesktopSectionPickerForm lets the user choose which desktop settings to restore
+func DesktopSectionPickerForm(items []DesktopSectionItem) ([]string, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	var selected []string
+	var options []huh.Option[string]
+	for _, item := range items {
+		options = append(options, huh.NewOption(item.Label, item.ID).Selected(true))
 	}
 
 	form := ApplyTheme(huh.NewForm(
 		huh.NewGroup(
 			huh.NewMultiSelect[string]().
-				Title("Select packages to install").
-				Description("Space to toggle, Enter to confirm, / to filter").
+				Title("Select desktop settings to restore").
+				Description("Space to toggle, Enter to confirm").
 				Options(options...).
-				Height(20).
 				Value(&selected),
 		),
 	))
@@ -330,21 +526,78 @@ func BrewPackagePickerForm(items []BrewPackageItem) ([]BrewPackageItem, error) {
 		return nil, err
 	}
 
-	// Map selected keys back to BrewPackageItem
-	var result []BrewPackageItem
-	for _, key := range selected {
-		if item, ok := itemMap[key]; ok {
-			result = append(result, item)
-		}
-	}
-
-	return result, nil
+	return selected, nil
 }
 
 // BrewPackageItem represents a brew package for selection
 type BrewPackageItem struct {
-	Type    string // "tap", "brew", "cask", "mas"
-	Name    string
-	Label   string // display label
-	RawLine string
+	Type  string // "tap", "brew", "cask", "mas", ...
+	Name  string
+	Label string // display label
+	Group string // the tap, or a heading for entries that don't come from one
+}
+
+// Conflict resolutions returned by the conflict forms
+const (
+	ConflictAsk       = "ask"
+	ConflictOverwrite = "overwrite"
+	ConflictKeep      = "keep"
+	ConflictSaveAs    = "save-as"
+	ConflictMerge     = "merge"
+)
+
+// ConflictStrategyForm asks how to handle local files that differ from the backup.
+// It returns ConflictAsk to decide file by file, or a resolution for all of them.
+func ConflictStrategyForm(count int) (string, error) {
+	strategy := ConflictAsk
+
+	form := ApplyTheme(huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title(fmt.Sprintf("%d local file(s) differ from the backup", count)).
+				Options(
+					huh.NewOption("Decide per file (show diffs)", ConflictAsk),
+					huh.NewOption("Overwrite all with backup", ConflictOverwrite),
+					huh.NewOption("Keep all local files", ConflictKeep),
+					huh.NewOption("Save backup copies as .stash-restored", ConflictSaveAs),
+				).
+				Value(&strategy),
+		),
+	))
+
+	if err := form.Run(); err != nil {
+		return "", err
+	}
+
+	return strategy, nil
+}
+
+// ConflictResolutionForm asks what to do with a single conflicting file
+func ConflictResolutionForm(path, description string, canMerge bool) (string, error) {
+	resolution := ConflictOverwrite
+
+	options := []huh.Option[string]{
+		huh.NewOption("Overwrite with backup", ConflictOverwrite),
+		huh.NewOption("Keep local file", ConflictKeep),
+		huh.NewOption("Save backup as .stash-restored", ConflictSaveAs),
+	}
+	if canMerge {
+		options = append(options, huh.NewOption("Merge with conflict markers", ConflictMerge))
+	}
+
+	form := ApplyTheme(huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title(path).
+				Description(description).
+				Options(options...).
+				Value(&resolution),
+		),
+	))
+
+	if err := form.Run(); err != nil {
+		return "", err
+	}
+
+	return resolution, nil
 }
@@ -100,3 +100,42 @@ func containsStringHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestGroupBrewItems(t *testing.T) {
+	items := []BrewPackageItem{
+		{Type: "tap", Name: "hashicorp/tap", Group: "hashicorp/tap"},
+		{Type: "brew", Name: "wget", Group: "homebrew/core"},
+		{Type: "mas", Name: "Xcode", Group: "App Store"},
+		{Type: "brew", Name: "hashicorp/tap/terraform", Group: "hashicorp/tap"},
+		{Type: "cask", Name: "firefox", Group: "homebrew/cask"},
+		{Type: "cask", Name: "homebrew/cask-fonts/font-fira-code", Group: "homebrew/cask-fonts"},
+		{Type: "vscode", Name: "golang.go", Group: "VS Code extensions"},
+		{Type: "brew", Name: "jq", Group: "homebrew/core"},
+	}
+
+	var got []string
+	for _, group := range groupBrewItems(items) {
+		for _, idx := range group.items {
+			got = append(got, group.title+": "+items[idx].Name)
+		}
+	}
+	want := []string{
+		"homebrew/core: wget",
+		"homebrew/core: jq",
+		"homebrew/cask: firefox",
+		"hashicorp/tap: hashicorp/tap",
+		"hashicorp/tap: hashicorp/tap/terraform",
+		"homebrew/cask-fonts: homebrew/cask-fonts/font-fira-code",
+		"App Store: Xcode",
+		"VS Code extensions: golang.go",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("groupBrewItems() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("groupBrewItems() = %q, want %q", got, want)
+			break
+		}
+	}
+}